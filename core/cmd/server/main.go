@@ -34,9 +34,11 @@ import (
 	"github.com/alpkeskin/rota/core/internal/api"
 	"github.com/alpkeskin/rota/core/internal/config"
 	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/proxy"
 	"github.com/alpkeskin/rota/core/internal/repository"
 	"github.com/alpkeskin/rota/core/internal/services"
+	"github.com/alpkeskin/rota/core/internal/tracing"
 	"github.com/alpkeskin/rota/core/pkg/logger"
 )
 
@@ -58,6 +60,7 @@ func run() error {
 	log := logger.New(cfg.LogLevel)
 	log.Info("starting application",
 		"proxy_port", cfg.ProxyPort,
+		"socks5_port", cfg.SOCKS5Port,
 		"api_port", cfg.APIPort,
 	)
 
@@ -78,6 +81,19 @@ func run() error {
 	proxyRepo := repository.NewProxyRepository(db)
 	settingsRepo := repository.NewSettingsRepository(db)
 	logRepo := repository.NewLogRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	clientRepo := repository.NewClientRepository(db)
+	poolRepo := repository.NewPoolRepository(db)
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+
+	// Bootstrap the first dashboard admin from ROTA_ADMIN_USER/ROTA_ADMIN_PASSWORD
+	created, err := userRepo.EnsureAdmin(ctx, cfg.AdminUser, cfg.AdminPass)
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap admin user: %w", err)
+	}
+	if created {
+		log.Info("created initial admin user", "username", cfg.AdminUser)
+	}
 
 	// Add database logging hook for proxy logs
 	log.AddHook(func(level, message string, attrs map[string]any) {
@@ -134,26 +150,66 @@ func run() error {
 	}
 	defer logCleanupService.Stop()
 
+	// Open GeoIP databases for locating proxy exit IPs (optional)
+	geo, err := proxy.OpenGeoIP(cfg.GeoIPDBPath, cfg.GeoIPASNDBPath)
+	if err != nil {
+		log.Warn("failed to open GeoIP database - proxy locations will not be resolved", "error", err)
+	}
+	defer geo.Close()
+
+	// Export traces when an OTLP endpoint is configured; W3C traceparent
+	// headers are propagated either way
+	shutdownTracing, err := tracing.Setup(ctx, cfg.OTLPEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	if cfg.OTLPEndpoint != "" {
+		log.Info("exporting traces", "endpoint", cfg.OTLPEndpoint)
+	}
+
+	// Create health checker (shared by the API test endpoints and the scheduler)
+	healthChecker := proxy.NewHealthChecker(proxyRepo, settingsRepo, proxy.NewUsageTracker(proxyRepo, log), geo, log)
+	healthScheduler := proxy.NewHealthScheduler(healthChecker, settingsRepo, healthCheckRepo, log)
+
 	// Create servers
-	proxyServer, err := proxy.New(cfg.ProxyPort, log, proxyRepo, settingsRepo)
+	proxyServer, err := proxy.New(cfg.ProxyPort, cfg.SOCKS5Port, log, proxyRepo, settingsRepo, clientRepo, poolRepo, healthScheduler)
 	if err != nil {
 		return fmt.Errorf("failed to create proxy server: %w", err)
 	}
-	apiServer := api.New(cfg, log, db)
+
+	// Settings saved through the API are applied to every running subsystem
+	settingsBus := services.NewSettingsBus(log)
+	apiServer := api.New(cfg, log, db, healthChecker, settingsBus)
+
+	settingsBus.Subscribe("proxy_server", proxyServer.ApplySettings)
+	settingsBus.Subscribe("health_checker", func(ctx context.Context, settings *models.Settings) error {
+		healthScheduler.UpdateSettings(settings.HealthCheck)
+		return nil
+	})
+	settingsBus.Subscribe("log_cleanup", func(ctx context.Context, settings *models.Settings) error {
+		return logCleanupService.UpdateSettings(ctx, settings.LogRetention)
+	})
 
 	// Create and start Webshare sync scheduler
-	var webshareScheduler *services.WebshareScheduler
-	if cfg.WebshareAPIKey != "" && cfg.WebshareSyncIntervalSeconds > 0 {
-		webshareSyncService := apiServer.GetWebshareSyncService()
-		if webshareSyncService != nil {
-			webshareScheduler = services.NewWebshareScheduler(
-				webshareSyncService,
-				cfg.WebshareSyncIntervalSeconds,
-				log,
-			)
-			webshareScheduler.Start(ctx)
-			defer webshareScheduler.Stop()
+	// The interval from settings, if set, overrides WEBSHARE_SYNC_INTERVAL_SECONDS
+	if webshareSyncService := apiServer.GetWebshareSyncService(); webshareSyncService != nil {
+		webshareScheduler := services.NewWebshareScheduler(
+			webshareSyncService,
+			cfg.WebshareSyncIntervalSeconds,
+			log,
+		)
+		if settings, err := settingsRepo.GetAll(ctx); err != nil {
+			log.Warn("failed to load webshare settings", "error", err)
+		} else {
+			webshareScheduler.UpdateSettings(settings.Webshare)
 		}
+		webshareScheduler.Start(ctx)
+		defer webshareScheduler.Stop()
+
+		settingsBus.Subscribe("webshare_scheduler", func(ctx context.Context, settings *models.Settings) error {
+			webshareScheduler.UpdateSettings(settings.Webshare)
+			return nil
+		})
 	}
 
 	// Set proxy server reference in API server for reload functionality
@@ -214,6 +270,11 @@ func run() error {
 	shutdownWg.Wait()
 	close(shutdownErrors)
 
+	// Flush spans of the last requests
+	if err := shutdownTracing(ctx); err != nil {
+		log.Warn("failed to flush traces", "error", err)
+	}
+
 	// Collect any shutdown errors
 	var shutdownErr error
 	for err := range shutdownErrors {
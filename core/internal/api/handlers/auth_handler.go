@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
 	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/repository"
 	"github.com/alpkeskin/rota/core/pkg/logger"
@@ -13,26 +14,22 @@ import (
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	settingsRepo *repository.SettingsRepository
-	logger       *logger.Logger
-	jwtSecret    []byte
-	adminUser    string
-	adminPass    string
+	userRepo  *repository.UserRepository
+	logger    *logger.Logger
+	jwtSecret []byte
 }
 
 // NewAuthHandler creates a new AuthHandler
-func NewAuthHandler(settingsRepo *repository.SettingsRepository, log *logger.Logger, jwtSecret, adminUser, adminPass string) *AuthHandler {
+func NewAuthHandler(userRepo *repository.UserRepository, log *logger.Logger, jwtSecret string) *AuthHandler {
 	return &AuthHandler{
-		settingsRepo: settingsRepo,
-		logger:       log,
-		jwtSecret:    []byte(jwtSecret),
-		adminUser:    adminUser,
-		adminPass:    adminPass,
+		userRepo:  userRepo,
+		logger:    log,
+		jwtSecret: []byte(jwtSecret),
 	}
 }
 
 // Login handles user login for dashboard/API access
-// This uses environment variable credentials (ROTA_ADMIN_USER, ROTA_ADMIN_PASSWORD)
+// Credentials are checked against the users table (bootstrapped from ROTA_ADMIN_USER/ROTA_ADMIN_PASSWORD)
 // Note: Settings authentication is for proxy server, not dashboard login
 //	@Summary		User login
 //	@Description	Authenticate user and receive JWT token
@@ -51,16 +48,23 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify credentials against environment variables
-	// Dashboard login is always enabled and uses ENV credentials
-	if req.Username != h.adminUser || req.Password != h.adminPass {
+	// Verify credentials against the users table
+	user, err := h.userRepo.Authenticate(r.Context(), req.Username, req.Password)
+	if err != nil {
+		h.logger.Error("failed to verify credentials", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Internal server error")
+		return
+	}
+
+	if user == nil {
+		metrics.AuthFailures.WithLabelValues("api", "invalid_credentials").Inc()
 		h.logger.Warn("failed login attempt", "username", req.Username)
 		h.errorResponse(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
 	// Generate JWT token
-	token, err := h.generateToken(req.Username)
+	token, err := h.generateToken(user)
 	if err != nil {
 		h.logger.Error("failed to generate token", "error", err)
 		h.errorResponse(w, http.StatusInternalServerError, "Internal server error")
@@ -71,18 +75,23 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	response := models.LoginResponse{
 		Token: token,
 		User: models.UserInfoResponse{
-			Username: req.Username,
+			Username: user.Username,
+			Role:     user.Role,
 		},
 	}
 
-	h.logger.Info("successful login", "username", req.Username)
+	h.logger.Info("successful login", "username", user.Username, "role", user.Role)
 	h.jsonResponse(w, http.StatusOK, response)
 }
 
 // generateToken generates a JWT token for the user
-func (h *AuthHandler) generateToken(username string) (string, error) {
+// The role claim is informational, for the dashboard only; requests are authorized
+// by the user's current role
+func (h *AuthHandler) generateToken(user *models.User) (string, error) {
 	claims := jwt.MapClaims{
-		"username": username,
+		"sub":      user.ID,
+		"username": user.Username,
+		"role":     string(user.Role),
 		"exp":      time.Now().Add(24 * time.Hour).Unix(),
 		"iat":      time.Now().Unix(),
 	}
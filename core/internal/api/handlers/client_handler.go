@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// ClientRefresher reloads proxy client credentials in the running proxy server
+type ClientRefresher interface {
+	RefreshClients(ctx context.Context) error
+}
+
+// ClientHandler handles proxy client credential endpoints
+type ClientHandler struct {
+	clientRepo *repository.ClientRepository
+	refresher  ClientRefresher
+	logger     *logger.Logger
+}
+
+// NewClientHandler creates a new ClientHandler
+func NewClientHandler(clientRepo *repository.ClientRepository, refresher ClientRefresher, log *logger.Logger) *ClientHandler {
+	return &ClientHandler{
+		clientRepo: clientRepo,
+		refresher:  refresher,
+		logger:     log,
+	}
+}
+
+// List handles proxy client listing
+//
+//	@Summary		List proxy clients
+//	@Description	Get all proxy client credentials with today's usage
+//	@Tags			clients
+//	@Produce		json
+//	@Success		200	{array}		models.ProxyClient	"List of proxy clients"
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/clients [get]
+func (h *ClientHandler) List(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.clientRepo.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list proxy clients", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list proxy clients")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, clients)
+}
+
+// Get handles fetching a single proxy client
+//
+//	@Summary		Get proxy client
+//	@Description	Get a proxy client credential by ID with today's usage
+//	@Tags			clients
+//	@Produce		json
+//	@Param			id	path		int					true	"Client ID"
+//	@Success		200	{object}	models.ProxyClient	"Proxy client"
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/clients/{id} [get]
+func (h *ClientHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid client ID")
+		return
+	}
+
+	client, err := h.clientRepo.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to get proxy client", "error", err, "client_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get proxy client")
+		return
+	}
+
+	if client == nil {
+		h.errorResponse(w, http.StatusNotFound, "Client not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, client)
+}
+
+// Create handles proxy client creation
+//
+//	@Summary		Create proxy client
+//	@Description	Create a proxy credential with its own rate limit and daily quotas (0 = unlimited)
+//	@Tags			clients
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateProxyClientRequest	true	"Client details"
+//	@Success		201		{object}	models.ProxyClient				"Created client"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/clients [post]
+func (h *ClientHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateProxyClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" || strings.Contains(req.Username, ":") {
+		h.errorResponse(w, http.StatusBadRequest, "Username is required and must not contain ':'")
+		return
+	}
+
+	if len(req.Password) < minPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+
+	if req.RequestsPerMinute < 0 || req.DailyRequestQuota < 0 || req.DailyByteQuota < 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Limits and quotas must not be negative")
+		return
+	}
+
+	client, err := h.clientRepo.Create(r.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			h.errorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Error("failed to create proxy client", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to create proxy client")
+		return
+	}
+
+	h.refreshClients(r.Context())
+
+	h.logger.Info("proxy client created", "username", client.Username)
+	h.jsonResponse(w, http.StatusCreated, client)
+}
+
+// Update handles proxy client updates
+//
+//	@Summary		Update proxy client
+//	@Description	Change a proxy client's password, limits, quotas or enabled flag
+//	@Tags			clients
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Client ID"
+//	@Param			request	body		models.UpdateProxyClientRequest	true	"Updated client details"
+//	@Success		200		{object}	models.ProxyClient				"Updated client"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/clients/{id} [put]
+func (h *ClientHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid client ID")
+		return
+	}
+
+	var req models.UpdateProxyClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Password != nil && len(*req.Password) < minPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+
+	if (req.RequestsPerMinute != nil && *req.RequestsPerMinute < 0) ||
+		(req.DailyRequestQuota != nil && *req.DailyRequestQuota < 0) ||
+		(req.DailyByteQuota != nil && *req.DailyByteQuota < 0) {
+		h.errorResponse(w, http.StatusBadRequest, "Limits and quotas must not be negative")
+		return
+	}
+
+	client, err := h.clientRepo.Update(r.Context(), id, req)
+	if err != nil {
+		h.logger.Error("failed to update proxy client", "error", err, "client_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to update proxy client")
+		return
+	}
+
+	if client == nil {
+		h.errorResponse(w, http.StatusNotFound, "Client not found")
+		return
+	}
+
+	h.refreshClients(r.Context())
+
+	h.logger.Info("proxy client updated", "username", client.Username)
+	h.jsonResponse(w, http.StatusOK, client)
+}
+
+// Delete handles proxy client deletion
+//
+//	@Summary		Delete proxy client
+//	@Description	Delete a proxy client credential and its usage counters
+//	@Tags			clients
+//	@Param			id	path	int	true	"Client ID"
+//	@Success		204	"Successfully deleted"
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/clients/{id} [delete]
+func (h *ClientHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid client ID")
+		return
+	}
+
+	if err := h.clientRepo.Delete(r.Context(), id); err != nil {
+		h.logger.Error("failed to delete proxy client", "error", err, "client_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to delete proxy client")
+		return
+	}
+
+	h.refreshClients(r.Context())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refreshClients pushes credential changes to the running proxy server
+// Failures are only logged; the proxy server also refreshes periodically
+func (h *ClientHandler) refreshClients(ctx context.Context) {
+	if err := h.refresher.RefreshClients(ctx); err != nil {
+		h.logger.Warn("failed to refresh proxy clients", "error", err)
+	}
+}
+
+// jsonResponse sends a JSON response
+func (h *ClientHandler) jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// errorResponse sends an error JSON response
+func (h *ClientHandler) errorResponse(w http.ResponseWriter, statusCode int, message string) {
+	response := models.ErrorResponse{
+		Error: message,
+	}
+	h.jsonResponse(w, statusCode, response)
+}
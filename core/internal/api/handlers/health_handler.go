@@ -3,29 +3,44 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/alpkeskin/rota/core/internal/database"
 	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/repository"
 	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/go-chi/chi/v5"
 )
 
 var startTime = time.Now()
 
+// healthHistoryWindows are the selectable windows of a proxy's health history,
+// up to the 90 day retention of proxy_health_checks
+var healthHistoryWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
 // HealthHandler handles health and status endpoints
 type HealthHandler struct {
-	db        *database.DB
-	proxyRepo *repository.ProxyRepository
-	logger    *logger.Logger
+	db              *database.DB
+	proxyRepo       *repository.ProxyRepository
+	healthCheckRepo *repository.HealthCheckRepository
+	logger          *logger.Logger
 }
 
 // NewHealthHandler creates a new HealthHandler
-func NewHealthHandler(db *database.DB, proxyRepo *repository.ProxyRepository, log *logger.Logger) *HealthHandler {
+func NewHealthHandler(db *database.DB, proxyRepo *repository.ProxyRepository, healthCheckRepo *repository.HealthCheckRepository, log *logger.Logger) *HealthHandler {
 	return &HealthHandler{
-		db:        db,
-		proxyRepo: proxyRepo,
-		logger:    log,
+		db:              db,
+		proxyRepo:       proxyRepo,
+		healthCheckRepo: healthCheckRepo,
+		logger:          log,
 	}
 }
 
@@ -137,6 +152,90 @@ func (h *HealthHandler) DatabaseStats(w http.ResponseWriter, r *http.Request) {
 	h.jsonResponse(w, http.StatusOK, response)
 }
 
+// HealthCheckRuns handles listing scheduled proxy health check runs
+//
+//	@Summary		Health check runs
+//	@Description	Get the most recent scheduled proxy health check runs
+//	@Tags			health
+//	@Produce		json
+//	@Param			limit	query		int						false	"Number of runs"	default(20)
+//	@Success		200		{array}		models.HealthCheckRun	"Health check runs, newest first"
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/healthcheck/runs [get]
+func (h *HealthHandler) HealthCheckRuns(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	runs, err := h.healthCheckRepo.ListRuns(r.Context(), limit)
+	if err != nil {
+		h.logger.Error("failed to list health check runs", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list health check runs")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, runs)
+}
+
+// ProxyHealthHistory handles a proxy's health check timeline
+//
+//	@Summary		Proxy health history
+//	@Description	Get a proxy's health check results with uptime and latency percentiles over a time window
+//	@Tags			health
+//	@Produce		json
+//	@Param			id		path		int							true	"Proxy ID"
+//	@Param			window	query		string						false	"Time window (1h, 6h, 24h, 7d, 30d, 90d)"	default(24h)
+//	@Param			limit	query		int							false	"Number of results"							default(100)
+//	@Success		200		{object}	models.ProxyHealthHistory	"Health history"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/proxies/{id}/health-history [get]
+func (h *HealthHandler) ProxyHealthHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid proxy ID")
+		return
+	}
+
+	windowName := r.URL.Query().Get("window")
+	if windowName == "" {
+		windowName = "24h"
+	}
+	window, ok := healthHistoryWindows[windowName]
+	if !ok {
+		h.errorResponse(w, http.StatusBadRequest, "window must be one of 1h, 6h, 24h, 7d, 30d, 90d")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	proxy, err := h.proxyRepo.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to get proxy", "error", err, "proxy_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get proxy health history")
+		return
+	}
+	if proxy == nil {
+		h.errorResponse(w, http.StatusNotFound, "Proxy not found")
+		return
+	}
+
+	history, err := h.healthCheckRepo.GetProxyHistory(r.Context(), id, window, limit)
+	if err != nil {
+		h.logger.Error("failed to get proxy health history", "error", err, "proxy_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get proxy health history")
+		return
+	}
+	history.Window = windowName
+
+	h.jsonResponse(w, http.StatusOK, history)
+}
+
 // jsonResponse sends a JSON response
 func (h *HealthHandler) jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// poolNamePattern restricts pool names to what fits in a "-pool-<name>" username parameter
+var poolNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,100}$`)
+
+// poolMethods lists the rotation methods a pool may override
+var poolMethods = map[string]bool{
+	"":             true,
+	"random":       true,
+	"roundrobin":   true,
+	"least_conn":   true,
+	"time_based":   true,
+	"rate_limited": true,
+}
+
+// PoolRefresher reloads proxy pools in the running proxy server
+type PoolRefresher interface {
+	RefreshPools(ctx context.Context) error
+}
+
+// PoolHandler handles proxy pool endpoints
+type PoolHandler struct {
+	poolRepo  *repository.PoolRepository
+	refresher PoolRefresher
+	logger    *logger.Logger
+}
+
+// NewPoolHandler creates a new PoolHandler
+func NewPoolHandler(poolRepo *repository.PoolRepository, refresher PoolRefresher, log *logger.Logger) *PoolHandler {
+	return &PoolHandler{
+		poolRepo:  poolRepo,
+		refresher: refresher,
+		logger:    log,
+	}
+}
+
+// List handles proxy pool listing
+//
+//	@Summary		List proxy pools
+//	@Description	Get all proxy pools with the number of proxies matching their tags
+//	@Tags			pools
+//	@Produce		json
+//	@Success		200	{array}		models.ProxyPool	"List of proxy pools"
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/pools [get]
+func (h *PoolHandler) List(w http.ResponseWriter, r *http.Request) {
+	pools, err := h.poolRepo.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list proxy pools", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list proxy pools")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, pools)
+}
+
+// Get handles fetching a single proxy pool
+//
+//	@Summary		Get proxy pool
+//	@Description	Get a proxy pool by ID
+//	@Tags			pools
+//	@Produce		json
+//	@Param			id	path		int					true	"Pool ID"
+//	@Success		200	{object}	models.ProxyPool	"Proxy pool"
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/pools/{id} [get]
+func (h *PoolHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	pool, err := h.poolRepo.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to get proxy pool", "error", err, "pool_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get proxy pool")
+		return
+	}
+
+	if pool == nil {
+		h.errorResponse(w, http.StatusNotFound, "Pool not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, pool)
+}
+
+// Create handles proxy pool creation
+//
+//	@Summary		Create proxy pool
+//	@Description	Create a named pool of proxies matching all of the given tags, optionally with its own rotation method
+//	@Tags			pools
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateProxyPoolRequest	true	"Pool details"
+//	@Success		201		{object}	models.ProxyPool				"Created pool"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/pools [post]
+func (h *PoolHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateProxyPoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if !poolNamePattern.MatchString(req.Name) {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required and may only contain letters, digits and underscores")
+		return
+	}
+
+	if len(req.Tags) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "At least one tag is required")
+		return
+	}
+
+	if err := validateTags(req.Tags); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !poolMethods[req.Method] {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid rotation method")
+		return
+	}
+
+	pool, err := h.poolRepo.Create(r.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			h.errorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Error("failed to create proxy pool", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to create proxy pool")
+		return
+	}
+
+	h.refreshPools(r.Context())
+
+	h.logger.Info("proxy pool created", "name", pool.Name)
+	h.jsonResponse(w, http.StatusCreated, pool)
+}
+
+// Update handles proxy pool updates
+//
+//	@Summary		Update proxy pool
+//	@Description	Change a proxy pool's description, tags or rotation method
+//	@Tags			pools
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int								true	"Pool ID"
+//	@Param			request	body		models.UpdateProxyPoolRequest	true	"Updated pool details"
+//	@Success		200		{object}	models.ProxyPool				"Updated pool"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/pools/{id} [put]
+func (h *PoolHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	var req models.UpdateProxyPoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Tags != nil && len(req.Tags) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "At least one tag is required")
+		return
+	}
+
+	if err := validateTags(req.Tags); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Method != nil && !poolMethods[*req.Method] {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid rotation method")
+		return
+	}
+
+	pool, err := h.poolRepo.Update(r.Context(), id, req)
+	if err != nil {
+		h.logger.Error("failed to update proxy pool", "error", err, "pool_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to update proxy pool")
+		return
+	}
+
+	if pool == nil {
+		h.errorResponse(w, http.StatusNotFound, "Pool not found")
+		return
+	}
+
+	h.refreshPools(r.Context())
+
+	h.logger.Info("proxy pool updated", "name", pool.Name)
+	h.jsonResponse(w, http.StatusOK, pool)
+}
+
+// Delete handles proxy pool deletion
+//
+//	@Summary		Delete proxy pool
+//	@Description	Delete a proxy pool; its proxies and their tags are kept
+//	@Tags			pools
+//	@Param			id	path	int	true	"Pool ID"
+//	@Success		204	"Successfully deleted"
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/pools/{id} [delete]
+func (h *PoolHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid pool ID")
+		return
+	}
+
+	if err := h.poolRepo.Delete(r.Context(), id); err != nil {
+		h.logger.Error("failed to delete proxy pool", "error", err, "pool_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to delete proxy pool")
+		return
+	}
+
+	h.refreshPools(r.Context())
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// refreshPools pushes pool changes to the running proxy server
+// Failures are only logged; the proxy server also refreshes periodically
+func (h *PoolHandler) refreshPools(ctx context.Context) {
+	if err := h.refresher.RefreshPools(ctx); err != nil {
+		h.logger.Warn("failed to refresh proxy pools", "error", err)
+	}
+}
+
+// jsonResponse sends a JSON response
+func (h *PoolHandler) jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// errorResponse sends an error JSON response
+func (h *PoolHandler) errorResponse(w http.ResponseWriter, statusCode int, message string) {
+	response := models.ErrorResponse{
+		Error: message,
+	}
+	h.jsonResponse(w, statusCode, response)
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alpkeskin/rota/core/internal/models"
@@ -15,23 +18,44 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// requestStatsBuckets maps the windows of a proxy's request stats, which
+// match its health history windows, to their bucket size
+var requestStatsBuckets = map[string]time.Duration{
+	"1h":  5 * time.Minute,
+	"6h":  15 * time.Minute,
+	"24h": time.Hour,
+	"7d":  6 * time.Hour,
+	"30d": 24 * time.Hour,
+	"90d": 24 * time.Hour,
+}
+
 // HealthChecker interface for testing proxies
 type HealthChecker interface {
 	CheckProxy(ctx context.Context, proxy *models.Proxy) (*models.ProxyTestResult, error)
 }
 
+// ProxyInvalidator drops state the running proxy server keeps per upstream
+// proxy, such as pooled connections, when proxies are updated or deleted
+type ProxyInvalidator interface {
+	InvalidateProxies(proxyIDs ...int)
+}
+
 // ProxyHandler handles proxy management endpoints
 type ProxyHandler struct {
 	proxyRepo     *repository.ProxyRepository
+	requestRepo   *repository.RequestRepository
 	healthChecker HealthChecker
+	invalidator   ProxyInvalidator
 	logger        *logger.Logger
 }
 
 // NewProxyHandler creates a new ProxyHandler
-func NewProxyHandler(proxyRepo *repository.ProxyRepository, healthChecker HealthChecker, log *logger.Logger) *ProxyHandler {
+func NewProxyHandler(proxyRepo *repository.ProxyRepository, requestRepo *repository.RequestRepository, healthChecker HealthChecker, invalidator ProxyInvalidator, log *logger.Logger) *ProxyHandler {
 	return &ProxyHandler{
 		proxyRepo:     proxyRepo,
+		requestRepo:   requestRepo,
 		healthChecker: healthChecker,
+		invalidator:   invalidator,
 		logger:        log,
 	}
 }
@@ -47,6 +71,10 @@ func NewProxyHandler(proxyRepo *repository.ProxyRepository, healthChecker Health
 //	@Param			search		query		string						false	"Search term"
 //	@Param			status		query		string						false	"Filter by status"
 //	@Param			protocol	query		string						false	"Filter by protocol"
+//	@Param			tag			query		[]string					false	"Filter by tag (key=value, repeatable)"	collectionFormat(multi)
+//	@Param			country		query		string						false	"Filter by exit country ISO code"
+//	@Param			asn			query		int							false	"Filter by exit ASN"
+//	@Param			exclude_asn	query		string						false	"Exclude exit ASNs (comma-separated)"
 //	@Param			sort		query		string						false	"Sort field"
 //	@Param			order		query		string						false	"Sort order (asc/desc)"
 //	@Success		200			{object}	models.ProxyListResponse	"List of proxies"
@@ -70,8 +98,20 @@ func (h *ProxyHandler) List(w http.ResponseWriter, r *http.Request) {
 	sortField := r.URL.Query().Get("sort")
 	sortOrder := r.URL.Query().Get("order")
 
+	tags, err := parseTagFilter(r.URL.Query()["tag"])
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	geo, err := parseGeoFilter(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Get proxies
-	proxies, total, err := h.proxyRepo.List(r.Context(), page, limit, search, status, protocol, sortField, sortOrder)
+	proxies, total, err := h.proxyRepo.List(r.Context(), page, limit, search, status, protocol, tags, geo, sortField, sortOrder)
 	if err != nil {
 		h.logger.Error("failed to list proxies", "error", err)
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to list proxies")
@@ -123,6 +163,16 @@ func (h *ProxyHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.Protocol = "http"
 	}
 
+	if err := validateTags(req.Tags); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := validateCABundle(req.CABundle); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	proxy, err := h.proxyRepo.Create(r.Context(), req)
 	if err != nil {
 		h.logger.Error("failed to create proxy", "error", err)
@@ -161,7 +211,14 @@ func (h *ProxyHandler) BulkCreate(w http.ResponseWriter, r *http.Request) {
 	results := []map[string]interface{}{}
 
 	for _, proxyReq := range req.Proxies {
-		proxy, err := h.proxyRepo.Create(r.Context(), proxyReq)
+		err := validateTags(proxyReq.Tags)
+		if err == nil {
+			err = validateCABundle(proxyReq.CABundle)
+		}
+		var proxy *models.Proxy
+		if err == nil {
+			proxy, err = h.proxyRepo.Create(r.Context(), proxyReq)
+		}
 		if err != nil {
 			failed++
 			results = append(results, map[string]interface{}{
@@ -216,6 +273,16 @@ func (h *ProxyHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validateTags(req.Tags); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := validateCABundle(req.CABundle); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	proxy, err := h.proxyRepo.Update(r.Context(), id, req)
 	if err != nil {
 		h.logger.Error("failed to update proxy", "error", err)
@@ -228,6 +295,8 @@ func (h *ProxyHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.invalidator.InvalidateProxies(id)
+
 	h.jsonResponse(w, http.StatusOK, proxy)
 }
 
@@ -255,6 +324,8 @@ func (h *ProxyHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.invalidator.InvalidateProxies(id)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -289,6 +360,8 @@ func (h *ProxyHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.invalidator.InvalidateProxies(req.IDs...)
+
 	response := map[string]interface{}{
 		"deleted": deleted,
 		"message": fmt.Sprintf("Successfully deleted %d proxies", deleted),
@@ -297,6 +370,55 @@ func (h *ProxyHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 	h.jsonResponse(w, http.StatusOK, response)
 }
 
+// BulkTags handles bulk tag edits
+//
+//	@Summary		Bulk edit proxy tags
+//	@Description	Add, overwrite or remove tags on multiple proxies at once
+//	@Tags			proxies
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.BulkTagRequest	true	"Proxy IDs and tag changes"
+//	@Success		200		{object}	map[string]interface{}	"Update results"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/proxies/bulk-tags [post]
+func (h *ProxyHandler) BulkTags(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.IDs) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "At least one proxy ID is required")
+		return
+	}
+
+	if len(req.Set) == 0 && len(req.Remove) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Nothing to change: set or remove tags")
+		return
+	}
+
+	if err := validateTags(req.Set); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := h.proxyRepo.BulkUpdateTags(r.Context(), req.IDs, req.Set, req.Remove)
+	if err != nil {
+		h.logger.Error("failed to bulk update proxy tags", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to update proxy tags")
+		return
+	}
+
+	response := map[string]interface{}{
+		"updated": updated,
+		"message": fmt.Sprintf("Successfully updated tags on %d proxies", updated),
+	}
+
+	h.jsonResponse(w, http.StatusOK, response)
+}
+
 // BulkTest handles testing multiple proxies
 //
 //	@Summary		Bulk test proxies
@@ -428,6 +550,125 @@ func (h *ProxyHandler) Test(w http.ResponseWriter, r *http.Request) {
 	h.jsonResponse(w, http.StatusOK, result)
 }
 
+// Requests handles listing a proxy's recorded requests
+//
+//	@Summary		List proxy requests
+//	@Description	Get a proxy's recorded requests, newest first, with cursor pagination
+//	@Tags			proxies
+//	@Produce		json
+//	@Param			id			path		int								true	"Proxy ID"
+//	@Param			cursor		query		string							false	"next_cursor of the previous page"
+//	@Param			limit		query		int								false	"Items per page"					default(100)
+//	@Param			success		query		bool							false	"Filter by success"
+//	@Param			status		query		string							false	"Filter by status code or class (e.g. 404, 5xx)"
+//	@Param			start_time	query		string							false	"Start time (RFC3339)"
+//	@Param			end_time	query		string							false	"End time (RFC3339)"
+//	@Success		200			{object}	models.ProxyRequestListResponse	"Page of requests"
+//	@Failure		400			{object}	models.ErrorResponse
+//	@Failure		404			{object}	models.ErrorResponse
+//	@Failure		500			{object}	models.ErrorResponse
+//	@Router			/proxies/{id}/requests [get]
+func (h *ProxyHandler) Requests(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid proxy ID")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	filter, err := parseRequestFilter(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	proxy, err := h.proxyRepo.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to get proxy", "error", err, "proxy_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list proxy requests")
+		return
+	}
+	if proxy == nil {
+		h.errorResponse(w, http.StatusNotFound, "Proxy not found")
+		return
+	}
+
+	// Fetch one extra request to know whether there is a next page
+	requests, err := h.requestRepo.ListByProxy(r.Context(), id, filter, limit+1)
+	if err != nil {
+		h.logger.Error("failed to list proxy requests", "error", err, "proxy_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list proxy requests")
+		return
+	}
+
+	response := models.ProxyRequestListResponse{Requests: requests}
+	if len(requests) > limit {
+		response.Requests = requests[:limit]
+		last := response.Requests[limit-1]
+		response.NextCursor = encodeRequestCursor(models.ProxyRequestCursor{Timestamp: last.Timestamp, ID: last.ID})
+	}
+
+	h.jsonResponse(w, http.StatusOK, response)
+}
+
+// Stats handles a proxy's request statistics
+//
+//	@Summary		Proxy request stats
+//	@Description	Get a proxy's time-bucketed request counts, success rate, latency percentiles and most frequent errors
+//	@Tags			proxies
+//	@Produce		json
+//	@Param			id		path		int							true	"Proxy ID"
+//	@Param			window	query		string						false	"Time window (1h, 6h, 24h, 7d, 30d, 90d)"	default(24h)
+//	@Success		200		{object}	models.ProxyRequestStats	"Request stats"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/proxies/{id}/stats [get]
+func (h *ProxyHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid proxy ID")
+		return
+	}
+
+	windowName := r.URL.Query().Get("window")
+	if windowName == "" {
+		windowName = "24h"
+	}
+	window, ok := healthHistoryWindows[windowName]
+	if !ok {
+		h.errorResponse(w, http.StatusBadRequest, "window must be one of 1h, 6h, 24h, 7d, 30d, 90d")
+		return
+	}
+	bucket := requestStatsBuckets[windowName]
+
+	proxy, err := h.proxyRepo.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to get proxy", "error", err, "proxy_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get proxy request stats")
+		return
+	}
+	if proxy == nil {
+		h.errorResponse(w, http.StatusNotFound, "Proxy not found")
+		return
+	}
+
+	stats, err := h.requestRepo.GetStats(r.Context(), id, window, bucket)
+	if err != nil {
+		h.logger.Error("failed to get proxy request stats", "error", err, "proxy_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get proxy request stats")
+		return
+	}
+	stats.Window = windowName
+	stats.Bucket = bucket.String()
+
+	h.jsonResponse(w, http.StatusOK, stats)
+}
+
 // Export handles proxy export
 //
 //	@Summary		Export proxies
@@ -436,8 +677,12 @@ func (h *ProxyHandler) Test(w http.ResponseWriter, r *http.Request) {
 //	@Produce		plain
 //	@Produce		json
 //	@Produce		text/csv
-//	@Param			format	query	string	false	"Export format (txt/json/csv)"	default(txt)
-//	@Param			status	query	string	false	"Filter by status"
+//	@Param			format	query	string		false	"Export format (txt/json/csv)"	default(txt)
+//	@Param			status	query	string		false	"Filter by status"
+//	@Param			tag		query	[]string	false	"Filter by tag (key=value, repeatable)"	collectionFormat(multi)
+//	@Param			country	query	string		false	"Filter by exit country ISO code"
+//	@Param			asn		query	int			false	"Filter by exit ASN"
+//	@Param			exclude_asn	query	string	false	"Exclude exit ASNs (comma-separated)"
 //	@Success		200		{file}	file	"Exported file"
 //	@Failure		400		{object}	models.ErrorResponse
 //	@Failure		500		{object}	models.ErrorResponse
@@ -450,8 +695,20 @@ func (h *ProxyHandler) Export(w http.ResponseWriter, r *http.Request) {
 
 	status := r.URL.Query().Get("status")
 
+	tags, err := parseTagFilter(r.URL.Query()["tag"])
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	geo, err := parseGeoFilter(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Get all proxies
-	proxies, _, err := h.proxyRepo.List(r.Context(), 1, 10000, "", status, "", "created_at", "asc")
+	proxies, _, err := h.proxyRepo.List(r.Context(), 1, 10000, "", status, "", tags, geo, "created_at", "asc")
 	if err != nil {
 		h.logger.Error("failed to get proxies for export", "error", err)
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to export proxies")
@@ -501,6 +758,165 @@ func (h *ProxyHandler) exportCSV(w http.ResponseWriter, proxies []models.ProxyWi
 	}
 }
 
+// parseTagFilter parses repeated "key=value" tag query parameters
+func parseTagFilter(values []string) (models.Tags, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	tags := make(models.Tags, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag filter %q, expected key=value", v)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// parseGeoFilter parses the country, asn and exclude_asn query parameters
+func parseGeoFilter(r *http.Request) (models.GeoFilter, error) {
+	var geo models.GeoFilter
+
+	if country := r.URL.Query().Get("country"); country != "" {
+		if len(country) != 2 {
+			return geo, fmt.Errorf("invalid country %q, expected a 2-letter ISO code", country)
+		}
+		geo.Country = strings.ToUpper(country)
+	}
+
+	if asn := r.URL.Query().Get("asn"); asn != "" {
+		n, err := strconv.Atoi(asn)
+		if err != nil || n <= 0 {
+			return geo, fmt.Errorf("invalid asn %q", asn)
+		}
+		geo.ASN = n
+	}
+
+	if exclude := r.URL.Query().Get("exclude_asn"); exclude != "" {
+		for _, part := range strings.Split(exclude, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || n <= 0 {
+				return geo, fmt.Errorf("invalid exclude_asn %q", part)
+			}
+			geo.ExcludeASN = append(geo.ExcludeASN, n)
+		}
+	}
+
+	return geo, nil
+}
+
+// parseRequestFilter parses the cursor, success, status, start_time and end_time
+// query parameters of a proxy's request list
+func parseRequestFilter(r *http.Request) (models.ProxyRequestFilter, error) {
+	var filter models.ProxyRequestFilter
+	query := r.URL.Query()
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := decodeRequestCursor(cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &after
+	}
+
+	if success := query.Get("success"); success != "" {
+		v, err := strconv.ParseBool(success)
+		if err != nil {
+			return filter, fmt.Errorf("invalid success %q", success)
+		}
+		filter.Success = &v
+	}
+
+	if status := query.Get("status"); status != "" {
+		if len(status) == 3 && strings.HasSuffix(strings.ToLower(status), "xx") && status[0] >= '1' && status[0] <= '5' {
+			class := int(status[0]-'0') * 100
+			filter.StatusMin, filter.StatusMax = class, class+99
+		} else {
+			code, err := strconv.Atoi(status)
+			if err != nil || code < 100 || code > 599 {
+				return filter, fmt.Errorf("invalid status %q, expected a status code or class such as 5xx", status)
+			}
+			filter.StatusMin, filter.StatusMax = code, code
+		}
+	}
+
+	var err error
+	if filter.StartTime, err = parseRequestTime(query.Get("start_time")); err != nil {
+		return filter, fmt.Errorf("invalid start_time: %w", err)
+	}
+	if filter.EndTime, err = parseRequestTime(query.Get("end_time")); err != nil {
+		return filter, fmt.Errorf("invalid end_time: %w", err)
+	}
+
+	return filter, nil
+}
+
+// parseRequestTime parses an optional RFC3339 time filter
+// Request timestamps are stored as the server's local time without a zone.
+func parseRequestTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("expected RFC3339, got %q", value)
+	}
+	t = t.Local()
+	return &t, nil
+}
+
+// encodeRequestCursor encodes the position of a request in a proxy's request list
+func encodeRequestCursor(c models.ProxyRequestCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.Timestamp.UnixNano(), c.ID)))
+}
+
+// decodeRequestCursor decodes a cursor returned as next_cursor
+func decodeRequestCursor(cursor string) (models.ProxyRequestCursor, error) {
+	var c models.ProxyRequestCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	c.ID, err = strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	c.Timestamp = time.Unix(0, n).UTC()
+	return c, nil
+}
+
+// validateTags checks that tag keys are non-empty and usable in key=value filters
+func validateTags(tags models.Tags) error {
+	for key := range tags {
+		if strings.TrimSpace(key) == "" || strings.Contains(key, "=") {
+			return fmt.Errorf("invalid tag key %q", key)
+		}
+	}
+	return nil
+}
+
+// validateCABundle checks that a CA bundle, if set, contains PEM certificates
+func validateCABundle(bundle *string) error {
+	if bundle == nil || *bundle == "" {
+		return nil
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(*bundle)) {
+		return fmt.Errorf("ca_bundle contains no valid PEM certificates")
+	}
+	return nil
+}
+
 // jsonResponse sends a JSON response
 func (h *ProxyHandler) jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// reservedRoutePrefixes are served by the proxy port itself
+var reservedRoutePrefixes = map[string]bool{
+	"/health": true,
+}
+
+// RouteRefresher reloads the reverse-proxy route table in the running proxy server
+type RouteRefresher interface {
+	RefreshRoutes(ctx context.Context) error
+}
+
+// RouteHandler handles reverse-proxy route endpoints
+type RouteHandler struct {
+	settingsRepo *repository.SettingsRepository
+	refresher    RouteRefresher
+	logger       *logger.Logger
+
+	// Serializes read-modify-write of the route table
+	mu sync.Mutex
+}
+
+// NewRouteHandler creates a new RouteHandler
+func NewRouteHandler(settingsRepo *repository.SettingsRepository, refresher RouteRefresher, log *logger.Logger) *RouteHandler {
+	return &RouteHandler{
+		settingsRepo: settingsRepo,
+		refresher:    refresher,
+		logger:       log,
+	}
+}
+
+// List handles route listing
+//
+//	@Summary		List routes
+//	@Description	Get the reverse-proxy routes served on the proxy port
+//	@Tags			routes
+//	@Produce		json
+//	@Success		200	{array}		models.ProxyRoute	"List of routes"
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/routes [get]
+func (h *RouteHandler) List(w http.ResponseWriter, r *http.Request) {
+	routes, err := h.settingsRepo.GetRoutes(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list routes", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list routes")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, routes)
+}
+
+// Create handles route creation
+//
+//	@Summary		Create route
+//	@Description	Expose an upstream API on the proxy port: requests under the path prefix are forwarded to the target through the proxy pool
+//	@Tags			routes
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.ProxyRoute	true	"Route details"
+//	@Success		201		{object}	models.ProxyRoute	"Created route"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/routes [post]
+func (h *RouteHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var route models.ProxyRoute
+	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	route.Name = strings.TrimSpace(route.Name)
+	if err := normalizeRoute(&route); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	routes, err := h.settingsRepo.GetRoutes(r.Context())
+	if err != nil {
+		h.logger.Error("failed to load routes", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to create route")
+		return
+	}
+
+	if err := routeConflict(routes, route, ""); err != nil {
+		h.errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if err := h.settingsRepo.SetRoutes(r.Context(), append(routes, route)); err != nil {
+		h.logger.Error("failed to create route", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to create route")
+		return
+	}
+
+	h.refreshRoutes(r.Context())
+
+	h.logger.Info("route created", "name", route.Name, "path_prefix", route.PathPrefix, "target", route.Target)
+	h.jsonResponse(w, http.StatusCreated, route)
+}
+
+// Update handles route updates
+//
+//	@Summary		Update route
+//	@Description	Replace a route's definition; the name in the body may rename it
+//	@Tags			routes
+//	@Accept			json
+//	@Produce		json
+//	@Param			name	path		string				true	"Route name"
+//	@Param			request	body		models.ProxyRoute	true	"Route details"
+//	@Success		200		{object}	models.ProxyRoute	"Updated route"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/routes/{name} [put]
+func (h *RouteHandler) Update(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var route models.ProxyRoute
+	if err := json.NewDecoder(r.Body).Decode(&route); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	route.Name = strings.TrimSpace(route.Name)
+	if route.Name == "" {
+		route.Name = name
+	}
+	if err := normalizeRoute(&route); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	routes, err := h.settingsRepo.GetRoutes(r.Context())
+	if err != nil {
+		h.logger.Error("failed to load routes", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to update route")
+		return
+	}
+
+	index := findRoute(routes, name)
+	if index < 0 {
+		h.errorResponse(w, http.StatusNotFound, "Route not found")
+		return
+	}
+
+	if err := routeConflict(routes, route, name); err != nil {
+		h.errorResponse(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	routes[index] = route
+	if err := h.settingsRepo.SetRoutes(r.Context(), routes); err != nil {
+		h.logger.Error("failed to update route", "error", err, "name", name)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to update route")
+		return
+	}
+
+	h.refreshRoutes(r.Context())
+
+	h.logger.Info("route updated", "name", route.Name, "path_prefix", route.PathPrefix, "target", route.Target)
+	h.jsonResponse(w, http.StatusOK, route)
+}
+
+// Delete handles route deletion
+//
+//	@Summary		Delete route
+//	@Description	Stop serving a reverse-proxy route
+//	@Tags			routes
+//	@Param			name	path	string	true	"Route name"
+//	@Success		204		"Successfully deleted"
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/routes/{name} [delete]
+func (h *RouteHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	routes, err := h.settingsRepo.GetRoutes(r.Context())
+	if err != nil {
+		h.logger.Error("failed to load routes", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to delete route")
+		return
+	}
+
+	index := findRoute(routes, name)
+	if index < 0 {
+		h.errorResponse(w, http.StatusNotFound, "Route not found")
+		return
+	}
+
+	routes = append(routes[:index], routes[index+1:]...)
+	if err := h.settingsRepo.SetRoutes(r.Context(), routes); err != nil {
+		h.logger.Error("failed to delete route", "error", err, "name", name)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to delete route")
+		return
+	}
+
+	h.refreshRoutes(r.Context())
+
+	h.logger.Info("route deleted", "name", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// normalizeRoute validates a route definition and trims its path prefix
+func normalizeRoute(route *models.ProxyRoute) error {
+	if !poolNamePattern.MatchString(route.Name) {
+		return fmt.Errorf("name is required and may only contain letters, digits and underscores")
+	}
+
+	route.PathPrefix = strings.TrimRight(strings.TrimSpace(route.PathPrefix), "/")
+	if route.PathPrefix == "" || !strings.HasPrefix(route.PathPrefix, "/") {
+		return fmt.Errorf("path_prefix must start with / and may not be /")
+	}
+	if reservedRoutePrefixes[route.PathPrefix] || strings.ContainsAny(route.PathPrefix, "?#") {
+		return fmt.Errorf("path_prefix %q is not allowed", route.PathPrefix)
+	}
+
+	route.Target = strings.TrimSpace(route.Target)
+	target, err := url.Parse(route.Target)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("target must be an absolute http or https URL")
+	}
+	if target.RawQuery != "" || target.Fragment != "" {
+		return fmt.Errorf("target may not contain a query or fragment")
+	}
+
+	if route.RateLimit.Enabled {
+		if route.RateLimit.Interval < 1 || route.RateLimit.Interval > 3600 {
+			return fmt.Errorf("rate_limit.interval must be between 1 and 3600 seconds")
+		}
+		if route.RateLimit.MaxRequests < 1 {
+			return fmt.Errorf("rate_limit.max_requests must be at least 1")
+		}
+	}
+
+	if route.Pool != "" && len(route.Tags) > 0 {
+		return fmt.Errorf("set either pool or tags, not both")
+	}
+	if route.Pool != "" && !poolNamePattern.MatchString(route.Pool) {
+		return fmt.Errorf("invalid pool name")
+	}
+	return validateTags(route.Tags)
+}
+
+// findRoute returns the index of the named route, or -1
+func findRoute(routes []models.ProxyRoute, name string) int {
+	for i, route := range routes {
+		if route.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// routeConflict reports another route (than the one named replacing) using the
+// same name or path prefix
+func routeConflict(routes []models.ProxyRoute, route models.ProxyRoute, replacing string) error {
+	for _, existing := range routes {
+		if existing.Name == replacing && replacing != "" {
+			continue
+		}
+		if existing.Name == route.Name {
+			return fmt.Errorf("route %s already exists", route.Name)
+		}
+		if existing.PathPrefix == route.PathPrefix {
+			return fmt.Errorf("path prefix %s is already used by route %s", route.PathPrefix, existing.Name)
+		}
+	}
+	return nil
+}
+
+// refreshRoutes pushes route changes to the running proxy server
+// Failures are only logged; routes are also reloaded with settings
+func (h *RouteHandler) refreshRoutes(ctx context.Context) {
+	if err := h.refresher.RefreshRoutes(ctx); err != nil {
+		h.logger.Warn("failed to refresh routes", "error", err)
+	}
+}
+
+// jsonResponse sends a JSON response
+func (h *RouteHandler) jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// errorResponse sends an error JSON response
+func (h *RouteHandler) errorResponse(w http.ResponseWriter, statusCode int, message string) {
+	response := models.ErrorResponse{
+		Error: message,
+	}
+	h.jsonResponse(w, statusCode, response)
+}
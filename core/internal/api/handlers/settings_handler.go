@@ -4,22 +4,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 
 	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/internal/services"
 	"github.com/alpkeskin/rota/core/pkg/logger"
 )
 
 // SettingsHandler handles settings endpoints
+// Saved settings are published on the bus so running subsystems apply them
 type SettingsHandler struct {
 	settingsRepo *repository.SettingsRepository
+	bus          *services.SettingsBus
 	logger       *logger.Logger
 }
 
 // NewSettingsHandler creates a new SettingsHandler
-func NewSettingsHandler(settingsRepo *repository.SettingsRepository, log *logger.Logger) *SettingsHandler {
+func NewSettingsHandler(settingsRepo *repository.SettingsRepository, bus *services.SettingsBus, log *logger.Logger) *SettingsHandler {
 	return &SettingsHandler{
 		settingsRepo: settingsRepo,
+		bus:          bus,
 		logger:       log,
 	}
 }
@@ -50,12 +56,12 @@ func (h *SettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
 // Update handles updating configuration
 //
 //	@Summary		Update settings
-//	@Description	Update system configuration
+//	@Description	Update system configuration and apply it to the running subsystems; "reload" reports each subsystem's result
 //	@Tags			settings
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		models.Settings			true	"Updated settings"
-//	@Success		200		{object}	map[string]interface{}	"Update confirmation"
+//	@Success		200		{object}	map[string]interface{}	"Update confirmation with reload status"
 //	@Failure		400		{object}	models.ErrorResponse
 //	@Failure		500		{object}	models.ErrorResponse
 //	@Router			/settings [put]
@@ -74,7 +80,7 @@ func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Update settings
 	// Note: These settings are for PROXY server authentication (port 8000)
-	// Dashboard/API authentication uses ROTA_ADMIN_USER/ROTA_ADMIN_PASSWORD (environment)
+	// Dashboard/API authentication uses the users table
 	if err := h.settingsRepo.UpdateAll(r.Context(), &settings); err != nil {
 		h.logger.Error("failed to update settings", "error", err)
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to update settings")
@@ -89,12 +95,17 @@ func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Never expose proxy password
-	updatedSettings.Authentication.Password = ""
+	// Apply to the running subsystems
+	reload := h.bus.Publish(r.Context(), updatedSettings)
+
+	// Never expose proxy password (the published settings are shared, so copy)
+	config := *updatedSettings
+	config.Authentication.Password = ""
 
 	response := map[string]interface{}{
 		"message": "Configuration updated successfully",
-		"config":  updatedSettings,
+		"config":  config,
+		"reload":  reload,
 	}
 
 	h.logger.Info("settings updated successfully")
@@ -104,10 +115,10 @@ func (h *SettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
 // Reset handles resetting configuration to defaults
 //
 //	@Summary		Reset settings
-//	@Description	Reset configuration to default values
+//	@Description	Reset configuration to default values and apply them to the running subsystems; "reload" reports each subsystem's result
 //	@Tags			settings
 //	@Produce		json
-//	@Success		200	{object}	map[string]interface{}	"Reset confirmation"
+//	@Success		200	{object}	map[string]interface{}	"Reset confirmation with reload status"
 //	@Failure		500	{object}	models.ErrorResponse
 //	@Router			/settings/reset [post]
 func (h *SettingsHandler) Reset(w http.ResponseWriter, r *http.Request) {
@@ -124,9 +135,12 @@ func (h *SettingsHandler) Reset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	reload := h.bus.Publish(r.Context(), settings)
+
 	response := map[string]interface{}{
 		"message": "Configuration reset to defaults",
 		"config":  settings,
+		"reload":  reload,
 	}
 
 	h.jsonResponse(w, http.StatusOK, response)
@@ -144,6 +158,63 @@ func (h *SettingsHandler) validateSettings(s *models.Settings) error {
 		return fmt.Errorf("rotation.retries must be between 0 and 10")
 	}
 
+	// Validate retry body buffering (bytes)
+	if s.Rotation.RetryBodyLimit < 0 || s.Rotation.RetryBodyLimit > 64<<20 {
+		return fmt.Errorf("rotation.retry_body_limit must be between 0 and 67108864")
+	}
+
+	// Validate sticky session TTL (seconds)
+	if s.Rotation.StickySessionTTL < 0 || s.Rotation.StickySessionTTL > 86400 {
+		return fmt.Errorf("rotation.sticky_session_ttl must be between 0 and 86400")
+	}
+
+	// Validate rate-limited rotation wait (seconds)
+	if s.Rotation.RateLimited.MaxWaitSeconds < 0 || s.Rotation.RateLimited.MaxWaitSeconds > 60 {
+		return fmt.Errorf("rotation.rate_limited.max_wait_seconds must be between 0 and 60")
+	}
+
+	// Validate circuit breaker (a failure threshold of 0 disables it)
+	breaker := s.Rotation.CircuitBreaker
+	if breaker.FailureThreshold < 0 || breaker.FailureThreshold > 100 {
+		return fmt.Errorf("rotation.circuit_breaker.failure_threshold must be between 0 and 100")
+	}
+	if breaker.FailureThreshold > 0 {
+		if breaker.OpenSeconds < 1 || breaker.OpenSeconds > 3600 {
+			return fmt.Errorf("rotation.circuit_breaker.open_seconds must be between 1 and 3600")
+		}
+		if breaker.MaxOpenSeconds < breaker.OpenSeconds || breaker.MaxOpenSeconds > 86400 {
+			return fmt.Errorf("rotation.circuit_breaker.max_open_seconds must be between open_seconds and 86400")
+		}
+		if breaker.HalfOpenRequests < 1 || breaker.HalfOpenRequests > 100 {
+			return fmt.Errorf("rotation.circuit_breaker.half_open_requests must be between 1 and 100")
+		}
+	}
+
+	// Validate blocked response policy
+	blocked := s.Rotation.Blocked
+	for _, code := range blocked.StatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("rotation.blocked.status_codes must be HTTP status codes")
+		}
+	}
+	for _, pattern := range blocked.BodyPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("rotation.blocked.body_patterns: invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if blocked.MaxRetries < 0 || blocked.MaxRetries > 10 {
+		return fmt.Errorf("rotation.blocked.max_retries must be between 0 and 10")
+	}
+	if blocked.BackoffMs < 0 || blocked.BackoffMs > 60000 {
+		return fmt.Errorf("rotation.blocked.backoff_ms must be between 0 and 60000")
+	}
+	if blocked.MaxBackoffMs < blocked.BackoffMs || blocked.MaxBackoffMs > 300000 {
+		return fmt.Errorf("rotation.blocked.max_backoff_ms must be between backoff_ms and 300000")
+	}
+	if blocked.CooldownSeconds < 0 || blocked.CooldownSeconds > 86400 {
+		return fmt.Errorf("rotation.blocked.cooldown_seconds must be between 0 and 86400")
+	}
+
 	// Validate healthcheck timeout
 	if s.HealthCheck.Timeout < 1 || s.HealthCheck.Timeout > 300 {
 		return fmt.Errorf("healthcheck.timeout must be between 1 and 300")
@@ -154,11 +225,28 @@ func (h *SettingsHandler) validateSettings(s *models.Settings) error {
 		return fmt.Errorf("healthcheck.workers must be between 1 and 100")
 	}
 
+	// Validate healthcheck schedule (minutes)
+	if s.HealthCheck.IntervalMinutes < 0 || s.HealthCheck.IntervalMinutes > 1440 {
+		return fmt.Errorf("healthcheck.interval_minutes must be between 0 and 1440")
+	}
+
 	// Validate healthcheck retest window (minutes)
 	if s.HealthCheck.RetestFailedAfterMinutes < 0 || s.HealthCheck.RetestFailedAfterMinutes > 10080 {
 		return fmt.Errorf("healthcheck.retest_failed_after_minutes must be between 0 and 10080")
 	}
 
+	// Validate exit IP echo endpoint
+	if s.HealthCheck.ExitIPURL != "" {
+		if u, err := url.Parse(s.HealthCheck.ExitIPURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("healthcheck.exit_ip_url must be an http or https URL")
+		}
+	}
+
+	// Validate Webshare auto-sync interval (0 keeps WEBSHARE_SYNC_INTERVAL_SECONDS)
+	if s.Webshare.SyncIntervalSeconds != 0 && (s.Webshare.SyncIntervalSeconds < 60 || s.Webshare.SyncIntervalSeconds > 604800) {
+		return fmt.Errorf("webshare.sync_interval_seconds must be 0 or between 60 and 604800")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// minPasswordLength is the minimum length for dashboard user passwords
+const minPasswordLength = 8
+
+// UserHandler handles dashboard user management endpoints
+type UserHandler struct {
+	userRepo *repository.UserRepository
+	logger   *logger.Logger
+}
+
+// NewUserHandler creates a new UserHandler
+func NewUserHandler(userRepo *repository.UserRepository, log *logger.Logger) *UserHandler {
+	return &UserHandler{
+		userRepo: userRepo,
+		logger:   log,
+	}
+}
+
+// List handles user listing
+//
+//	@Summary		List users
+//	@Description	Get all dashboard user accounts (admin only)
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{array}		models.User	"List of users"
+//	@Failure		403	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/users [get]
+func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	users, err := h.userRepo.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list users", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, users)
+}
+
+// Get handles fetching a single user
+//
+//	@Summary		Get user
+//	@Description	Get a dashboard user account by ID (admin only)
+//	@Tags			users
+//	@Produce		json
+//	@Param			id	path		int			true	"User ID"
+//	@Success		200	{object}	models.User	"User"
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		404	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/users/{id} [get]
+func (h *UserHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		h.logger.Error("failed to get user", "error", err, "user_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to get user")
+		return
+	}
+
+	if user == nil {
+		h.errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, user)
+}
+
+// Create handles user creation
+//
+//	@Summary		Create user
+//	@Description	Create a dashboard user account (admin only)
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateUserRequest	true	"User details"
+//	@Success		201		{object}	models.User					"Created user"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/users [post]
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Username is required")
+		return
+	}
+
+	if len(req.Password) < minPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.UserRoleViewer
+	}
+
+	if !req.Role.IsValid() {
+		h.errorResponse(w, http.StatusBadRequest, "Role must be one of admin, operator, viewer")
+		return
+	}
+
+	user, err := h.userRepo.Create(r.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			h.errorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Error("failed to create user", "error", err)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+
+	h.logger.Info("user created", "username", user.Username, "role", user.Role)
+	h.jsonResponse(w, http.StatusCreated, user)
+}
+
+// Update handles user updates
+//
+//	@Summary		Update user
+//	@Description	Change a dashboard user's password and/or role (admin only)
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"User ID"
+//	@Param			request	body		models.UpdateUserRequest	true	"Updated user details"
+//	@Success		200		{object}	models.User					"Updated user"
+//	@Failure		400		{object}	models.ErrorResponse
+//	@Failure		404		{object}	models.ErrorResponse
+//	@Failure		409		{object}	models.ErrorResponse
+//	@Failure		500		{object}	models.ErrorResponse
+//	@Router			/users/{id} [put]
+func (h *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Password != "" && len(req.Password) < minPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, "Password must be at least 8 characters")
+		return
+	}
+
+	if req.Role != "" && !req.Role.IsValid() {
+		h.errorResponse(w, http.StatusBadRequest, "Role must be one of admin, operator, viewer")
+		return
+	}
+
+	user, err := h.userRepo.Update(r.Context(), id, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrLastAdmin) {
+			h.errorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Error("failed to update user", "error", err, "user_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	if user == nil {
+		h.errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	h.logger.Info("user updated", "username", user.Username, "role", user.Role)
+	h.jsonResponse(w, http.StatusOK, user)
+}
+
+// Delete handles user deletion
+//
+//	@Summary		Delete user
+//	@Description	Delete a dashboard user account (admin only)
+//	@Tags			users
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204	"Successfully deleted"
+//	@Failure		400	{object}	models.ErrorResponse
+//	@Failure		409	{object}	models.ErrorResponse
+//	@Failure		500	{object}	models.ErrorResponse
+//	@Router			/users/{id} [delete]
+func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrLastAdmin) {
+			h.errorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.Error("failed to delete user", "error", err, "user_id", id)
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	if user == nil {
+		h.errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// jsonResponse sends a JSON response
+func (h *UserHandler) jsonResponse(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// errorResponse sends an error JSON response
+func (h *UserHandler) errorResponse(w http.ResponseWriter, statusCode int, message string) {
+	response := models.ErrorResponse{
+		Error: message,
+	}
+	h.jsonResponse(w, statusCode, response)
+}
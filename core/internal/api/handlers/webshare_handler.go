@@ -16,8 +16,9 @@ import (
 type WebshareHandler struct {
 	syncService  *services.WebshareSyncService
 	webshareRepo *repository.WebshareRepository
+	settingsRepo *repository.SettingsRepository
 	hasAPIKey    bool
-	syncInterval int
+	syncInterval int // WEBSHARE_SYNC_INTERVAL_SECONDS, unless overridden in settings
 	logger       *logger.Logger
 }
 
@@ -25,6 +26,7 @@ type WebshareHandler struct {
 func NewWebshareHandler(
 	syncService *services.WebshareSyncService,
 	webshareRepo *repository.WebshareRepository,
+	settingsRepo *repository.SettingsRepository,
 	hasAPIKey bool,
 	syncInterval int,
 	log *logger.Logger,
@@ -32,6 +34,7 @@ func NewWebshareHandler(
 	return &WebshareHandler{
 		syncService:  syncService,
 		webshareRepo: webshareRepo,
+		settingsRepo: settingsRepo,
 		hasAPIKey:    hasAPIKey,
 		syncInterval: syncInterval,
 		logger:       log,
@@ -125,8 +128,12 @@ func (h *WebshareHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Calculate next sync time
-	if lastSync != nil && h.syncInterval > 0 {
-		nextSyncTime := lastSync.SyncedAt.Add(time.Duration(h.syncInterval) * time.Second)
+	syncInterval := h.syncInterval
+	if settings, err := h.settingsRepo.GetAll(ctx); err == nil && settings.Webshare.SyncIntervalSeconds > 0 {
+		syncInterval = settings.Webshare.SyncIntervalSeconds
+	}
+	if lastSync != nil && syncInterval > 0 {
+		nextSyncTime := lastSync.SyncedAt.Add(time.Duration(syncInterval) * time.Second)
 		response.NextSyncTime = &nextSyncTime
 	}
 
@@ -12,9 +12,15 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// WebSocketAuthSubprotocol is the subprotocol a client offers before its JWT when
+// authenticating a WebSocket handshake via Sec-WebSocket-Protocol
+const WebSocketAuthSubprotocol = "bearer"
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
+	// Echo the auth subprotocol back so browsers accept the handshake
+	Subprotocols: []string{WebSocketAuthSubprotocol},
 	CheckOrigin: func(r *http.Request) bool {
 		// Allow all origins for development
 		// In production, implement proper origin checking
@@ -1,11 +1,30 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/api/handlers"
+	"github.com/alpkeskin/rota/core/internal/metrics"
+	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/pkg/logger"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+// contextKey is the type for values stored in the request context by this package
+type contextKey string
+
+const (
+	// usernameContextKey holds the authenticated dashboard username
+	usernameContextKey contextKey = "username"
+	// roleContextKey holds the authenticated dashboard user's role
+	roleContextKey contextKey = "role"
 )
 
 // LoggerMiddleware logs HTTP requests
@@ -50,3 +69,168 @@ func OptionsMiddleware() func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// UserLookup finds the dashboard user a token was issued to
+// It is implemented by repository.UserRepository.
+type UserLookup interface {
+	GetByID(ctx context.Context, id int) (*models.User, error)
+}
+
+// JWTAuthMiddleware validates the dashboard JWT on every request that is not in publicPaths
+// Tokens are read from the Authorization header ("Bearer <token>"). WebSocket handshakes
+// cannot set headers from the browser, so they may also pass the token as the "token"
+// query parameter or as the subprotocol following handlers.WebSocketAuthSubprotocol.
+// The token's user is looked up on every request, so deleting a user revokes their
+// tokens and a role change applies right away.
+func JWTAuthMiddleware(jwtSecret []byte, users UserLookup, publicPaths map[string]bool, log *logger.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tokenString := extractToken(r)
+			if tokenString == "" {
+				metrics.AuthFailures.WithLabelValues("api", "missing_token").Inc()
+				unauthorized(w, "Missing authentication token")
+				return
+			}
+
+			claims, err := parseToken(tokenString, jwtSecret)
+			if err != nil {
+				metrics.AuthFailures.WithLabelValues("api", "invalid_token").Inc()
+				log.Warn("rejected API request with invalid token",
+					"path", r.URL.Path,
+					"remote_addr", r.RemoteAddr,
+					"error", err,
+				)
+				unauthorized(w, "Invalid or expired token")
+				return
+			}
+
+			// The role claim is informational; access follows the user's current role
+			userID, ok := claims["sub"].(float64)
+			if !ok {
+				metrics.AuthFailures.WithLabelValues("api", "invalid_token").Inc()
+				unauthorized(w, "Invalid or expired token")
+				return
+			}
+
+			user, err := users.GetByID(r.Context(), int(userID))
+			if err != nil {
+				log.Error("failed to look up token user", "error", err, "user_id", int(userID))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Internal server error"})
+				return
+			}
+			if user == nil {
+				metrics.AuthFailures.WithLabelValues("api", "unknown_user").Inc()
+				log.Warn("rejected API request for deleted user",
+					"path", r.URL.Path,
+					"user_id", int(userID),
+				)
+				unauthorized(w, "Invalid or expired token")
+				return
+			}
+
+			role := user.Role
+			if !role.IsValid() {
+				// Users without a known role get the least privileged access
+				role = models.UserRoleViewer
+			}
+
+			ctx := context.WithValue(r.Context(), usernameContextKey, user.Username)
+			ctx = context.WithValue(ctx, roleContextKey, role)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UsernameFromContext returns the authenticated dashboard username, if any
+func UsernameFromContext(ctx context.Context) string {
+	username, _ := ctx.Value(usernameContextKey).(string)
+	return username
+}
+
+// RoleFromContext returns the authenticated dashboard user's role, if any
+func RoleFromContext(ctx context.Context) models.UserRole {
+	role, _ := ctx.Value(roleContextKey).(models.UserRole)
+	return role
+}
+
+// RequireRole only lets requests through if the caller has one of the given roles
+// Must run after JWTAuthMiddleware
+func RequireRole(roles ...models.UserRole) func(next http.Handler) http.Handler {
+	allowed := make(map[models.UserRole]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !allowed[RoleFromContext(r.Context())] {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Insufficient permissions"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// extractToken returns the raw JWT from the request or an empty string
+func extractToken(r *http.Request) string {
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		if token, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+
+	// Only WebSocket handshakes may carry the token outside the Authorization header
+	if !websocket.IsWebSocketUpgrade(r) {
+		return ""
+	}
+
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+
+	// Subprotocol form: Sec-WebSocket-Protocol: bearer, <token>
+	protocols := websocket.Subprotocols(r)
+	for i, protocol := range protocols {
+		if protocol == handlers.WebSocketAuthSubprotocol && i+1 < len(protocols) {
+			return protocols[i+1]
+		}
+	}
+
+	return ""
+}
+
+// parseToken verifies the token signature and expiry and returns its claims
+func parseToken(tokenString string, jwtSecret []byte) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, fmt.Errorf("token is not valid")
+	}
+
+	return claims, nil
+}
+
+// unauthorized writes a 401 JSON error response
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="Rota API"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/api/handlers"
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSecret = []byte("test-secret")
+
+// testUsers is an in-memory UserLookup
+type testUsers map[int]*models.User
+
+func (u testUsers) GetByID(ctx context.Context, id int) (*models.User, error) {
+	return u[id], nil
+}
+
+// signTestToken signs claims for a user like the login handler does
+func signTestToken(t *testing.T, method jwt.SigningMethod, key any, userID int, role models.UserRole, exp time.Time) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub":      userID,
+		"username": "user",
+		"role":     string(role),
+		"exp":      exp.Unix(),
+		"iat":      time.Now().Unix(),
+	}).SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+// newTestRouter returns the JWT middleware around a handler that reports the caller's role
+func newTestRouter(users UserLookup, public map[string]bool, next http.Handler) http.Handler {
+	if next == nil {
+		next = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(RoleFromContext(r.Context())))
+		})
+	}
+	return JWTAuthMiddleware(testSecret, users, public, logger.New("error"))(next)
+}
+
+// TestJWTAuthMiddlewareCurrentUser tests that access follows the user's current
+// role and that tokens of deleted users are rejected
+func TestJWTAuthMiddlewareCurrentUser(t *testing.T) {
+	users := testUsers{1: {ID: 1, Username: "alice", Role: models.UserRoleAdmin}}
+	token := signTestToken(t, jwt.SigningMethodHS256, testSecret, 1, models.UserRoleAdmin, time.Now().Add(time.Hour))
+	router := newTestRouter(users, nil, nil)
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/proxies", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(); rec.Code != http.StatusOK || rec.Body.String() != "admin" {
+		t.Fatalf("admin request = %d %q, want 200 admin", rec.Code, rec.Body.String())
+	}
+
+	// Demoted after the token was issued
+	users[1].Role = models.UserRoleViewer
+	if rec := request(); rec.Body.String() != "viewer" {
+		t.Errorf("demoted user has role %q, want viewer", rec.Body.String())
+	}
+
+	delete(users, 1)
+	if rec := request(); rec.Code != http.StatusUnauthorized {
+		t.Errorf("deleted user's request = %d, want 401", rec.Code)
+	}
+}
+
+// TestJWTAuthMiddlewarePublicPaths tests that allowlisted paths need no token
+func TestJWTAuthMiddlewarePublicPaths(t *testing.T) {
+	router := newTestRouter(testUsers{}, publicPaths, nil)
+
+	for path := range publicPaths {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s without token = %d, want 200", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/proxies", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /api/v1/proxies without token = %d, want 401", rec.Code)
+	}
+}
+
+// TestJWTAuthMiddlewareRejectsTokens tests that missing, expired and
+// non-HS256 tokens are rejected
+func TestJWTAuthMiddlewareRejectsTokens(t *testing.T) {
+	users := testUsers{1: {ID: 1, Username: "alice", Role: models.UserRoleAdmin}}
+	router := newTestRouter(users, nil, nil)
+	valid := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		authorization string
+	}{
+		{"missing", ""},
+		{"not bearer", "Basic " + signTestToken(t, jwt.SigningMethodHS256, testSecret, 1, models.UserRoleAdmin, valid)},
+		{"expired", "Bearer " + signTestToken(t, jwt.SigningMethodHS256, testSecret, 1, models.UserRoleAdmin, time.Now().Add(-time.Minute))},
+		{"wrong secret", "Bearer " + signTestToken(t, jwt.SigningMethodHS256, []byte("other-secret"), 1, models.UserRoleAdmin, valid)},
+		{"HS384", "Bearer " + signTestToken(t, jwt.SigningMethodHS384, testSecret, 1, models.UserRoleAdmin, valid)},
+		{"none", "Bearer " + signTestToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, 1, models.UserRoleAdmin, valid)},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/v1/proxies", nil)
+		if tt.authorization != "" {
+			req.Header.Set("Authorization", tt.authorization)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s token = %d, want 401", tt.name, rec.Code)
+		}
+	}
+}
+
+// TestJWTAuthMiddlewareWebSocketToken tests that the token query parameter and
+// bearer subprotocol are only accepted on WebSocket handshakes
+func TestJWTAuthMiddlewareWebSocketToken(t *testing.T) {
+	users := testUsers{1: {ID: 1, Username: "alice", Role: models.UserRoleAdmin}}
+	token := signTestToken(t, jwt.SigningMethodHS256, testSecret, 1, models.UserRoleAdmin, time.Now().Add(time.Hour))
+	router := newTestRouter(users, nil, nil)
+
+	tests := []struct {
+		name     string
+		query    bool
+		protocol bool
+	}{
+		{"query parameter", true, false},
+		{"subprotocol", false, true},
+	}
+
+	for _, tt := range tests {
+		for _, upgrade := range []bool{true, false} {
+			target := "/ws/logs"
+			if tt.query {
+				target += "?token=" + token
+			}
+			req := httptest.NewRequest("GET", target, nil)
+			if tt.protocol {
+				req.Header.Set("Sec-WebSocket-Protocol", handlers.WebSocketAuthSubprotocol+", "+token)
+			}
+			if upgrade {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", "websocket")
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			want := http.StatusUnauthorized
+			if upgrade {
+				want = http.StatusOK
+			}
+			if rec.Code != want {
+				t.Errorf("%s with upgrade=%v = %d, want %d", tt.name, upgrade, rec.Code, want)
+			}
+		}
+	}
+}
+
+// TestRequireRole tests that a viewer is refused admin routes
+func TestRequireRole(t *testing.T) {
+	users := testUsers{
+		1: {ID: 1, Username: "alice", Role: models.UserRoleAdmin},
+		2: {ID: 2, Username: "bob", Role: models.UserRoleViewer},
+	}
+	admin := RequireRole(models.UserRoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	router := newTestRouter(users, nil, admin)
+
+	for _, tt := range []struct {
+		userID int
+		want   int
+	}{
+		{1, http.StatusOK},
+		{2, http.StatusForbidden},
+	} {
+		// The token's role claim does not grant access, the user's current role does
+		token := signTestToken(t, jwt.SigningMethodHS256, testSecret, tt.userID, models.UserRoleAdmin, time.Now().Add(time.Hour))
+		req := httptest.NewRequest("DELETE", "/api/v1/users/3", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("user %d on admin route = %d, want %d", tt.userID, rec.Code, tt.want)
+		}
+	}
+}
@@ -3,14 +3,19 @@ package api
 import (
 	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/alpkeskin/rota/core/internal/api/handlers"
 	"github.com/alpkeskin/rota/core/internal/config"
 	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/metrics"
+	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/proxy"
 	"github.com/alpkeskin/rota/core/internal/repository"
 	"github.com/alpkeskin/rota/core/internal/services"
@@ -18,14 +23,18 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-
-	// Import for swagger documentation
-	_ "github.com/alpkeskin/rota/core/internal/models"
 )
 
 // ProxyServer interface for reloading proxy pool
 type ProxyServer interface {
 	ReloadSettings(ctx context.Context) error
+	RefreshClients(ctx context.Context) error
+	RefreshPools(ctx context.Context) error
+	RefreshRoutes(ctx context.Context) error
+	ListSessions() []models.StickySession
+	ListCircuits() []models.CircuitState
+	ListTransports() []models.TransportStats
+	InvalidateProxies(proxyIDs ...int)
 }
 
 // Server represents the API server
@@ -36,6 +45,15 @@ type Server struct {
 	db     *database.DB
 	port   int
 
+	// Secret used to sign and verify dashboard JWTs
+	jwtSecret []byte
+
+	// Dashboard users, looked up to authorize each token
+	userRepo *repository.UserRepository
+
+	// Bearer token required by /metrics, empty if it is public
+	metricsToken string
+
 	// Proxy server reference for reloading
 	proxyServer ProxyServer
 
@@ -44,6 +62,10 @@ type Server struct {
 
 	// Handlers
 	authHandler          *handlers.AuthHandler
+	userHandler          *handlers.UserHandler
+	clientHandler        *handlers.ClientHandler
+	poolHandler          *handlers.PoolHandler
+	routeHandler         *handlers.RouteHandler
 	healthHandler        *handlers.HealthHandler
 	dashboardHandler     *handlers.DashboardHandler
 	proxyHandler         *handlers.ProxyHandler
@@ -56,25 +78,25 @@ type Server struct {
 }
 
 // New creates a new API server instance
-func New(cfg *config.Config, log *logger.Logger, db *database.DB) *Server {
+// Settings changes are published on settingsBus.
+func New(cfg *config.Config, log *logger.Logger, db *database.DB, healthChecker *proxy.HealthChecker, settingsBus *services.SettingsBus) *Server {
 	// Initialize repositories
 	proxyRepo := repository.NewProxyRepository(db)
 	logRepo := repository.NewLogRepository(db)
 	settingsRepo := repository.NewSettingsRepository(db)
 	dashboardRepo := repository.NewDashboardRepository(db)
 	webshareRepo := repository.NewWebshareRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	clientRepo := repository.NewClientRepository(db)
+	poolRepo := repository.NewPoolRepository(db)
+	healthCheckRepo := repository.NewHealthCheckRepository(db)
+	requestRepo := repository.NewRequestRepository(db)
 
 	// Generate random JWT secret on startup
 	// This ensures all previous tokens become invalid on restart
 	jwtSecret := generateJWTSecret()
 	log.Info("generated new JWT secret for this session", "length", len(jwtSecret))
 
-	// Create usage tracker for health checks
-	tracker := proxy.NewUsageTracker(proxyRepo)
-
-	// Create health checker for testing proxies
-	healthChecker := proxy.NewHealthChecker(proxyRepo, settingsRepo, tracker, log)
-
 	// Initialize Webshare sync service and handler
 	hasAPIKey := cfg.WebshareAPIKey != ""
 	var webshareSyncService *services.WebshareSyncService
@@ -92,6 +114,7 @@ func New(cfg *config.Config, log *logger.Logger, db *database.DB) *Server {
 		webshareHandler = handlers.NewWebshareHandler(
 			webshareSyncService,
 			webshareRepo,
+			settingsRepo,
 			hasAPIKey,
 			cfg.WebshareSyncIntervalSeconds,
 			log,
@@ -101,6 +124,7 @@ func New(cfg *config.Config, log *logger.Logger, db *database.DB) *Server {
 		webshareHandler = handlers.NewWebshareHandler(
 			nil,
 			webshareRepo,
+			settingsRepo,
 			hasAPIKey,
 			cfg.WebshareSyncIntervalSeconds,
 			log,
@@ -108,12 +132,12 @@ func New(cfg *config.Config, log *logger.Logger, db *database.DB) *Server {
 	}
 
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(settingsRepo, log, jwtSecret, cfg.AdminUser, cfg.AdminPass)
-	healthHandler := handlers.NewHealthHandler(db, proxyRepo, log)
+	authHandler := handlers.NewAuthHandler(userRepo, log, jwtSecret)
+	userHandler := handlers.NewUserHandler(userRepo, log)
+	healthHandler := handlers.NewHealthHandler(db, proxyRepo, healthCheckRepo, log)
 	dashboardHandler := handlers.NewDashboardHandler(dashboardRepo, proxyRepo, log)
-	proxyHandler := handlers.NewProxyHandler(proxyRepo, healthChecker, log)
 	logsHandler := handlers.NewLogsHandler(logRepo, log)
-	settingsHandler := handlers.NewSettingsHandler(settingsRepo, log)
+	settingsHandler := handlers.NewSettingsHandler(settingsRepo, settingsBus, log)
 	websocketHandler := handlers.NewWebSocketHandler(dashboardRepo, proxyRepo, logRepo, log)
 	metricsHandler := handlers.NewMetricsHandler(log)
 	documentationHandler := handlers.NewDocumentationHandler()
@@ -123,10 +147,13 @@ func New(cfg *config.Config, log *logger.Logger, db *database.DB) *Server {
 		logger:               log,
 		db:                   db,
 		port:                 cfg.APIPort,
+		jwtSecret:            []byte(jwtSecret),
+		userRepo:             userRepo,
+		metricsToken:         cfg.MetricsToken,
 		authHandler:          authHandler,
+		userHandler:          userHandler,
 		healthHandler:        healthHandler,
 		dashboardHandler:     dashboardHandler,
-		proxyHandler:         proxyHandler,
 		logsHandler:          logsHandler,
 		settingsHandler:      settingsHandler,
 		websocketHandler:     websocketHandler,
@@ -136,6 +163,12 @@ func New(cfg *config.Config, log *logger.Logger, db *database.DB) *Server {
 		webshareSyncService:  webshareSyncService,
 	}
 
+	// Client, pool and route changes are pushed to the proxy server once it is attached
+	s.clientHandler = handlers.NewClientHandler(clientRepo, s, log)
+	s.proxyHandler = handlers.NewProxyHandler(proxyRepo, requestRepo, healthChecker, s, log)
+	s.poolHandler = handlers.NewPoolHandler(poolRepo, s, log)
+	s.routeHandler = handlers.NewRouteHandler(settingsRepo, s, log)
+
 	s.setupMiddleware()
 	s.setupRoutes()
 
@@ -170,12 +203,26 @@ func (s *Server) setupMiddleware() {
 	s.router.Use(LoggerMiddleware(s.logger))
 	s.router.Use(middleware.Recoverer)
 	s.router.Use(middleware.Timeout(60 * time.Second))
+
+	// Everything except the public allowlist requires a valid dashboard token
+	s.router.Use(JWTAuthMiddleware(s.jwtSecret, s.userRepo, publicPaths, s.logger))
+}
+
+// publicPaths lists the routes that are reachable without a dashboard token
+var publicPaths = map[string]bool{
+	"/health":              true,
+	"/metrics":             true, // checks METRICS_TOKEN itself
+	"/docs":                true,
+	"/api/v1/swagger.json": true,
+	"/api/v1/health":       true,
+	"/api/v1/auth/login":   true,
 }
 
 // setupRoutes configures all API routes
 func (s *Server) setupRoutes() {
-	// Public routes (no auth required)
+	// Public routes (no auth required, see publicPaths)
 	s.router.Get("/health", s.healthHandler.Health)
+	s.router.Get("/metrics", s.serveMetrics)
 
 	// API Documentation
 	s.router.Get("/docs", s.documentationHandler.ServeDocumentation)
@@ -189,46 +236,114 @@ func (s *Server) setupRoutes() {
 		// Authentication
 		r.Post("/auth/login", s.authHandler.Login)
 
-		// Health & Status
-		r.Get("/status", s.healthHandler.Status)
-		r.Get("/database/health", s.healthHandler.DatabaseHealth)
-		r.Get("/database/stats", s.healthHandler.DatabaseStats)
-
-		// System Metrics
-		r.Get("/metrics/system", s.metricsHandler.GetSystemMetrics)
-
-		// Dashboard endpoints
-		r.Get("/dashboard/stats", s.dashboardHandler.GetStats)
-		r.Get("/dashboard/charts/response-time", s.dashboardHandler.GetResponseTimeChart)
-		r.Get("/dashboard/charts/success-rate", s.dashboardHandler.GetSuccessRateChart)
-
-		// Proxy management
-		r.Get("/proxies", s.proxyHandler.List)
-		r.Post("/proxies", s.proxyHandler.Create)
-		r.Post("/proxies/bulk", s.proxyHandler.BulkCreate)
-		r.Post("/proxies/bulk-delete", s.proxyHandler.BulkDelete)
-		r.Post("/proxies/bulk-test", s.proxyHandler.BulkTest)
-		r.Get("/proxies/export", s.proxyHandler.Export)
-		r.Put("/proxies/{id}", s.proxyHandler.Update)
-		r.Delete("/proxies/{id}", s.proxyHandler.Delete)
-		r.Post("/proxies/{id}/test", s.proxyHandler.Test)
-		r.Post("/proxies/reload", s.ReloadProxyPool)
-
-		// System logs
-		r.Get("/logs", s.logsHandler.List)
-		r.Get("/logs/export", s.logsHandler.Export)
-
-		// Settings
-		r.Get("/settings", s.settingsHandler.Get)
-		r.Put("/settings", s.settingsHandler.Update)
-		r.Post("/settings/reset", s.settingsHandler.Reset)
-
-		// Webshare sync
-		r.Post("/webshare/sync", s.webshareHandler.Sync)
-		r.Get("/webshare/sync/status", s.webshareHandler.GetStatus)
+		// Read-only routes (viewer and above)
+		r.Group(func(r chi.Router) {
+			r.Use(RequireRole(models.UserRoleViewer, models.UserRoleOperator, models.UserRoleAdmin))
+
+			// Health & Status
+			r.Get("/status", s.healthHandler.Status)
+			r.Get("/database/health", s.healthHandler.DatabaseHealth)
+			r.Get("/database/stats", s.healthHandler.DatabaseStats)
+			r.Get("/healthcheck/runs", s.healthHandler.HealthCheckRuns)
+
+			// System Metrics
+			r.Get("/metrics/system", s.metricsHandler.GetSystemMetrics)
+
+			// Dashboard endpoints
+			r.Get("/dashboard/stats", s.dashboardHandler.GetStats)
+			r.Get("/dashboard/charts/response-time", s.dashboardHandler.GetResponseTimeChart)
+			r.Get("/dashboard/charts/success-rate", s.dashboardHandler.GetSuccessRateChart)
+
+			// Proxy listing
+			r.Get("/proxies", s.proxyHandler.List)
+			r.Get("/proxies/{id}/health-history", s.healthHandler.ProxyHealthHistory)
+			r.Get("/proxies/{id}/requests", s.proxyHandler.Requests)
+			r.Get("/proxies/{id}/stats", s.proxyHandler.Stats)
+
+			// Proxy pools
+			r.Get("/pools", s.poolHandler.List)
+			r.Get("/pools/{id}", s.poolHandler.Get)
+
+			// Reverse-proxy routes
+			r.Get("/routes", s.routeHandler.List)
+
+			// Sticky sessions
+			r.Get("/sessions", s.ListSessions)
+
+			// Circuit breakers
+			r.Get("/circuits", s.ListCircuits)
+
+			// Upstream connection pools
+			r.Get("/transports", s.ListTransports)
+
+			// System logs
+			r.Get("/logs", s.logsHandler.List)
+			r.Get("/logs/export", s.logsHandler.Export)
+
+			// Webshare sync status
+			r.Get("/webshare/sync/status", s.webshareHandler.GetStatus)
+		})
+
+		// Proxy operations (operator and above)
+		r.Group(func(r chi.Router) {
+			r.Use(RequireRole(models.UserRoleOperator, models.UserRoleAdmin))
+
+			r.Post("/proxies", s.proxyHandler.Create)
+			r.Post("/proxies/bulk", s.proxyHandler.BulkCreate)
+			r.Post("/proxies/bulk-test", s.proxyHandler.BulkTest)
+			r.Post("/proxies/bulk-tags", s.proxyHandler.BulkTags)
+			r.Get("/proxies/export", s.proxyHandler.Export)
+			r.Put("/proxies/{id}", s.proxyHandler.Update)
+			r.Delete("/proxies/{id}", s.proxyHandler.Delete)
+			r.Post("/proxies/{id}/test", s.proxyHandler.Test)
+			r.Post("/proxies/reload", s.ReloadProxyPool)
+
+			// Proxy pools (write)
+			r.Post("/pools", s.poolHandler.Create)
+			r.Put("/pools/{id}", s.poolHandler.Update)
+			r.Delete("/pools/{id}", s.poolHandler.Delete)
+
+			// Settings (read)
+			r.Get("/settings", s.settingsHandler.Get)
+
+			// Proxy clients (read)
+			r.Get("/clients", s.clientHandler.List)
+			r.Get("/clients/{id}", s.clientHandler.Get)
+
+			// Webshare sync
+			r.Post("/webshare/sync", s.webshareHandler.Sync)
+		})
+
+		// Administration (admin only)
+		r.Group(func(r chi.Router) {
+			r.Use(RequireRole(models.UserRoleAdmin))
+
+			r.Post("/proxies/bulk-delete", s.proxyHandler.BulkDelete)
+
+			// Settings (write)
+			r.Put("/settings", s.settingsHandler.Update)
+			r.Post("/settings/reset", s.settingsHandler.Reset)
+
+			// Reverse-proxy routes (write)
+			r.Post("/routes", s.routeHandler.Create)
+			r.Put("/routes/{name}", s.routeHandler.Update)
+			r.Delete("/routes/{name}", s.routeHandler.Delete)
+
+			// Proxy clients (write)
+			r.Post("/clients", s.clientHandler.Create)
+			r.Put("/clients/{id}", s.clientHandler.Update)
+			r.Delete("/clients/{id}", s.clientHandler.Delete)
+
+			// User management
+			r.Get("/users", s.userHandler.List)
+			r.Post("/users", s.userHandler.Create)
+			r.Get("/users/{id}", s.userHandler.Get)
+			r.Put("/users/{id}", s.userHandler.Update)
+			r.Delete("/users/{id}", s.userHandler.Delete)
+		})
 	})
 
-	// WebSocket routes
+	// WebSocket routes (token via query param or subprotocol)
 	s.router.Get("/ws/dashboard", s.websocketHandler.DashboardWebSocket)
 	s.router.Get("/ws/logs", s.websocketHandler.LogsWebSocket)
 }
@@ -255,6 +370,38 @@ func (s *Server) SetProxyServer(ps ProxyServer) {
 	s.proxyServer = ps
 }
 
+// RefreshClients reloads proxy client credentials in the proxy server, if attached
+func (s *Server) RefreshClients(ctx context.Context) error {
+	if s.proxyServer == nil {
+		return nil
+	}
+	return s.proxyServer.RefreshClients(ctx)
+}
+
+// RefreshPools reloads proxy pool definitions in the proxy server, if attached
+func (s *Server) RefreshPools(ctx context.Context) error {
+	if s.proxyServer == nil {
+		return nil
+	}
+	return s.proxyServer.RefreshPools(ctx)
+}
+
+// RefreshRoutes reloads the reverse-proxy route table in the proxy server, if attached
+func (s *Server) RefreshRoutes(ctx context.Context) error {
+	if s.proxyServer == nil {
+		return nil
+	}
+	return s.proxyServer.RefreshRoutes(ctx)
+}
+
+// InvalidateProxies drops cached transports of updated or deleted proxies in the proxy server, if attached
+func (s *Server) InvalidateProxies(proxyIDs ...int) {
+	if s.proxyServer == nil {
+		return
+	}
+	s.proxyServer.InvalidateProxies(proxyIDs...)
+}
+
 // ReloadProxyPool reloads the proxy pool from database
 //
 //	@Summary		Reload proxy pool
@@ -288,6 +435,84 @@ func (s *Server) ReloadProxyPool(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"success","message":"Proxy pool reloaded successfully"}`))
 }
 
+// ListSessions lists the active sticky sessions
+//
+//	@Summary		List sticky sessions
+//	@Description	Get sticky sessions ("-session-<id>" proxy usernames) currently pinned to an upstream proxy
+//	@Tags			proxies
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}	"Active sessions"
+//	@Failure		503	{object}	models.ErrorResponse
+//	@Router			/sessions [get]
+func (s *Server) ListSessions(w http.ResponseWriter, r *http.Request) {
+	if s.proxyServer == nil {
+		s.logger.Error("proxy server not initialized")
+		http.Error(w, "proxy server not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	sessions := s.proxyServer.ListSessions()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// ListCircuits lists upstream proxy circuit breakers
+//
+//	@Summary		List circuit breakers
+//	@Description	Get proxies whose circuit breaker is open, half-open or counting consecutive failures
+//	@Tags			proxies
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}	"Circuit breaker states"
+//	@Failure		503	{object}	models.ErrorResponse
+//	@Router			/circuits [get]
+func (s *Server) ListCircuits(w http.ResponseWriter, r *http.Request) {
+	if s.proxyServer == nil {
+		s.logger.Error("proxy server not initialized")
+		http.Error(w, "proxy server not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	circuits := s.proxyServer.ListCircuits()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"circuits": circuits,
+		"total":    len(circuits),
+	})
+}
+
+// ListTransports lists the connection pools kept for upstream proxies
+//
+//	@Summary		List upstream connection pools
+//	@Description	Get the cached transport of each recently used upstream proxy with its open connections, dials and requests
+//	@Tags			proxies
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}	"Connection pool stats"
+//	@Failure		503	{object}	models.ErrorResponse
+//	@Router			/transports [get]
+func (s *Server) ListTransports(w http.ResponseWriter, r *http.Request) {
+	if s.proxyServer == nil {
+		s.logger.Error("proxy server not initialized")
+		http.Error(w, "proxy server not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	transports := s.proxyServer.ListTransports()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"transports": transports,
+		"total":      len(transports),
+	})
+}
+
 // serveSwaggerJSON serves the swagger.json file
 func (s *Server) serveSwaggerJSON(w http.ResponseWriter, r *http.Request) {
 	// Serve from the docs directory in the project root
@@ -295,6 +520,21 @@ func (s *Server) serveSwaggerJSON(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, swaggerPath)
 }
 
+// serveMetrics serves the Prometheus metrics of the proxy server and API
+// Scrapers must send METRICS_TOKEN as a bearer token when it is set.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metricsToken != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.metricsToken)) != 1 {
+			metrics.AuthFailures.WithLabelValues("api", "invalid_metrics_token").Inc()
+			unauthorized(w, "Invalid metrics token")
+			return
+		}
+	}
+
+	metrics.Handler().ServeHTTP(w, r)
+}
+
 // GetWebshareSyncService returns the Webshare sync service
 func (s *Server) GetWebshareSyncService() *services.WebshareSyncService {
 	return s.webshareSyncService
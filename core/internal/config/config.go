@@ -9,6 +9,7 @@ import (
 // Config holds all application configuration
 type Config struct {
 	ProxyPort                int
+	SOCKS5Port               int // 0 disables the SOCKS5 listener
 	APIPort                  int
 	LogLevel                 string
 	Database                 DatabaseConfig
@@ -17,6 +18,10 @@ type Config struct {
 	WebshareAPIKey           string
 	WebshareSyncIntervalSeconds int
 	WebshareMode             string
+	GeoIPDBPath              string
+	GeoIPASNDBPath           string
+	MetricsToken             string // Bearer token required by /metrics (empty leaves it public)
+	OTLPEndpoint             string // OTLP/HTTP endpoint traces are exported to (empty disables tracing)
 }
 
 // DatabaseConfig holds database configuration
@@ -41,6 +46,7 @@ func (d *DatabaseConfig) DSN() string {
 func Load() (*Config, error) {
 	cfg := &Config{
 		ProxyPort: getEnvAsInt("PROXY_PORT", 8000),
+		SOCKS5Port: getEnvAsInt("SOCKS5_PORT", 0),
 		APIPort:   getEnvAsInt("API_PORT", 8001),
 		LogLevel:  getEnv("LOG_LEVEL", "info"),
 		Database: DatabaseConfig{
@@ -56,6 +62,10 @@ func Load() (*Config, error) {
 		WebshareAPIKey:           getEnv("WEBSHARE_API_KEY", ""),
 		WebshareSyncIntervalSeconds: getEnvAsInt("WEBSHARE_SYNC_INTERVAL_SECONDS", 0),
 		WebshareMode:             getEnv("WEBSHARE_MODE", "direct"),
+		GeoIPDBPath:              getEnv("GEOIP_DB_PATH", ""),
+		GeoIPASNDBPath:           getEnv("GEOIP_ASN_DB_PATH", ""),
+		MetricsToken:             getEnv("METRICS_TOKEN", ""),
+		OTLPEndpoint:             getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")),
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -76,6 +86,12 @@ func (c *Config) Validate() error {
 	if c.ProxyPort == c.APIPort {
 		return fmt.Errorf("proxy port and API port cannot be the same: %d", c.ProxyPort)
 	}
+	if c.SOCKS5Port < 0 || c.SOCKS5Port > 65535 {
+		return fmt.Errorf("invalid SOCKS5 port: %d", c.SOCKS5Port)
+	}
+	if c.SOCKS5Port != 0 && (c.SOCKS5Port == c.ProxyPort || c.SOCKS5Port == c.APIPort) {
+		return fmt.Errorf("SOCKS5 port cannot be the same as the proxy or API port: %d", c.SOCKS5Port)
+	}
 
 	validLogLevels := map[string]bool{
 		"debug": true,
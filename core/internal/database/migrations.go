@@ -312,6 +312,317 @@ var migrations = []Migration{
 			WHERE key = 'healthcheck';
 		`,
 	},
+	{
+		Version:     14,
+		Description: "Create users table for dashboard accounts",
+		Up: `
+			CREATE TABLE IF NOT EXISTS users (
+				id SERIAL PRIMARY KEY,
+				username VARCHAR(255) NOT NULL UNIQUE,
+				password_hash TEXT NOT NULL,
+				role VARCHAR(20) NOT NULL DEFAULT 'viewer' CHECK (role IN ('admin', 'operator', 'viewer')),
+				created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+			);
+
+			CREATE INDEX idx_users_role ON users(role);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_users_role;
+			DROP TABLE IF EXISTS users;
+		`,
+	},
+	{
+		Version:     15,
+		Description: "Create proxy_clients tables and attribute proxy requests to clients",
+		Up: `
+			CREATE TABLE IF NOT EXISTS proxy_clients (
+				id SERIAL PRIMARY KEY,
+				username VARCHAR(255) NOT NULL UNIQUE,
+				password_hash TEXT NOT NULL,
+				description TEXT,
+				enabled BOOLEAN NOT NULL DEFAULT TRUE,
+				requests_per_minute INTEGER NOT NULL DEFAULT 0,
+				daily_request_quota BIGINT NOT NULL DEFAULT 0,
+				daily_byte_quota BIGINT NOT NULL DEFAULT 0,
+				created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+			);
+
+			-- Daily usage counters used for quota enforcement and billing
+			CREATE TABLE IF NOT EXISTS proxy_client_usage (
+				client_id INTEGER NOT NULL REFERENCES proxy_clients(id) ON DELETE CASCADE,
+				day DATE NOT NULL,
+				requests BIGINT NOT NULL DEFAULT 0,
+				bytes BIGINT NOT NULL DEFAULT 0,
+				PRIMARY KEY (client_id, day)
+			);
+
+			ALTER TABLE proxy_requests ADD COLUMN IF NOT EXISTS client_id INTEGER;
+			CREATE INDEX IF NOT EXISTS idx_proxy_requests_client_id ON proxy_requests(client_id, timestamp DESC);
+		`,
+		Down: `
+			DROP INDEX IF EXISTS idx_proxy_requests_client_id;
+			ALTER TABLE proxy_requests DROP COLUMN IF EXISTS client_id;
+			DROP TABLE IF EXISTS proxy_client_usage;
+			DROP TABLE IF EXISTS proxy_clients;
+		`,
+	},
+	{
+		Version:     16,
+		Description: "Add rotation sticky_session_ttl setting",
+		Up: `
+			UPDATE settings
+			SET value = jsonb_set(
+				value,
+				'{sticky_session_ttl}',
+				'600'::jsonb
+			)
+			WHERE key = 'rotation'
+			AND NOT (value ? 'sticky_session_ttl');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value - 'sticky_session_ttl'
+			WHERE key = 'rotation';
+		`,
+	},
+	{
+		Version:     17,
+		Description: "Add proxy tags and proxy_pools table",
+		Up: `
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS tags JSONB NOT NULL DEFAULT '{}'::jsonb;
+			CREATE INDEX IF NOT EXISTS idx_proxies_tags ON proxies USING GIN (tags);
+
+			CREATE TABLE IF NOT EXISTS proxy_pools (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(100) NOT NULL UNIQUE,
+				description TEXT,
+				tags JSONB NOT NULL DEFAULT '{}'::jsonb,
+				method VARCHAR(50) NOT NULL DEFAULT '',
+				created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+				updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+			);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS proxy_pools;
+			DROP INDEX IF EXISTS idx_proxies_tags;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS tags;
+		`,
+	},
+	{
+		Version:     18,
+		Description: "Add proxy exit IP and GeoIP columns and healthcheck exit_ip_url setting",
+		Up: `
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS exit_ip VARCHAR(45);
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS city VARCHAR(255);
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS asn INTEGER;
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS as_org VARCHAR(255);
+			CREATE INDEX IF NOT EXISTS idx_proxies_country ON proxies(country);
+			CREATE INDEX IF NOT EXISTS idx_proxies_asn ON proxies(asn);
+
+			UPDATE settings
+			SET value = jsonb_set(
+				value,
+				'{exit_ip_url}',
+				'"https://api.ipify.org"'::jsonb
+			)
+			WHERE key = 'healthcheck'
+			AND NOT (value ? 'exit_ip_url');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value - 'exit_ip_url'
+			WHERE key = 'healthcheck';
+
+			DROP INDEX IF EXISTS idx_proxies_asn;
+			DROP INDEX IF EXISTS idx_proxies_country;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS as_org;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS asn;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS city;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS country;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS exit_ip;
+		`,
+	},
+	{
+		Version:     19,
+		Description: "Create health_check_runs table and add healthcheck interval_minutes setting",
+		Up: `
+			CREATE TABLE IF NOT EXISTS health_check_runs (
+				id SERIAL PRIMARY KEY,
+				started_at TIMESTAMP NOT NULL,
+				finished_at TIMESTAMP NOT NULL,
+				checked INTEGER NOT NULL DEFAULT 0,
+				healthy INTEGER NOT NULL DEFAULT 0,
+				failed INTEGER NOT NULL DEFAULT 0,
+				resurrected INTEGER NOT NULL DEFAULT 0,
+				error TEXT
+			);
+			CREATE INDEX IF NOT EXISTS idx_health_check_runs_started_at ON health_check_runs(started_at DESC);
+
+			UPDATE settings
+			SET value = jsonb_set(
+				value,
+				'{interval_minutes}',
+				'5'::jsonb
+			)
+			WHERE key = 'healthcheck'
+			AND NOT (value ? 'interval_minutes');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value - 'interval_minutes'
+			WHERE key = 'healthcheck';
+
+			DROP TABLE IF EXISTS health_check_runs;
+		`,
+	},
+	{
+		Version:     20,
+		Description: "Add rotation circuit_breaker setting",
+		Up: `
+			UPDATE settings
+			SET value = jsonb_set(
+				value,
+				'{circuit_breaker}',
+				'{"failure_threshold": 5, "open_seconds": 30, "max_open_seconds": 600, "half_open_requests": 1}'::jsonb
+			)
+			WHERE key = 'rotation'
+			AND NOT (value ? 'circuit_breaker');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value - 'circuit_breaker'
+			WHERE key = 'rotation';
+		`,
+	},
+	{
+		Version:     21,
+		Description: "Add rotation rate_limited max_wait_seconds setting",
+		Up: `
+			UPDATE settings
+			SET value = jsonb_set(
+				value,
+				'{rate_limited,max_wait_seconds}',
+				'5'::jsonb
+			)
+			WHERE key = 'rotation'
+			AND value ? 'rate_limited'
+			AND NOT (value->'rate_limited' ? 'max_wait_seconds');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value #- '{rate_limited,max_wait_seconds}'
+			WHERE key = 'rotation';
+		`,
+	},
+	{
+		Version:     22,
+		Description: "Add traffic and tunnel columns to proxy_requests",
+		Up: `
+			ALTER TABLE proxy_requests ADD COLUMN IF NOT EXISTS bytes_sent BIGINT;
+			ALTER TABLE proxy_requests ADD COLUMN IF NOT EXISTS bytes_received BIGINT;
+			ALTER TABLE proxy_requests ADD COLUMN IF NOT EXISTS duration INTEGER;
+			ALTER TABLE proxy_requests ADD COLUMN IF NOT EXISTS close_reason VARCHAR(50);
+		`,
+		Down: `
+			ALTER TABLE proxy_requests DROP COLUMN IF EXISTS close_reason;
+			ALTER TABLE proxy_requests DROP COLUMN IF EXISTS duration;
+			ALTER TABLE proxy_requests DROP COLUMN IF EXISTS bytes_received;
+			ALTER TABLE proxy_requests DROP COLUMN IF EXISTS bytes_sent;
+		`,
+	},
+	{
+		Version:     23,
+		Description: "Add TLS settings for https proxies",
+		Up: `
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS tls_server_name VARCHAR(255);
+			ALTER TABLE proxies ADD COLUMN IF NOT EXISTS ca_bundle TEXT;
+		`,
+		Down: `
+			ALTER TABLE proxies DROP COLUMN IF EXISTS ca_bundle;
+			ALTER TABLE proxies DROP COLUMN IF EXISTS tls_server_name;
+		`,
+	},
+	{
+		Version:     24,
+		Description: "Add reverse-proxy route table with the former built-in Hyperliquid route",
+		Up: `
+			INSERT INTO settings (key, value) VALUES
+			('routes', '{"routes": [{"name": "hyperliquid", "path_prefix": "/hyperliquid", "target": "https://api.hyperliquid.xyz", "auth_required": false, "rate_limit": {"enabled": false, "interval": 1, "max_requests": 100}}]}'::jsonb)
+			ON CONFLICT (key) DO NOTHING;
+		`,
+		Down: `
+			DELETE FROM settings WHERE key = 'routes';
+		`,
+	},
+	{
+		Version:     25,
+		Description: "Add rotation retry_body_limit and retry_non_idempotent settings",
+		Up: `
+			UPDATE settings
+			SET value = value || '{"retry_body_limit": 1048576, "retry_non_idempotent": false}'::jsonb
+			WHERE key = 'rotation'
+			AND NOT (value ? 'retry_body_limit');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value - 'retry_body_limit' - 'retry_non_idempotent'
+			WHERE key = 'rotation';
+		`,
+	},
+	{
+		Version:     26,
+		Description: "Add rotation blocked response setting",
+		Up: `
+			UPDATE settings
+			SET value = jsonb_set(
+				value,
+				'{blocked}',
+				'{"status_codes": [], "body_patterns": [], "max_retries": 2, "backoff_ms": 500, "max_backoff_ms": 5000, "cooldown_seconds": 60}'::jsonb
+			)
+			WHERE key = 'rotation'
+			AND NOT (value ? 'blocked');
+		`,
+		Down: `
+			UPDATE settings
+			SET value = value - 'blocked'
+			WHERE key = 'rotation';
+		`,
+	},
+	{
+		Version:     27,
+		Description: "Create proxy_health_checks table as hypertable",
+		Up: `
+			CREATE TABLE IF NOT EXISTS proxy_health_checks (
+				timestamp TIMESTAMP NOT NULL DEFAULT NOW(),
+				proxy_id INTEGER NOT NULL REFERENCES proxies(id) ON DELETE CASCADE,
+				success BOOLEAN NOT NULL,
+				response_time INTEGER NOT NULL,
+				error TEXT
+			);
+
+			-- Create hypertable
+			SELECT create_hypertable('proxy_health_checks', 'timestamp', if_not_exists => TRUE);
+
+			-- Create indexes
+			CREATE INDEX IF NOT EXISTS idx_proxy_health_checks_proxy_id ON proxy_health_checks(proxy_id, timestamp DESC);
+
+			-- Add retention policy (keep check history for 90 days)
+			SELECT add_retention_policy('proxy_health_checks', INTERVAL '90 days', if_not_exists => TRUE);
+
+			-- Add compression policy (compress data older than 14 days)
+			ALTER TABLE proxy_health_checks SET (
+				timescaledb.compress,
+				timescaledb.compress_segmentby = 'proxy_id'
+			);
+			SELECT add_compression_policy('proxy_health_checks', INTERVAL '14 days', if_not_exists => TRUE);
+		`,
+		Down: `
+			DROP TABLE IF EXISTS proxy_health_checks;
+		`,
+	},
 }
 
 // Migrate runs all pending migrations
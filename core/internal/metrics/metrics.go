@@ -0,0 +1,208 @@
+// Package metrics holds the Prometheus metrics of the proxy server and API
+// Metrics are updated in process where the events happen and served by Handler.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "rota"
+
+// Request results
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultBlocked = "blocked" // the target answered with a blocked response
+)
+
+// registry holds Rota's metrics plus the Go runtime and process collectors
+var registry = prometheus.NewRegistry()
+
+// latencyBuckets are the histogram buckets for upstream and health check latency, in seconds
+var latencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var factory = promauto.With(registry)
+
+var (
+	// ProxyRequests counts requests sent through an upstream proxy, one per
+	// attempt, like the rows of proxy_requests
+	ProxyRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "proxy_requests_total",
+		Help:      "Requests sent through upstream proxies by result, client method and proxy ID.",
+	}, []string{"result", "method", "proxy_id"})
+
+	// UpstreamLatency observes how long upstream proxies take to answer a request
+	// or open a tunnel
+	UpstreamLatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time until an upstream proxy returned response headers (kind http) or opened a tunnel (kind connect).",
+		Buckets:   latencyBuckets,
+	}, []string{"kind", "result"})
+
+	// TunnelsOpen is the number of open CONNECT tunnels
+	TunnelsOpen = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tunnels_open",
+		Help:      "CONNECT and SOCKS5 tunnels currently open.",
+	})
+
+	// TunnelsClosed counts closed CONNECT tunnels by close reason
+	TunnelsClosed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tunnels_closed_total",
+		Help:      "Closed CONNECT and SOCKS5 tunnels by close reason.",
+	}, []string{"close_reason"})
+
+	// TunnelBytes counts bytes relayed through CONNECT tunnels
+	TunnelBytes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tunnel_bytes_total",
+		Help:      "Bytes relayed through closed tunnels, sent (client to target) or received (target to client).",
+	}, []string{"direction"})
+
+	// SelectorErrors counts requests that failed because no proxy could be selected
+	SelectorErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "selector_errors_total",
+		Help:      "Requests failed because no proxy was available, by reason.",
+	}, []string{"reason"})
+
+	// Retries counts resent requests: retries through the same proxy, fallbacks
+	// to another proxy and retries after blocked responses
+	Retries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "retries_total",
+		Help:      "Requests resent after a failure, by kind (retry, fallback, blocked).",
+	}, []string{"kind"})
+
+	// Proxies is the number of proxies by status
+	Proxies = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "proxies",
+		Help:      "Proxies by status, updated on every proxy list refresh.",
+	}, []string{"status"})
+
+	// RateLimitRejections counts requests rejected by rate limits and quotas
+	RateLimitRejections = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Proxy requests rejected by rate limits or client quotas, by reason.",
+	}, []string{"reason"})
+
+	// AuthFailures counts failed authentications
+	AuthFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Failed authentications of proxy clients (server proxy) and dashboard users (server api).",
+	}, []string{"server", "reason"})
+
+	// HealthChecks counts proxy health checks by result
+	HealthChecks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "health_checks_total",
+		Help:      "Proxy health checks by result (passed, error, bad_status).",
+	}, []string{"result"})
+
+	// HealthCheckLatency observes how long health check requests take
+	HealthCheckLatency = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "health_check_duration_seconds",
+		Help:      "Duration of health check requests through proxies.",
+		Buckets:   latencyBuckets,
+	})
+
+	// WebshareSyncs counts Webshare sync runs by result
+	WebshareSyncs = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webshare_syncs_total",
+		Help:      "Webshare sync runs by result (success, failure, skipped).",
+	}, []string{"result"})
+
+	// WebshareSyncProxies counts proxies changed by Webshare syncs
+	WebshareSyncProxies = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webshare_sync_proxies_total",
+		Help:      "Proxies added, removed or sent for replacement by Webshare syncs.",
+	}, []string{"action"})
+
+	// UsageQueueDepth is the number of request records waiting to be written
+	UsageQueueDepth = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "usage_queue_depth",
+		Help:      "Request records waiting to be written to the database, as of the last flush.",
+	})
+
+	// UsageRecordsWritten counts request records written to the database
+	UsageRecordsWritten = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_records_written_total",
+		Help:      "Request records written to proxy_requests.",
+	})
+
+	// UsageRecordsDropped counts request records that were never written
+	UsageRecordsDropped = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_records_dropped_total",
+		Help:      "Request records dropped, by reason (queue_full, write_error, deleted_proxy, closed).",
+	}, []string{"reason"})
+
+	// UsageFlushes counts batch writes of request records by result
+	UsageFlushes = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "usage_flushes_total",
+		Help:      "Batch writes of request records by result.",
+	}, []string{"result"})
+
+	// UsageFlushDuration observes how long batch writes of request records take
+	UsageFlushDuration = factory.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "usage_flush_duration_seconds",
+		Help:      "Duration of batch writes of request records.",
+		Buckets:   latencyBuckets,
+	})
+
+	// ClientUsageDropped counts proxy client usage updates that were never written
+	ClientUsageDropped = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "client_usage_dropped_total",
+		Help:      "Proxy client usage updates dropped, by reason (queue_full, write_error, closed).",
+	}, []string{"reason"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ProxyLabel returns the proxy_id label value for a proxy
+func ProxyLabel(proxyID int) string {
+	return strconv.Itoa(proxyID)
+}
+
+// MethodLabel returns the method label value for a client's HTTP method
+// Methods other than the standard ones share "other", so clients can't create
+// unbounded series with made-up methods.
+func MethodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "other"
+	}
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
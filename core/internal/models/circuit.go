@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CircuitState represents the circuit breaker state of an upstream proxy
+type CircuitState struct {
+	ProxyID   int        `json:"proxy_id"`
+	State     string     `json:"state"`                // closed, open or half_open
+	Failures  int        `json:"failures"`             // consecutive failures while closed
+	Trips     int        `json:"trips"`                // consecutive openings
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`  // when the circuit last opened
+	OpenUntil *time.Time `json:"open_until,omitempty"` // when the circuit becomes half-open
+}
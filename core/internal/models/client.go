@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// ProxyClient represents a consumer credential for the proxy server (port 8000)
+// Each client has its own limits and daily quotas; 0 means unlimited
+type ProxyClient struct {
+	ID                int       `json:"id"`
+	Username          string    `json:"username"`
+	PasswordHash      string    `json:"-"` // Never expose password hash in JSON
+	Description       *string   `json:"description,omitempty"`
+	Enabled           bool      `json:"enabled"`
+	RequestsPerMinute int       `json:"requests_per_minute"`
+	DailyRequestQuota int64     `json:"daily_request_quota"`
+	DailyByteQuota    int64     `json:"daily_byte_quota"`
+	RequestsToday     int64     `json:"requests_today"`
+	BytesToday        int64     `json:"bytes_today"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// CreateProxyClientRequest represents a request to create a proxy client
+type CreateProxyClientRequest struct {
+	Username          string  `json:"username" validate:"required"`
+	Password          string  `json:"password" validate:"required,min=8"`
+	Description       *string `json:"description,omitempty"`
+	Enabled           *bool   `json:"enabled,omitempty"`
+	RequestsPerMinute int     `json:"requests_per_minute" validate:"min=0"`
+	DailyRequestQuota int64   `json:"daily_request_quota" validate:"min=0"`
+	DailyByteQuota    int64   `json:"daily_byte_quota" validate:"min=0"`
+}
+
+// UpdateProxyClientRequest represents a request to update a proxy client
+// Omitted fields are left unchanged
+type UpdateProxyClientRequest struct {
+	Password          *string `json:"password,omitempty" validate:"omitempty,min=8"`
+	Description       *string `json:"description,omitempty"`
+	Enabled           *bool   `json:"enabled,omitempty"`
+	RequestsPerMinute *int    `json:"requests_per_minute,omitempty" validate:"omitempty,min=0"`
+	DailyRequestQuota *int64  `json:"daily_request_quota,omitempty" validate:"omitempty,min=0"`
+	DailyByteQuota    *int64  `json:"daily_byte_quota,omitempty" validate:"omitempty,min=0"`
+}
@@ -10,6 +10,7 @@ type DashboardStats struct {
 	RequestGrowth      float64 `json:"request_growth"`
 	SuccessRateGrowth  float64 `json:"success_rate_growth"`
 	ResponseTimeDelta  int     `json:"response_time_delta"`
+	BytesToday         int64   `json:"bytes_today"` // Request/response and tunnel bytes in the last 24 hours
 }
 
 // ChartDataPoint represents a single data point in a chart
@@ -0,0 +1,48 @@
+package models
+
+import "strings"
+
+// GeoInfo is the location of a proxy's exit IP from the GeoIP database
+type GeoInfo struct {
+	Country string // ISO 3166-1 alpha-2 code, e.g. "DE"
+	City    string
+	ASN     int
+	ASOrg   string
+}
+
+// GeoFilter restricts proxies by exit location; zero fields match everything
+type GeoFilter struct {
+	Country    string // ISO 3166-1 alpha-2 code, case-insensitive
+	ASN        int
+	ExcludeASN []int // e.g. the ASNs of a cloud provider
+}
+
+// IsZero reports whether the filter matches every proxy
+func (f GeoFilter) IsZero() bool {
+	return f.Country == "" && f.ASN == 0 && len(f.ExcludeASN) == 0
+}
+
+// Matches reports whether a proxy with the given exit country and ASN passes the filter
+// Proxies with an unknown ASN can't be proven to be outside excluded networks.
+func (f GeoFilter) Matches(country *string, asn *int) bool {
+	if f.Country != "" && (country == nil || !strings.EqualFold(*country, f.Country)) {
+		return false
+	}
+
+	if f.ASN != 0 && (asn == nil || *asn != f.ASN) {
+		return false
+	}
+
+	if len(f.ExcludeASN) > 0 {
+		if asn == nil {
+			return false
+		}
+		for _, excluded := range f.ExcludeASN {
+			if *asn == excluded {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// HealthCheckRun summarises one scheduled health check pass over the proxy pool
+type HealthCheckRun struct {
+	ID          int       `json:"id"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	Checked     int       `json:"checked"`     // Proxies tested in this run
+	Healthy     int       `json:"healthy"`     // Proxies that passed
+	Failed      int       `json:"failed"`      // Proxies that failed
+	Resurrected int       `json:"resurrected"` // Failed proxies that passed their retest
+	Error       *string   `json:"error,omitempty"`
+}
+
+// ProxyHealthCheck represents one health check result of a proxy
+type ProxyHealthCheck struct {
+	Timestamp    time.Time `json:"timestamp"`
+	Success      bool      `json:"success"`
+	ResponseTime int       `json:"response_time"` // in milliseconds
+	Error        *string   `json:"error,omitempty"`
+}
+
+// LatencyPercentiles represents response time percentiles in milliseconds
+type LatencyPercentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// ProxyHealthHistory represents a proxy's health checks over a time window
+type ProxyHealthHistory struct {
+	ProxyID       int                 `json:"proxy_id"`
+	Window        string              `json:"window"`
+	Checks        int                 `json:"checks"`
+	Passed        int                 `json:"passed"`
+	UptimePercent *float64            `json:"uptime_percent"` // null without checks
+	Latency       *LatencyPercentiles `json:"latency"`        // of passed checks, null without any
+	Results       []ProxyHealthCheck  `json:"results"`        // newest first, up to the requested limit
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ProxyPool represents a named sub-pool of proxies selected by tags
+// Clients pick it with the X-Rota-Pool header or a "-pool-<name>" username suffix
+type ProxyPool struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	Tags        Tags      `json:"tags"`   // Proxies must carry all of these tags
+	Method      string    `json:"method"` // Rotation method, empty uses rotation.method
+	ProxyCount  int       `json:"proxy_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateProxyPoolRequest represents a request to create a proxy pool
+type CreateProxyPoolRequest struct {
+	Name        string  `json:"name" validate:"required"`
+	Description *string `json:"description,omitempty"`
+	Tags        Tags    `json:"tags" validate:"required,min=1"`
+	Method      string  `json:"method,omitempty"`
+}
+
+// UpdateProxyPoolRequest represents a request to update a proxy pool
+// Omitted fields are left unchanged
+type UpdateProxyPoolRequest struct {
+	Description *string `json:"description,omitempty"`
+	Tags        Tags    `json:"tags,omitempty"`
+	Method      *string `json:"method,omitempty"`
+}
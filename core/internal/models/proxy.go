@@ -16,10 +16,21 @@ type Proxy struct {
 	AvgResponseTime    int        `json:"avg_response_time"`
 	LastCheck          *time.Time `json:"last_check,omitempty"`
 	LastError          *string    `json:"-"`
+	Tags               Tags       `json:"tags"`
+	ExitIP             *string    `json:"exit_ip,omitempty"` // Egress IP seen by the exit IP echo endpoint
+	Country            *string    `json:"country,omitempty"` // ISO country code of the exit IP
+	City               *string    `json:"city,omitempty"`
+	ASN                *int       `json:"asn,omitempty"`
+	ASOrg              *string    `json:"as_org,omitempty"`
+	TLSServerName      *string    `json:"tls_server_name,omitempty"` // SNI for https proxies, defaults to the address host
+	CABundle           *string    `json:"ca_bundle,omitempty"`       // PEM CA certificates trusted for https proxies, system roots if empty
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
+// Tags are key/value labels on a proxy, e.g. {"country": "us", "tier": "residential"}
+type Tags map[string]string
+
 // ProxyWithStats represents a proxy with calculated statistics
 type ProxyWithStats struct {
 	ID              int        `json:"id"`
@@ -31,24 +42,36 @@ type ProxyWithStats struct {
 	SuccessRate     float64    `json:"success_rate"`
 	AvgResponseTime int        `json:"avg_response_time"`
 	LastCheck       *time.Time `json:"last_check,omitempty"`
+	Tags            Tags       `json:"tags"`
+	ExitIP          *string    `json:"exit_ip,omitempty"`
+	Country         *string    `json:"country,omitempty"`
+	City            *string    `json:"city,omitempty"`
+	ASN             *int       `json:"asn,omitempty"`
+	ASOrg           *string    `json:"as_org,omitempty"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // CreateProxyRequest represents a request to create a proxy
 type CreateProxyRequest struct {
-	Address  string  `json:"address" validate:"required"`
-	Protocol string  `json:"protocol" validate:"required,oneof=http https socks4 socks4a socks5"`
-	Username *string `json:"username,omitempty"`
-	Password *string `json:"password,omitempty"`
+	Address       string  `json:"address" validate:"required"`
+	Protocol      string  `json:"protocol" validate:"required,oneof=http https socks4 socks4a socks5"`
+	Username      *string `json:"username,omitempty"`
+	Password      *string `json:"password,omitempty"`
+	Tags          Tags    `json:"tags,omitempty"`
+	TLSServerName *string `json:"tls_server_name,omitempty"`
+	CABundle      *string `json:"ca_bundle,omitempty"`
 }
 
 // UpdateProxyRequest represents a request to update a proxy
 type UpdateProxyRequest struct {
-	Address  string  `json:"address"`
-	Protocol string  `json:"protocol" validate:"omitempty,oneof=http https socks4 socks4a socks5"`
-	Username *string `json:"username,omitempty"`
-	Password *string `json:"password,omitempty"`
+	Address       string  `json:"address"`
+	Protocol      string  `json:"protocol" validate:"omitempty,oneof=http https socks4 socks4a socks5"`
+	Username      *string `json:"username,omitempty"`
+	Password      *string `json:"password,omitempty"`
+	Tags          Tags    `json:"tags,omitempty"`            // Replaces all tags when set
+	TLSServerName *string `json:"tls_server_name,omitempty"` // Unchanged when omitted, cleared when empty
+	CABundle      *string `json:"ca_bundle,omitempty"`       // Unchanged when omitted, cleared when empty
 }
 
 // BulkCreateProxyRequest represents a request to create multiple proxies
@@ -61,6 +84,14 @@ type BulkDeleteProxyRequest struct {
 	IDs []int `json:"ids" validate:"required,min=1"`
 }
 
+// BulkTagRequest represents a request to edit tags on multiple proxies
+// Tags in Set are added or overwritten, keys in Remove are deleted
+type BulkTagRequest struct {
+	IDs    []int    `json:"ids" validate:"required,min=1"`
+	Set    Tags     `json:"set,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
 // BulkTestRequest represents a request to test multiple proxies
 type BulkTestRequest struct {
 	IDs []int `json:"ids" validate:"required,min=1"`
@@ -79,6 +110,8 @@ type ProxyTestResult struct {
 	Status       string    `json:"status"`
 	ResponseTime *int      `json:"response_time,omitempty"`
 	Error        *string   `json:"error,omitempty"`
+	ExitIP       *string   `json:"exit_ip,omitempty"`
+	Country      *string   `json:"country,omitempty"`
 	TestedAt     time.Time `json:"tested_at"`
 }
 
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// ProxyRequest represents a request recorded in proxy_requests
+type ProxyRequest struct {
+	ID            int64     `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	ProxyID       int       `json:"proxy_id"`
+	ClientID      *int      `json:"client_id,omitempty"`
+	Method        string    `json:"method"`
+	URL           *string   `json:"url,omitempty"`
+	StatusCode    *int      `json:"status_code,omitempty"`
+	ResponseTime  int       `json:"response_time"` // in milliseconds
+	Success       bool      `json:"success"`
+	Error         *string   `json:"error,omitempty"`
+	BytesSent     int64     `json:"bytes_sent"`
+	BytesReceived int64     `json:"bytes_received"`
+	Duration      *int      `json:"duration,omitempty"`     // CONNECT tunnel lifetime in milliseconds
+	CloseReason   *string   `json:"close_reason,omitempty"` // why a CONNECT tunnel ended
+}
+
+// ProxyRequestCursor marks the last request of a page; the next page starts after it
+type ProxyRequestCursor struct {
+	Timestamp time.Time
+	ID        int64
+}
+
+// ProxyRequestFilter represents filters for listing a proxy's requests
+type ProxyRequestFilter struct {
+	Success   *bool
+	StatusMin int // inclusive, 0 means no lower bound
+	StatusMax int // inclusive, 0 means no upper bound
+	StartTime *time.Time
+	EndTime   *time.Time
+	After     *ProxyRequestCursor
+}
+
+// ProxyRequestListResponse represents a page of a proxy's requests
+type ProxyRequestListResponse struct {
+	Requests   []ProxyRequest `json:"requests"`              // newest first
+	NextCursor string         `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// ProxyRequestBucket represents a proxy's requests within one time bucket
+type ProxyRequestBucket struct {
+	Time            time.Time `json:"time"`
+	Requests        int64     `json:"requests"`
+	Successful      int64     `json:"successful"`
+	Failed          int64     `json:"failed"`
+	SuccessRate     float64   `json:"success_rate"`      // 0-100
+	AvgResponseTime int       `json:"avg_response_time"` // of successful requests, in milliseconds
+}
+
+// ProxyErrorCount represents how often an error message was recorded
+type ProxyErrorCount struct {
+	Error string `json:"error"`
+	Count int64  `json:"count"`
+}
+
+// ProxyRequestStats represents a proxy's request statistics over a time window
+type ProxyRequestStats struct {
+	ProxyID     int                  `json:"proxy_id"`
+	Window      string               `json:"window"`
+	Bucket      string               `json:"bucket"`
+	Requests    int64                `json:"requests"`
+	Successful  int64                `json:"successful"`
+	Failed      int64                `json:"failed"`
+	SuccessRate *float64             `json:"success_rate"` // null without requests
+	Latency     *LatencyPercentiles  `json:"latency"`      // of successful requests, null without any
+	Buckets     []ProxyRequestBucket `json:"buckets"`      // oldest first, empty buckets omitted
+	TopErrors   []ProxyErrorCount    `json:"top_errors"`   // most frequent first
+}
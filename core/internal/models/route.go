@@ -0,0 +1,20 @@
+package models
+
+// ProxyRoute exposes an upstream API as a rotating gateway on the proxy port
+// Direct requests under PathPrefix are forwarded to Target through the proxy pool,
+// e.g. "/hyperliquid/info" to "https://api.hyperliquid.xyz/info".
+type ProxyRoute struct {
+	Name         string            `json:"name"`
+	PathPrefix   string            `json:"path_prefix"`    // e.g. "/hyperliquid", stripped before forwarding
+	Target       string            `json:"target"`         // Base URL the rest of the path is appended to
+	AuthRequired bool              `json:"auth_required"`  // Require proxy credentials even when proxy authentication is disabled
+	RateLimit    RateLimitSettings `json:"rate_limit"`     // Per-IP limit for this route, on top of the global one
+	Pool         string            `json:"pool,omitempty"` // Named proxy pool to use
+	Tags         Tags              `json:"tags,omitempty"` // Or: use proxies carrying all of these tags
+}
+
+// RouteSettings is the route table stored under the "routes" settings key
+// It is managed through the /routes endpoints rather than PUT /settings.
+type RouteSettings struct {
+	Routes []ProxyRoute `json:"routes"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// StickySession represents an active sticky session pinned to an upstream proxy
+type StickySession struct {
+	Username     string    `json:"username"`
+	SessionID    string    `json:"session_id"`
+	ProxyID      int       `json:"proxy_id"`
+	ProxyAddress string    `json:"proxy_address"`
+	Requests     int64     `json:"requests"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastUsed     time.Time `json:"last_used"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
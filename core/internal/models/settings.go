@@ -9,31 +9,38 @@ type Settings struct {
 	RateLimit      RateLimitSettings      `json:"rate_limit"`
 	HealthCheck    HealthCheckSettings    `json:"healthcheck"`
 	LogRetention   LogRetentionSettings   `json:"log_retention"`
+	Webshare       WebshareSettings       `json:"webshare"`
 }
 
 // AuthenticationSettings represents proxy server authentication configuration
 // This controls authentication for incoming requests to the PROXY server (port 8000)
-// NOT for dashboard/API login (which uses ROTA_ADMIN_USER/ROTA_ADMIN_PASSWORD)
+// NOT for dashboard/API login (which uses the users table)
+// When enabled, per-client credentials from proxy_clients are accepted as well
 type AuthenticationSettings struct {
 	Enabled  bool   `json:"enabled"`  // Enable authentication for proxy requests
-	Username string `json:"username"` // Username for proxy authentication
+	Username string `json:"username"` // Shared username for proxy authentication (empty = clients only)
 	Password string `json:"password"` // Password for proxy authentication (write-only)
 }
 
 // RotationSettings represents proxy rotation configuration
 type RotationSettings struct {
-	Method             string              `json:"method"`
-	TimeBased          TimeBasedSettings   `json:"time_based,omitempty"`
-	RateLimited        RateLimitedSettings `json:"rate_limited,omitempty"`
-	RemoveUnhealthy    bool                `json:"remove_unhealthy"`
-	Fallback           bool                `json:"fallback"`
-	FallbackMaxRetries int                 `json:"fallback_max_retries"`
-	FollowRedirect     bool                `json:"follow_redirect"`
-	Timeout            int                 `json:"timeout"`
-	Retries            int                 `json:"retries"`
-	AllowedProtocols   []string            `json:"allowed_protocols"` // ["http", "https", "socks4", "socks4a", "socks5"], empty means all
-	MaxResponseTime    int                 `json:"max_response_time"` // in milliseconds, 0 means no limit
-	MinSuccessRate     float64             `json:"min_success_rate"`  // 0-100, 0 means no minimum
+	Method             string                 `json:"method"`
+	TimeBased          TimeBasedSettings      `json:"time_based,omitempty"`
+	RateLimited        RateLimitedSettings    `json:"rate_limited,omitempty"`
+	RemoveUnhealthy    bool                   `json:"remove_unhealthy"`
+	Fallback           bool                   `json:"fallback"`
+	FallbackMaxRetries int                    `json:"fallback_max_retries"`
+	FollowRedirect     bool                   `json:"follow_redirect"`
+	Timeout            int                    `json:"timeout"`
+	Retries            int                    `json:"retries"`
+	RetryBodyLimit     int64                  `json:"retry_body_limit"`     // request bodies up to this many bytes are buffered for retries, 0 never retries requests with a body
+	RetryNonIdempotent bool                   `json:"retry_non_idempotent"` // also retry POST and PATCH requests that were sent, not only idempotent methods
+	AllowedProtocols   []string               `json:"allowed_protocols"`    // ["http", "https", "socks4", "socks4a", "socks5"], empty means all
+	MaxResponseTime    int                    `json:"max_response_time"`    // in milliseconds, 0 means no limit
+	MinSuccessRate     float64                `json:"min_success_rate"`     // 0-100, 0 means no minimum
+	StickySessionTTL   int                    `json:"sticky_session_ttl"`   // idle seconds before a "-session-<id>" pin expires, 0 disables
+	CircuitBreaker     CircuitBreakerSettings `json:"circuit_breaker"`
+	Blocked            BlockedSettings        `json:"blocked"`
 }
 
 // TimeBasedSettings represents time-based rotation settings
@@ -45,6 +52,27 @@ type TimeBasedSettings struct {
 type RateLimitedSettings struct {
 	MaxRequestsPerMinute int `json:"max_requests_per_minute"` // Maximum requests per proxy per time window (default: 30)
 	WindowSeconds        int `json:"window_seconds"`          // Time window in seconds (default: 60)
+	MaxWaitSeconds       int `json:"max_wait_seconds"`        // How long a request waits for a proxy under the limit (0 fails immediately)
+}
+
+// CircuitBreakerSettings represents per-proxy circuit breaker settings
+type CircuitBreakerSettings struct {
+	FailureThreshold int `json:"failure_threshold"`  // Consecutive failures that open the circuit (0 disables the breaker)
+	OpenSeconds      int `json:"open_seconds"`       // First open duration, doubled on each consecutive opening
+	MaxOpenSeconds   int `json:"max_open_seconds"`   // Upper bound for the open duration
+	HalfOpenRequests int `json:"half_open_requests"` // Live requests let through while half-open; all must succeed to close
+}
+
+// BlockedSettings represents target responses that count as the proxy being blocked
+// A blocked response is retried through another proxy, which is then skipped for
+// that target host until its Retry-After or CooldownSeconds passes.
+type BlockedSettings struct {
+	StatusCodes     []int    `json:"status_codes"`     // e.g. [429, 503]
+	BodyPatterns    []string `json:"body_patterns"`    // regular expressions matched against the start of the response body
+	MaxRetries      int      `json:"max_retries"`      // other proxies tried after blocked responses (0 returns the first one)
+	BackoffMs       int      `json:"backoff_ms"`       // wait before the next proxy, doubled on each retry
+	MaxBackoffMs    int      `json:"max_backoff_ms"`   // upper bound for the wait, including Retry-After
+	CooldownSeconds int      `json:"cooldown_seconds"` // how long a proxy is skipped for the target without Retry-After
 }
 
 // RateLimitSettings represents rate limiting configuration
@@ -61,7 +89,9 @@ type HealthCheckSettings struct {
 	URL                      string   `json:"url"`
 	Status                   int      `json:"status"`
 	Headers                  []string `json:"headers"`
+	IntervalMinutes          int      `json:"interval_minutes"` // Scheduled check interval (0 disables scheduled checks)
 	RetestFailedAfterMinutes int      `json:"retest_failed_after_minutes"`
+	ExitIPURL                string   `json:"exit_ip_url"` // Echo endpoint returning the caller's IP (empty disables exit IP discovery)
 }
 
 // LogRetentionSettings represents log retention and cleanup configuration
@@ -72,6 +102,18 @@ type LogRetentionSettings struct {
 	CleanupIntervalHours int  `json:"cleanup_interval_hours"` // How often to run cleanup (1, 6, 12, 24)
 }
 
+// WebshareSettings represents Webshare auto-sync configuration
+type WebshareSettings struct {
+	SyncIntervalSeconds int `json:"sync_interval_seconds"` // Auto-sync interval (0 uses WEBSHARE_SYNC_INTERVAL_SECONDS)
+}
+
+// SettingsReloadStatus reports whether a subsystem applied updated settings
+type SettingsReloadStatus struct {
+	Subsystem string `json:"subsystem"`
+	Applied   bool   `json:"applied"`
+	Error     string `json:"error,omitempty"`
+}
+
 // SettingRecord represents a settings database record
 type SettingRecord struct {
 	Key       string         `json:"key"`
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TransportStats represents the connection pool kept for an upstream proxy
+type TransportStats struct {
+	ProxyID         int       `json:"proxy_id"`
+	ProxyAddress    string    `json:"proxy_address"`
+	OpenConnections int64     `json:"open_connections"` // connections to the proxy, in use or idle
+	Dials           int64     `json:"dials"`            // connections opened since the pool was created
+	Requests        int64     `json:"requests"`         // request attempts sent through the pool
+	CreatedAt       time.Time `json:"created_at"`
+	LastUsed        time.Time `json:"last_used"`
+}
@@ -1,5 +1,49 @@
 package models
 
+import "time"
+
+// UserRole represents a dashboard user role
+type UserRole string
+
+const (
+	UserRoleAdmin    UserRole = "admin"
+	UserRoleOperator UserRole = "operator"
+	UserRoleViewer   UserRole = "viewer"
+)
+
+// IsValid reports whether the role is one of the known roles
+func (r UserRole) IsValid() bool {
+	switch r {
+	case UserRoleAdmin, UserRoleOperator, UserRoleViewer:
+		return true
+	}
+	return false
+}
+
+// User represents a dashboard/API user account
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"` // Never expose password hash in JSON
+	Role         UserRole  `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateUserRequest represents a request to create a user
+type CreateUserRequest struct {
+	Username string   `json:"username" validate:"required"`
+	Password string   `json:"password" validate:"required,min=8"`
+	Role     UserRole `json:"role" validate:"required,oneof=admin operator viewer"`
+}
+
+// UpdateUserRequest represents a request to update a user
+// Empty fields are left unchanged
+type UpdateUserRequest struct {
+	Password string   `json:"password,omitempty" validate:"omitempty,min=8"`
+	Role     UserRole `json:"role,omitempty" validate:"omitempty,oneof=admin operator viewer"`
+}
+
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
@@ -14,5 +58,6 @@ type LoginResponse struct {
 
 // UserInfoResponse represents user information in responses
 type UserInfoResponse struct {
-	Username string `json:"username"`
+	Username string   `json:"username"`
+	Role     UserRole `json:"role"`
 }
@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+const (
+	// blockedBodyPeek is how much of a response body is matched against blocked.body_patterns
+	blockedBodyPeek = 64 << 10
+
+	// maxBlockDuration bounds how long a Retry-After keeps a proxy away from a host
+	maxBlockDuration = time.Hour
+)
+
+// blockedError is returned with the last blocked response once the retries
+// for blocked responses are used up; the response is passed to the client
+type blockedError struct {
+	reason string
+}
+
+// Error returns the reason the response counts as blocked
+func (e *blockedError) Error() string {
+	return "blocked by target: " + e.reason
+}
+
+// blockedMatcher detects target responses that count as the proxy being blocked
+type blockedMatcher struct {
+	statusCodes map[int]bool
+	patterns    []*regexp.Regexp
+}
+
+// newBlockedMatcher compiles the blocked response settings
+// Invalid patterns are skipped; settings are validated when saved.
+func newBlockedMatcher(settings models.BlockedSettings) *blockedMatcher {
+	m := &blockedMatcher{statusCodes: make(map[int]bool, len(settings.StatusCodes))}
+	for _, code := range settings.StatusCodes {
+		m.statusCodes[code] = true
+	}
+	for _, pattern := range settings.BodyPatterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			m.patterns = append(m.patterns, re)
+		}
+	}
+	return m
+}
+
+// match reports why the response counts as blocked, or "" if it doesn't
+// The start of the body is read to match body patterns and put back in front of
+// the rest, so the response can still be passed on. Compressed bodies are
+// matched as received.
+func (m *blockedMatcher) match(resp *http.Response) string {
+	if m.statusCodes[resp.StatusCode] {
+		return fmt.Sprintf("status %d", resp.StatusCode)
+	}
+	if len(m.patterns) == 0 || resp.Body == nil {
+		return ""
+	}
+
+	prefix, _ := io.ReadAll(io.LimitReader(resp.Body, blockedBodyPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+
+	for _, re := range m.patterns {
+		if re.Match(prefix) {
+			return fmt.Sprintf("body matches %q", re.String())
+		}
+	}
+	return ""
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// blockedBackoff returns the wait before the next proxy after the given
+// number of blocked responses, honoring the last response's Retry-After
+func blockedBackoff(settings models.BlockedSettings, blocked int, after time.Duration) time.Duration {
+	wait := time.Duration(settings.BackoffMs) * time.Millisecond
+	for i := 1; i < blocked && wait < time.Duration(settings.MaxBackoffMs)*time.Millisecond; i++ {
+		wait *= 2
+	}
+	wait = max(wait, after)
+	if limit := time.Duration(settings.MaxBackoffMs) * time.Millisecond; wait > limit {
+		wait = limit
+	}
+	return wait
+}
+
+// blockKey identifies a proxy blocked by a target host
+type blockKey struct {
+	proxyID int
+	host    string
+}
+
+// BlockList remembers which proxies a target host has blocked, so they are
+// skipped for that host until the block expires
+type BlockList struct {
+	mu     sync.Mutex
+	blocks map[blockKey]time.Time // when the block expires
+	now    func() time.Time
+}
+
+// NewBlockList creates an empty block list
+func NewBlockList() *BlockList {
+	return &BlockList{
+		blocks: make(map[blockKey]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Block skips the proxy for the host for d, capped at maxBlockDuration
+func (l *BlockList) Block(proxyID int, host string, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	if d > maxBlockDuration {
+		d = maxBlockDuration
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := l.now().Add(d)
+	key := blockKey{proxyID: proxyID, host: host}
+	if until.After(l.blocks[key]) {
+		l.blocks[key] = until
+	}
+}
+
+// Blocked reports whether the proxy is blocked by the host
+func (l *BlockList) Blocked(proxyID int, host string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until, ok := l.blocks[blockKey{proxyID: proxyID, host: host}]
+	return ok && l.now().Before(until)
+}
+
+// Soonest returns the proxy whose block by the host ends first, nil if proxies is empty
+func (l *BlockList) Soonest(proxies []*models.Proxy, host string) *models.Proxy {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var soonest *models.Proxy
+	var soonestUntil time.Time
+	for _, p := range proxies {
+		until := l.blocks[blockKey{proxyID: p.ID, host: host}]
+		if soonest == nil || until.Before(soonestUntil) {
+			soonest, soonestUntil = p, until
+		}
+	}
+	return soonest
+}
+
+// Cleanup drops expired blocks
+func (l *BlockList) Cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, until := range l.blocks {
+		if !now.Before(until) {
+			delete(l.blocks, key)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// TestBlockedMatcher tests status and body matching and that the body is kept intact
+func TestBlockedMatcher(t *testing.T) {
+	m := newBlockedMatcher(models.BlockedSettings{
+		StatusCodes:  []int{429},
+		BodyPatterns: []string{`(?i)captcha`},
+	})
+
+	tests := []struct {
+		status  int
+		body    string
+		blocked bool
+	}{
+		{429, "slow down", true},
+		{200, "<html>Please solve the CAPTCHA</html>", true},
+		{200, "<html>hello</html>", false},
+		{403, "forbidden", false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}
+		if reason := m.match(resp); (reason != "") != tt.blocked {
+			t.Errorf("%d %q: reason = %q, want blocked %v", tt.status, tt.body, reason, tt.blocked)
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+			t.Errorf("%d %q: body after match = %q", tt.status, tt.body, body)
+		}
+	}
+}
+
+// TestRetryAfter tests parsing Retry-After in seconds and as an HTTP date
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestBlockedBackoff tests doubling, Retry-After and the upper bound
+func TestBlockedBackoff(t *testing.T) {
+	settings := models.BlockedSettings{BackoffMs: 500, MaxBackoffMs: 3000}
+
+	tests := []struct {
+		blocked int
+		after   time.Duration
+		want    time.Duration
+	}{
+		{1, 0, 500 * time.Millisecond},
+		{2, 0, time.Second},
+		{4, 0, 3 * time.Second},
+		{1, 2 * time.Second, 2 * time.Second},
+		{1, time.Minute, 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := blockedBackoff(settings, tt.blocked, tt.after); got != tt.want {
+			t.Errorf("blockedBackoff(%d, %v) = %v, want %v", tt.blocked, tt.after, got, tt.want)
+		}
+	}
+}
+
+// TestBlockList tests that blocks apply per proxy and host and expire
+func TestBlockList(t *testing.T) {
+	now := time.Now()
+	l := NewBlockList()
+	l.now = func() time.Time { return now }
+
+	l.Block(1, "example.com", time.Minute)
+	if !l.Blocked(1, "example.com") {
+		t.Error("proxy 1 not blocked for example.com")
+	}
+	if l.Blocked(1, "example.org") || l.Blocked(2, "example.com") {
+		t.Error("block applied to another host or proxy")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if l.Blocked(1, "example.com") {
+		t.Error("block didn't expire")
+	}
+	l.Cleanup()
+	if len(l.blocks) != 0 {
+		t.Errorf("Cleanup left %d expired blocks", len(l.blocks))
+	}
+}
+
+// TestSendWithRetryBlocked tests that the client gets the target's blocked
+// response when no other proxy is left, and that a proxy blocked by the host is
+// still used when every proxy is
+func TestSendWithRetryBlocked(t *testing.T) {
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, "slow down")
+	}))
+	defer upstream.Close()
+
+	p := &models.Proxy{ID: 1, Address: strings.TrimPrefix(upstream.URL, "http://"), Protocol: "http", Status: "active"}
+	settings := &models.RotationSettings{Retries: 1, Timeout: 5, Blocked: models.BlockedSettings{
+		StatusCodes:     []int{429},
+		MaxRetries:      2,
+		BackoffMs:       1,
+		MaxBackoffMs:    1,
+		CooldownSeconds: 60,
+	}}
+	h := NewUpstreamProxyHandler(newTestSelector(settings, p), nil, NewSessionManager(0), NewCircuitBreaker(models.CircuitBreakerSettings{}),
+		NewBlockList(), NewConnectionTracker(), NewTransportCache(), nil, settings, logger.New("error"))
+
+	for i := 1; i <= 2; i++ {
+		req := httptest.NewRequest("GET", "http://example.com/", nil)
+		resp, proxyID, err := h.sendWithRetry(req, context.Background())
+		var blockedErr *blockedError
+		if !errors.As(err, &blockedErr) {
+			t.Fatalf("request %d: error = %v, want a blocked error", i, err)
+		}
+		if resp == nil || resp.StatusCode != http.StatusTooManyRequests || proxyID != 1 {
+			t.Fatalf("request %d: got response %v from proxy %d, want the 429 from proxy 1", i, resp, proxyID)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "slow down" {
+			t.Errorf("request %d: body = %q", i, body)
+		}
+		if got := requests.Load(); got != int32(i) {
+			t.Errorf("upstream received %d requests after request %d, want %d", got, i, i)
+		}
+	}
+}
+
+// TestBlockListSoonest tests picking the proxy whose block ends first
+func TestBlockListSoonest(t *testing.T) {
+	l := NewBlockList()
+	l.Block(1, "example.com", time.Hour)
+	l.Block(2, "example.com", time.Minute)
+	l.Block(3, "example.com", 30*time.Minute)
+
+	proxies := []*models.Proxy{{ID: 1}, {ID: 2}, {ID: 3}}
+	if p := l.Soonest(proxies, "example.com"); p == nil || p.ID != 2 {
+		t.Errorf("Soonest = %v, want proxy 2", p)
+	}
+	if p := l.Soonest(nil, "example.com"); p != nil {
+		t.Errorf("Soonest of no proxies = %v, want nil", p)
+	}
+}
@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// idempotentMethods are safe to send more than once (RFC 9110 section 9.2.2)
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// isIdempotent reports whether the request may be sent more than once
+// Like net/http, requests carrying an idempotency key count as idempotent.
+func isIdempotent(req *http.Request) bool {
+	if idempotentMethods[req.Method] {
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// bufferBody makes the request body replayable by setting GetBody
+// Bodies without GetBody are buffered up to limit bytes. Larger bodies, and
+// bodies of unknown length exceeding the limit, are left streaming and
+// reported as not replayable.
+func bufferBody(req *http.Request, limit int64) (bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return true, nil
+	}
+	if req.GetBody != nil {
+		return true, nil
+	}
+	if req.ContentLength > limit {
+		return false, nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil {
+		return false, err
+	}
+
+	if int64(len(buf)) > limit {
+		// Put the bytes read back in front of the rest of the body
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+		return false, nil
+	}
+
+	req.Body.Close()
+	req.ContentLength = int64(len(buf))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(buf)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return true, nil
+}
+
+// retryPolicy decides whether a failed attempt may be sent again, through the
+// same proxy or another one
+type retryPolicy struct {
+	replayable bool // the body can be resent
+	idempotent bool // the method may be sent more than once
+}
+
+// newRetryPolicy prepares the request body for retries and returns its policy
+func newRetryPolicy(req *http.Request, settings *models.RotationSettings) (retryPolicy, error) {
+	replayable, err := bufferBody(req, settings.RetryBodyLimit)
+	if err != nil {
+		return retryPolicy{}, err
+	}
+
+	return retryPolicy{
+		replayable: replayable,
+		idempotent: settings.RetryNonIdempotent || isIdempotent(req),
+	}, nil
+}
+
+// allows reports whether an attempt that failed with err may be sent again
+// Requests that failed before reaching the proxy are retried whatever the
+// method, as long as the body can be resent.
+func (p retryPolicy) allows(err error) bool {
+	if !p.replayable {
+		return false
+	}
+	return p.idempotent || notSent(err)
+}
+
+// notSent reports whether the request failed before it was written to the proxy
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect")
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// TestBufferBody tests which bodies are buffered and that unbuffered ones are left intact
+func TestBufferBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		length     int64 // -1 for unknown length
+		replayable bool
+	}{
+		{"empty", "", 0, true},
+		{"small", "payload", 7, true},
+		{"small unknown length", "payload", -1, true},
+		{"large", strings.Repeat("x", 20), 20, false},
+		{"large unknown length", strings.Repeat("x", 20), -1, false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "http://example.com/", strings.NewReader(tt.body))
+		req.ContentLength = tt.length
+
+		replayable, err := bufferBody(req, 10)
+		if err != nil {
+			t.Fatalf("%s: bufferBody: %v", tt.name, err)
+		}
+		if replayable != tt.replayable {
+			t.Errorf("%s: replayable = %v, want %v", tt.name, replayable, tt.replayable)
+		}
+
+		body, _ := io.ReadAll(req.Body)
+		if string(body) != tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.name, body, tt.body)
+		}
+		if replayable && req.GetBody != nil {
+			again, _ := req.GetBody()
+			if body, _ := io.ReadAll(again); string(body) != tt.body {
+				t.Errorf("%s: replayed body = %q, want %q", tt.name, body, tt.body)
+			}
+		}
+	}
+}
+
+// TestIsIdempotent tests the methods and headers that allow resending a request
+func TestIsIdempotent(t *testing.T) {
+	for method, want := range map[string]bool{"GET": true, "PUT": true, "DELETE": true, "POST": false, "PATCH": false} {
+		if got := isIdempotent(httptest.NewRequest(method, "http://example.com/", nil)); got != want {
+			t.Errorf("isIdempotent(%s) = %v, want %v", method, got, want)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "http://example.com/", nil)
+	req.Header.Set("Idempotency-Key", "abc")
+	if !isIdempotent(req) {
+		t.Error("POST with an Idempotency-Key is not idempotent")
+	}
+}
+
+// TestTryProxyWithRetriesBody tests that retries resend the full body and that
+// requests the proxy may have forwarded are only resent if idempotent
+func TestTryProxyWithRetriesBody(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		first := len(bodies)%2 == 1
+		mu.Unlock()
+
+		// Drop the connection on every other request
+		if first {
+			conn, _, _ := http.NewResponseController(w).Hijack()
+			conn.Close()
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	p := &models.Proxy{ID: 1, Address: strings.TrimPrefix(srv.URL, "http://"), Protocol: "http"}
+	settings := &models.RotationSettings{Retries: 2, Timeout: 5, RetryBodyLimit: 1024}
+	h := NewUpstreamProxyHandler(newTestSelector(settings), nil, NewSessionManager(0), NewCircuitBreaker(models.CircuitBreakerSettings{}),
+		NewBlockList(), NewConnectionTracker(), NewTransportCache(), nil, settings, logger.New("error"))
+
+	tests := []struct {
+		method  string
+		success bool
+	}{
+		{"PUT", true},
+		{"POST", false},
+	}
+
+	for _, tt := range tests {
+		mu.Lock()
+		bodies = nil
+		mu.Unlock()
+
+		req := httptest.NewRequest(tt.method, "http://example.com/", strings.NewReader("payload"))
+		policy, err := newRetryPolicy(req, settings)
+		if err != nil {
+			t.Fatalf("newRetryPolicy: %v", err)
+		}
+
+		resp, err := h.tryProxyWithRetries(req, context.Background(), settings, policy, p, settings.Retries)
+		if (err == nil) != tt.success {
+			t.Errorf("%s: error = %v, want success %v", tt.method, err, tt.success)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		mu.Lock()
+		for _, body := range bodies {
+			if body != "payload" {
+				t.Errorf("%s: upstream received body %q, want payload", tt.method, body)
+			}
+		}
+		if want := map[bool]int{true: 2, false: 1}[tt.success]; len(bodies) != want {
+			t.Errorf("%s: %d attempts, want %d", tt.method, len(bodies), want)
+		}
+		mu.Unlock()
+	}
+}
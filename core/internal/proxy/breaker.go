@@ -0,0 +1,221 @@
+package proxy
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// circuit is the breaker state of one upstream proxy
+type circuit struct {
+	state     string
+	failures  int // consecutive failures while closed
+	trips     int // consecutive openings, grows the open duration
+	openedAt  time.Time
+	openUntil time.Time
+	probes    int // half-open requests in flight
+	successes int // half-open requests that succeeded
+}
+
+// CircuitBreaker tracks upstream proxy failures in memory and stops selecting a
+// proxy once it fails repeatedly
+//
+// A closed circuit opens after FailureThreshold consecutive failures and rejects
+// the proxy for OpenSeconds, doubling on every consecutive opening up to
+// MaxOpenSeconds. Once that elapses the circuit is half-open: up to
+// HalfOpenRequests live requests are let through, all of them must succeed to
+// close it again, and any failure re-opens it.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	settings models.CircuitBreakerSettings
+	circuits map[int]*circuit // keyed by proxy ID, only proxies with failures
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a new circuit breaker; a FailureThreshold <= 0 disables it
+func NewCircuitBreaker(settings models.CircuitBreakerSettings) *CircuitBreaker {
+	return &CircuitBreaker{
+		settings: settings,
+		circuits: make(map[int]*circuit),
+		now:      time.Now,
+	}
+}
+
+// UpdateSettings applies new breaker settings; disabling the breaker closes all circuits
+func (b *CircuitBreaker) UpdateSettings(settings models.CircuitBreakerSettings) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.settings = settings
+	if settings.FailureThreshold <= 0 {
+		b.circuits = make(map[int]*circuit)
+	}
+}
+
+// Allow reports whether a request may be sent through the proxy
+// Every allowed request must be followed by Success, Failure or Release.
+func (b *CircuitBreaker) Allow(proxyID int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[proxyID]
+	if !ok || b.settings.FailureThreshold <= 0 {
+		return true
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		if b.now().Before(c.openUntil) {
+			return false
+		}
+		c.state = CircuitHalfOpen
+		c.probes = 0
+		c.successes = 0
+		fallthrough
+	case CircuitHalfOpen:
+		if c.probes+c.successes >= b.halfOpenRequests() {
+			return false
+		}
+		c.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a successful request through the proxy
+func (b *CircuitBreaker) Success(proxyID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[proxyID]
+	if !ok {
+		return
+	}
+
+	if c.state != CircuitHalfOpen {
+		// A success resets the consecutive failure count of a closed circuit
+		if c.state == CircuitClosed {
+			delete(b.circuits, proxyID)
+		}
+		return
+	}
+
+	c.probes = max(c.probes-1, 0)
+	c.successes++
+	if c.successes >= b.halfOpenRequests() {
+		delete(b.circuits, proxyID)
+	}
+}
+
+// Failure records a failed request through the proxy and reports whether it opened the circuit
+func (b *CircuitBreaker) Failure(proxyID int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.settings.FailureThreshold <= 0 {
+		return false
+	}
+
+	c, ok := b.circuits[proxyID]
+	if !ok {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[proxyID] = c
+	}
+
+	switch c.state {
+	case CircuitClosed:
+		c.failures++
+		if c.failures < b.settings.FailureThreshold {
+			return false
+		}
+	case CircuitHalfOpen:
+		c.probes = max(c.probes-1, 0)
+	default:
+		// Already open, e.g. a request that started before the circuit opened
+		return false
+	}
+
+	b.open(c)
+	return true
+}
+
+// Release frees a half-open slot taken by Allow when the request ended without
+// telling anything about the proxy, e.g. because the client went away
+func (b *CircuitBreaker) Release(proxyID int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[proxyID]; ok && c.state == CircuitHalfOpen {
+		c.probes = max(c.probes-1, 0)
+	}
+}
+
+// List returns the circuits that are not closed or have recent failures, open ones first
+func (b *CircuitBreaker) List() []models.CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make([]models.CircuitState, 0, len(b.circuits))
+	for proxyID, c := range b.circuits {
+		state := models.CircuitState{
+			ProxyID:  proxyID,
+			State:    c.state,
+			Failures: c.failures,
+			Trips:    c.trips,
+		}
+		if c.state != CircuitClosed {
+			openedAt, openUntil := c.openedAt, c.openUntil
+			state.OpenedAt = &openedAt
+			state.OpenUntil = &openUntil
+		}
+		states = append(states, state)
+	}
+
+	order := map[string]int{CircuitOpen: 0, CircuitHalfOpen: 1, CircuitClosed: 2}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].State != states[j].State {
+			return order[states[i].State] < order[states[j].State]
+		}
+		return states[i].ProxyID < states[j].ProxyID
+	})
+
+	return states
+}
+
+// open opens the circuit for the base open duration, doubled per consecutive opening
+func (b *CircuitBreaker) open(c *circuit) {
+	base := time.Duration(max(b.settings.OpenSeconds, 1)) * time.Second
+	limit := time.Duration(max(b.settings.MaxOpenSeconds, b.settings.OpenSeconds, 1)) * time.Second
+
+	duration := base
+	for i := 0; i < c.trips && duration < limit; i++ {
+		duration *= 2
+	}
+	if duration > limit {
+		duration = limit
+	}
+
+	now := b.now()
+	c.state = CircuitOpen
+	c.trips++
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+	c.openedAt = now
+	c.openUntil = now.Add(duration)
+}
+
+// halfOpenRequests returns how many requests a half-open circuit lets through
+func (b *CircuitBreaker) halfOpenRequests() int {
+	return max(b.settings.HalfOpenRequests, 1)
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// newTestBreaker returns a breaker driven by a manual clock
+func newTestBreaker(settings models.CircuitBreakerSettings) (*CircuitBreaker, *time.Time) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(settings)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+// TestCircuitBreakerOpensAfterThreshold tests that consecutive failures open the circuit
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(models.CircuitBreakerSettings{FailureThreshold: 3, OpenSeconds: 30, MaxOpenSeconds: 600, HalfOpenRequests: 1})
+
+	b.Failure(1)
+	b.Failure(1)
+	b.Success(1) // resets the count
+	b.Failure(1)
+	b.Failure(1)
+	if !b.Allow(1) {
+		t.Fatal("circuit opened before reaching the threshold")
+	}
+
+	if !b.Failure(1) {
+		t.Fatal("Failure did not report opening the circuit")
+	}
+	if b.Allow(1) {
+		t.Error("open circuit allowed a request")
+	}
+	if !b.Allow(2) {
+		t.Error("unrelated proxy was rejected")
+	}
+}
+
+// TestCircuitBreakerHalfOpen tests recovery and re-opening with a growing open duration
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	b, now := newTestBreaker(models.CircuitBreakerSettings{FailureThreshold: 1, OpenSeconds: 30, MaxOpenSeconds: 100, HalfOpenRequests: 2})
+
+	b.Failure(1)
+	*now = now.Add(30 * time.Second)
+
+	// Half-open lets two probes through, then rejects until they report
+	if !b.Allow(1) || !b.Allow(1) {
+		t.Fatal("half-open circuit rejected a probe")
+	}
+	if b.Allow(1) {
+		t.Fatal("half-open circuit allowed more probes than configured")
+	}
+
+	// A failing probe re-opens the circuit for twice as long
+	b.Success(1)
+	b.Failure(1)
+	*now = now.Add(59 * time.Second)
+	if b.Allow(1) {
+		t.Fatal("circuit half-opened before the doubled open duration")
+	}
+
+	// The open duration is capped at max_open_seconds
+	*now = now.Add(time.Second)
+	b.Allow(1)
+	b.Failure(1)
+	states := b.List()
+	if len(states) != 1 || states[0].State != CircuitOpen || states[0].OpenUntil.Sub(*now) != 100*time.Second {
+		t.Fatalf("List() = %+v, want one circuit open for 100s", states)
+	}
+
+	// All probes must succeed to close the circuit; a released probe is retried
+	*now = now.Add(100 * time.Second)
+	b.Allow(1)
+	b.Release(1)
+	if !b.Allow(1) || !b.Allow(1) {
+		t.Fatal("released probe slot was not freed")
+	}
+	b.Success(1)
+	b.Success(1)
+	if len(b.List()) != 0 {
+		t.Errorf("List() = %+v, want closed circuit removed", b.List())
+	}
+}
+
+// TestCircuitBreakerDisabled tests that a zero threshold never rejects
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b, _ := newTestBreaker(models.CircuitBreakerSettings{})
+
+	for i := 0; i < 10; i++ {
+		if b.Failure(1) {
+			t.Fatal("disabled breaker opened a circuit")
+		}
+	}
+	if !b.Allow(1) {
+		t.Error("disabled breaker rejected a request")
+	}
+}
@@ -0,0 +1,228 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrClientRateLimited is returned when a client exceeds its requests per minute
+	ErrClientRateLimited = errors.New("client rate limit exceeded")
+	// ErrClientQuotaExceeded is returned when a client has used up its daily quota
+	ErrClientQuotaExceeded = errors.New("client daily quota exceeded")
+)
+
+// Client is an authenticated proxy client with its limits and today's usage
+type Client struct {
+	ID       int
+	Username string
+
+	registry *ClientRegistry
+
+	mu                sync.Mutex
+	passwordHash      string
+	verified          [sha256.Size]byte // sha256 of the last password that passed bcrypt
+	hasVerified       bool
+	requestsPerMinute int
+	limiter           *rate.Limiter // nil when unlimited
+	dailyRequestQuota int64
+	dailyByteQuota    int64
+	day               time.Time
+	requestsToday     int64
+	bytesToday        int64
+}
+
+// ClientRegistry holds the enabled proxy client credentials in memory
+// It is refreshed from the database periodically and after API changes
+type ClientRegistry struct {
+	repo    *repository.ClientRepository
+	usage   *UsageTracker // persists client usage
+	logger  *logger.Logger
+	mu      sync.RWMutex
+	clients map[string]*Client // keyed by username
+}
+
+// NewClientRegistry creates a new client registry
+func NewClientRegistry(repo *repository.ClientRepository, usage *UsageTracker, log *logger.Logger) *ClientRegistry {
+	return &ClientRegistry{
+		repo:    repo,
+		usage:   usage,
+		logger:  log,
+		clients: make(map[string]*Client),
+	}
+}
+
+// Refresh reloads enabled clients and today's usage from the database
+// Existing clients keep their limiter state and in-memory counters
+func (r *ClientRegistry) Refresh(ctx context.Context) error {
+	rows, err := r.repo.ListEnabled(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load proxy clients: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := make(map[string]*Client, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		c, ok := r.clients[row.Username]
+		if !ok || c.ID != row.ID {
+			c = &Client{ID: row.ID, Username: row.Username, registry: r}
+		}
+		c.apply(row)
+		clients[row.Username] = c
+	}
+	r.clients = clients
+
+	return nil
+}
+
+// Len returns the number of enabled clients
+func (r *ClientRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.clients)
+}
+
+// Authenticate returns the enabled client matching the credentials, or nil
+func (r *ClientRegistry) Authenticate(username, password string) *Client {
+	r.mu.RLock()
+	c := r.clients[username]
+	r.mu.RUnlock()
+
+	if c == nil {
+		return nil
+	}
+
+	if !c.checkPassword(password) {
+		return nil
+	}
+
+	return c
+}
+
+// apply updates the client from its database row
+func (c *Client) apply(row *models.ProxyClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.passwordHash != row.PasswordHash {
+		c.passwordHash = row.PasswordHash
+		c.hasVerified = false
+	}
+
+	if c.limiter == nil || c.requestsPerMinute != row.RequestsPerMinute {
+		c.requestsPerMinute = row.RequestsPerMinute
+		c.limiter = nil
+		if row.RequestsPerMinute > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(float64(row.RequestsPerMinute)/60), row.RequestsPerMinute)
+		}
+	}
+
+	c.dailyRequestQuota = row.DailyRequestQuota
+	c.dailyByteQuota = row.DailyByteQuota
+
+	// Take the larger of the stored and in-memory counters, since usage
+	// writes are asynchronous and may not have landed yet
+	c.rollover()
+	c.requestsToday = max(c.requestsToday, row.RequestsToday)
+	c.bytesToday = max(c.bytesToday, row.BytesToday)
+}
+
+// checkPassword verifies the password against the bcrypt hash
+// The last successful password is cached so bcrypt doesn't run on every request
+func (c *Client) checkPassword(password string) bool {
+	sum := sha256.Sum256([]byte(password))
+
+	c.mu.Lock()
+	hash := c.passwordHash
+	if c.hasVerified && subtle.ConstantTimeCompare(sum[:], c.verified[:]) == 1 {
+		c.mu.Unlock()
+		return true
+	}
+	c.mu.Unlock()
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	if c.passwordHash == hash {
+		c.verified = sum
+		c.hasVerified = true
+	}
+	c.mu.Unlock()
+
+	return true
+}
+
+// Admit checks the client's rate limit and daily quotas and counts the request
+func (c *Client) Admit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollover()
+
+	if c.dailyRequestQuota > 0 && c.requestsToday >= c.dailyRequestQuota {
+		return ErrClientQuotaExceeded
+	}
+	if c.dailyByteQuota > 0 && c.bytesToday >= c.dailyByteQuota {
+		return ErrClientQuotaExceeded
+	}
+	if c.limiter != nil && !c.limiter.Allow() {
+		return ErrClientRateLimited
+	}
+
+	c.requestsToday++
+	return nil
+}
+
+// RecordUsage adds transferred bytes to today's usage and queues the request
+// to be persisted by the usage writer
+// The request itself was already counted in memory by Admit
+func (c *Client) RecordUsage(bytes int64) {
+	c.mu.Lock()
+	c.rollover()
+	c.bytesToday += bytes
+	day := c.day
+	c.mu.Unlock()
+
+	c.registry.usage.RecordClient(c.ID, day, bytes)
+}
+
+// rollover resets today's counters when the day changes; c.mu must be held
+func (c *Client) rollover() {
+	today := repository.UsageDay(time.Now())
+	if !c.day.Equal(today) {
+		c.day = today
+		c.requestsToday = 0
+		c.bytesToday = 0
+	}
+}
+
+// clientContextKey stores the authenticated *Client in a request context
+type clientContextKey struct{}
+
+// WithClient returns a copy of ctx carrying the authenticated client
+func WithClient(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, c)
+}
+
+// ClientFromContext returns the authenticated client, or nil for anonymous
+// and shared-credential requests
+func ClientFromContext(ctx context.Context) *Client {
+	c, _ := ctx.Value(clientContextKey{}).(*Client)
+	return c
+}
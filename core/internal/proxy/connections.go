@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ConnectionTracker counts in-flight requests and open tunnels per upstream proxy
+// One tracker is shared by the handler and every least-connections selector.
+type ConnectionTracker struct {
+	mu     sync.RWMutex
+	counts map[int]*atomic.Int64 // keyed by proxy ID
+}
+
+// NewConnectionTracker creates a new connection tracker
+func NewConnectionTracker() *ConnectionTracker {
+	return &ConnectionTracker{
+		counts: make(map[int]*atomic.Int64),
+	}
+}
+
+// Acquire counts a new request or tunnel through the proxy
+// The returned release func must be called once it ends; extra calls are ignored.
+func (t *ConnectionTracker) Acquire(proxyID int) (release func()) {
+	counter := t.counter(proxyID)
+	counter.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { counter.Add(-1) })
+	}
+}
+
+// InFlight returns the number of requests and tunnels currently using the proxy
+func (t *ConnectionTracker) InFlight(proxyID int) int64 {
+	t.mu.RLock()
+	counter, ok := t.counts[proxyID]
+	t.mu.RUnlock()
+
+	if !ok {
+		return 0
+	}
+	return counter.Load()
+}
+
+// counter returns the proxy's counter, creating it on first use
+func (t *ConnectionTracker) counter(proxyID int) *atomic.Int64 {
+	t.mu.RLock()
+	counter, ok := t.counts[proxyID]
+	t.mu.RUnlock()
+	if ok {
+		return counter
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if counter, ok := t.counts[proxyID]; ok {
+		return counter
+	}
+	counter = &atomic.Int64{}
+	t.counts[proxyID] = counter
+	return counter
+}
+
+// connectionAware is implemented by selectors that balance on in-flight requests
+type connectionAware interface {
+	setConnections(conns *ConnectionTracker)
+}
+
+// shareConnections makes the selector balance on the shared in-flight counters, if it uses them
+func shareConnections(selector ProxySelector, conns *ConnectionTracker) {
+	if c, ok := selector.(connectionAware); ok {
+		c.setConnections(conns)
+	}
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// TestConnectionTrackerRelease tests that release is counted once
+func TestConnectionTrackerRelease(t *testing.T) {
+	conns := NewConnectionTracker()
+
+	release := conns.Acquire(1)
+	conns.Acquire(1)
+	if got := conns.InFlight(1); got != 2 {
+		t.Fatalf("InFlight = %d, want 2", got)
+	}
+
+	release()
+	release()
+	if got := conns.InFlight(1); got != 1 {
+		t.Errorf("InFlight after double release = %d, want 1", got)
+	}
+	if got := conns.InFlight(2); got != 0 {
+		t.Errorf("InFlight of unused proxy = %d, want 0", got)
+	}
+}
+
+// TestLeastConnectionsSelector tests that the busier of two proxies is avoided
+func TestLeastConnectionsSelector(t *testing.T) {
+	s := NewLeastConnectionsSelector(nil, &models.RotationSettings{})
+	s.proxies = []*models.Proxy{{ID: 1}, {ID: 2}}
+
+	conns := NewConnectionTracker()
+	shareConnections(s, conns)
+	conns.Acquire(1)
+
+	for i := 0; i < 20; i++ {
+		p, err := s.Select(context.Background())
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		if p.ID != 2 {
+			t.Fatalf("Select = proxy %d, want the idle proxy 2", p.ID)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// geoRecord holds the fields read from MaxMind City/Country and ASN databases
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN   uint   `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// GeoIP looks up exit IPs in local MaxMind-format (mmdb) databases
+// A nil *GeoIP is valid and resolves nothing
+type GeoIP struct {
+	readers []*maxminddb.Reader
+}
+
+// OpenGeoIP opens the given mmdb files, typically a City (or Country) and an ASN
+// database; empty paths are skipped. It returns nil when no path is set.
+func OpenGeoIP(paths ...string) (*GeoIP, error) {
+	g := &GeoIP{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		r, err := maxminddb.Open(path)
+		if err != nil {
+			g.Close()
+			return nil, fmt.Errorf("failed to open GeoIP database %s: %w", path, err)
+		}
+		g.readers = append(g.readers, r)
+	}
+
+	if len(g.readers) == 0 {
+		return nil, nil
+	}
+	return g, nil
+}
+
+// Lookup returns the location of ip, merged across all databases
+func (g *GeoIP) Lookup(ip net.IP) models.GeoInfo {
+	var info models.GeoInfo
+	if g == nil {
+		return info
+	}
+
+	for _, r := range g.readers {
+		var rec geoRecord
+		if err := r.Lookup(ip, &rec); err != nil {
+			continue
+		}
+		if info.Country == "" {
+			info.Country = strings.ToUpper(rec.Country.ISOCode)
+		}
+		if info.City == "" {
+			info.City = rec.City.Names["en"]
+		}
+		if info.ASN == 0 {
+			info.ASN = int(rec.ASN)
+			info.ASOrg = rec.ASOrg
+		}
+	}
+
+	return info
+}
+
+// Close releases the databases
+func (g *GeoIP) Close() error {
+	if g == nil {
+		return nil
+	}
+	for _, r := range g.readers {
+		r.Close()
+	}
+	return nil
+}
+
+// parseExitIP extracts the caller IP from an echo endpoint response
+// Plain-text bodies (api.ipify.org) and JSON with an "ip" or "origin" field
+// (ipify ?format=json, httpbin.org/ip) are supported
+func parseExitIP(body []byte) net.IP {
+	text := strings.TrimSpace(string(body))
+	if ip := net.ParseIP(text); ip != nil {
+		return ip
+	}
+
+	var payload struct {
+		IP     string `json:"ip"`
+		Origin string `json:"origin"`
+	}
+	if err := json.Unmarshal([]byte(text), &payload); err != nil {
+		return nil
+	}
+
+	value := payload.IP
+	if value == "" {
+		// httpbin reports "client, proxy" when forwarded; the first is the caller
+		value, _, _ = strings.Cut(payload.Origin, ",")
+	}
+	return net.ParseIP(strings.TrimSpace(value))
+}
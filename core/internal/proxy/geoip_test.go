@@ -0,0 +1,41 @@
+package proxy
+
+import "testing"
+
+// TestParseExitIP tests extracting the caller IP from echo endpoint responses
+func TestParseExitIP(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{"203.0.113.7\n", "203.0.113.7"},
+		{"2001:db8::1", "2001:db8::1"},
+		{`{"ip":"198.51.100.2"}`, "198.51.100.2"},
+		{`{"origin": "198.51.100.2, 10.0.0.1"}`, "198.51.100.2"},
+		{"<html>blocked</html>", ""},
+		{`{"ip":""}`, ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := parseExitIP([]byte(tt.body))
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("parseExitIP(%q) = %v, want nil", tt.body, got)
+			}
+			continue
+		}
+		if got == nil || got.String() != tt.want {
+			t.Errorf("parseExitIP(%q) = %v, want %s", tt.body, got, tt.want)
+		}
+	}
+}
+
+// TestGeoIPLookupNil tests that a nil GeoIP resolves nothing
+func TestGeoIPLookupNil(t *testing.T) {
+	var g *GeoIP
+	if info := g.Lookup(parseExitIP([]byte("203.0.113.7"))); info.Country != "" || info.ASN != 0 {
+		t.Errorf("nil GeoIP Lookup = %+v, want zero", info)
+	}
+}
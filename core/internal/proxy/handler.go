@@ -3,24 +3,60 @@ package proxy
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
 	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/tracing"
 	"github.com/alpkeskin/rota/core/pkg/logger"
 	"github.com/elazarl/goproxy"
 	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 	proxyDialer "golang.org/x/net/proxy"
 )
 
+// ErrCircuitOpen is returned when every proxy the selector offers has an open circuit
+var ErrCircuitOpen = errors.New("circuit breaker open for all selected proxies")
+
+// ErrNoTunnelProxy is returned when no proxy the selector offers can tunnel to the CONNECT host
+var ErrNoTunnelProxy = errors.New("no selected proxy can tunnel to the requested host")
+
+// ErrProxyBlocked is returned when the target host has blocked every proxy the selector offers
+var ErrProxyBlocked = errors.New("target host blocked all selected proxies")
+
+// breakerSelectAttempts bounds how many proxies are drawn from the selector
+// while looking for one whose circuit is not open
+const breakerSelectAttempts = 10
+
+// handlerConfig is a snapshot of the handler's live configuration
+// Snapshots are never modified; UpdateConfig swaps in a new one.
+type handlerConfig struct {
+	selector ProxySelector
+	settings *models.RotationSettings
+	blocked  *blockedMatcher
+}
+
 // UpstreamProxyHandler handles requests with upstream proxy rotation
+// Each request loads the configuration snapshot once, so requests in flight
+// during a reload finish on the selector and settings they started with.
 type UpstreamProxyHandler struct {
-	selector        ProxySelector
+	config          atomic.Pointer[handlerConfig]
+	pools           *PoolManager
+	sessions        *SessionManager
+	breaker         *CircuitBreaker
+	blocks          *BlockList
+	conns           *ConnectionTracker
+	transports      *TransportCache
 	tracker         *UsageTracker
-	settings        *models.RotationSettings
 	logger          *logger.Logger
 	removeUnhealthy bool
 }
@@ -28,17 +64,44 @@ type UpstreamProxyHandler struct {
 // NewUpstreamProxyHandler creates a new upstream proxy handler
 func NewUpstreamProxyHandler(
 	selector ProxySelector,
+	pools *PoolManager,
+	sessions *SessionManager,
+	breaker *CircuitBreaker,
+	blocks *BlockList,
+	conns *ConnectionTracker,
+	transports *TransportCache,
 	tracker *UsageTracker,
 	settings *models.RotationSettings,
 	log *logger.Logger,
 ) *UpstreamProxyHandler {
-	return &UpstreamProxyHandler{
-		selector:        selector,
+	h := &UpstreamProxyHandler{
+		pools:           pools,
+		sessions:        sessions,
+		breaker:         breaker,
+		blocks:          blocks,
+		conns:           conns,
+		transports:      transports,
 		tracker:         tracker,
-		settings:        settings,
 		logger:          log,
 		removeUnhealthy: settings.RemoveUnhealthy,
 	}
+	h.UpdateConfig(selector, settings)
+	return h
+}
+
+// UpdateConfig atomically replaces the default selector and rotation settings
+// settings must not be modified afterwards.
+func (h *UpstreamProxyHandler) UpdateConfig(selector ProxySelector, settings *models.RotationSettings) {
+	h.config.Store(&handlerConfig{
+		selector: selector,
+		settings: settings,
+		blocked:  newBlockedMatcher(settings.Blocked),
+	})
+}
+
+// Selector returns the current default selector
+func (h *UpstreamProxyHandler) Selector() ProxySelector {
+	return h.config.Load().selector
 }
 
 // HandleRequest handles HTTP requests with upstream proxy rotation
@@ -46,15 +109,16 @@ func (h *UpstreamProxyHandler) HandleRequest(req *http.Request, ctx *goproxy.Pro
 	startTime := time.Now()
 	requestID := uuid.New().String()
 
-	// Determine source based on target host
+	// Requests served through a reverse-proxy route are logged as such
 	source := "proxy"
-	urlStr := req.URL.String()
-	if strings.Contains(req.URL.Host, "api.hyperliquid.xyz") || strings.Contains(urlStr, "api.hyperliquid.xyz") {
-		source = "hl-proxy"
+	route := RouteOptionsFromContext(req.Context()).Route
+	if route != "" {
+		source = "route"
 	}
 
 	h.logger.Info("handling proxy request",
 		"source", source,
+		"route", route,
 		"request_id", requestID,
 		"method", req.Method,
 		"url", req.URL.String(),
@@ -63,41 +127,81 @@ func (h *UpstreamProxyHandler) HandleRequest(req *http.Request, ctx *goproxy.Pro
 	// Remove hop-by-hop headers
 	h.removeHopByHopHeaders(req)
 
+	client := ClientFromContext(req.Context())
+	clientID := 0
+	if client != nil {
+		clientID = client.ID
+	}
+
 	// Try to send request through proxy pool with retry/fallback
-	resp, proxyID, err := h.sendWithRetry(req, ctx.Req.Context())
+	// The span continues the client's trace if it sent a traceparent
+	spanCtx, span := startRequestSpan(ctx.Req.Context(), "proxy.request", req, req.URL.Hostname())
+	resp, proxyID, err := h.sendWithRetry(req, spanCtx)
 	duration := int(time.Since(startTime).Milliseconds())
 
-	// Record the request
 	if proxyID > 0 {
-		record := RequestRecord{
-			ProxyID:      proxyID,
-			ProxyAddress: "", // Will be filled from proxy info
-			RequestedURL: req.URL.String(),
-			Method:       req.Method,
-			Success:      err == nil && resp != nil,
-			ResponseTime: duration,
-			Timestamp:    startTime,
-		}
+		span.SetAttributes(attrProxyID.Int(proxyID))
+	}
+	if resp != nil {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	}
+	endSpan(span, err)
+
+	// Build the request record
+	requestBytes := max(req.ContentLength, 0)
+	record := RequestRecord{
+		ProxyID:      proxyID,
+		ClientID:     clientID,
+		ProxyAddress: "", // Will be filled from proxy info
+		RequestedURL: req.URL.String(),
+		Method:       req.Method,
+		Success:      err == nil && resp != nil,
+		ResponseTime: duration,
+		Timestamp:    startTime,
+		BytesSent:    requestBytes,
+	}
 
-		if resp != nil {
-			record.StatusCode = resp.StatusCode
-		}
+	if resp != nil {
+		record.StatusCode = resp.StatusCode
+	}
+
+	if err != nil {
+		record.ErrorMessage = err.Error()
+	}
 
+	// Account the request against the client's quota and record it
+	// A blocked response comes with an error and is passed on as a failure
+	if proxyID > 0 {
+		result := metrics.ResultSuccess
 		if err != nil {
-			record.ErrorMessage = err.Error()
+			result = metrics.ResultBlocked
+		}
+		metrics.ProxyRequests.WithLabelValues(result, metrics.MethodLabel(req.Method), metrics.ProxyLabel(proxyID)).Inc()
+	}
+	if resp == nil {
+		if client != nil {
+			client.RecordUsage(requestBytes)
+		}
+		if proxyID > 0 {
+			h.recordRequest(record)
+		}
+	} else {
+		// Response bytes are known once the body has been consumed
+		resp.Body = &countingBody{
+			ReadCloser: resp.Body,
+			onClose: func(n int64) {
+				if client != nil {
+					client.RecordUsage(requestBytes + n)
+				}
+				if proxyID > 0 {
+					record.BytesReceived = n
+					h.recordRequest(record)
+				}
+			},
 		}
-
-		// Record asynchronously to not block the request
-		go func() {
-			recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			if err := h.tracker.RecordRequest(recordCtx, record); err != nil {
-				h.logger.Error("failed to record request", "error", err)
-			}
-		}()
 	}
 
-	if err != nil {
+	if resp == nil {
 		h.logger.Error("proxy request failed",
 			"source", source,
 			"request_id", requestID,
@@ -109,6 +213,20 @@ func (h *UpstreamProxyHandler) HandleRequest(req *http.Request, ctx *goproxy.Pro
 		return req, h.badGateway(err.Error())
 	}
 
+	if err != nil {
+		h.logger.Warn("proxy request blocked by target",
+			"source", source,
+			"request_id", requestID,
+			"method", req.Method,
+			"url", req.URL.String(),
+			"status", resp.StatusCode,
+			"error", err,
+			"duration_ms", duration,
+			"proxy_id", proxyID,
+		)
+		return req, resp
+	}
+
 	h.logger.Info("proxy request completed",
 		"source", source,
 		"request_id", requestID,
@@ -124,13 +242,21 @@ func (h *UpstreamProxyHandler) HandleRequest(req *http.Request, ctx *goproxy.Pro
 
 // sendWithRetry attempts to send the request with retry and fallback logic
 func (h *UpstreamProxyHandler) sendWithRetry(req *http.Request, ctx context.Context) (*http.Response, int, error) {
-	maxFallbackRetries := h.settings.FallbackMaxRetries
-	if !h.settings.Fallback {
+	cfg := h.config.Load()
+
+	// Buffer the body so retries resend it
+	policy, err := newRetryPolicy(req, cfg.settings)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	maxFallbackRetries := cfg.settings.FallbackMaxRetries
+	if !cfg.settings.Fallback {
 		maxFallbackRetries = 1
 	}
 
 	// Use Retries setting for per-proxy retries
-	perProxyRetries := h.settings.Retries
+	perProxyRetries := cfg.settings.Retries
 	if perProxyRetries <= 0 {
 		perProxyRetries = 1 // Default to 1 if not set
 	}
@@ -142,13 +268,62 @@ func (h *UpstreamProxyHandler) sendWithRetry(req *http.Request, ctx context.Cont
 		"url", req.URL.String(),
 	)
 
+	clientID := 0
+	if client := ClientFromContext(req.Context()); client != nil {
+		clientID = client.ID
+	}
+	opts := RouteOptionsFromContext(req.Context())
+
+	// Proxies the target host has blocked recently are skipped, unless all of them are
+	host := req.URL.Hostname()
+	var blockedCandidates []*models.Proxy
+	notBlocked := func(p *models.Proxy) error {
+		if h.blocks.Blocked(p.ID, host) {
+			blockedCandidates = append(blockedCandidates, p)
+			return ErrProxyBlocked
+		}
+		return nil
+	}
+
 	var lastErr error
 	triedProxies := make(map[int]bool)
 
-	for fallbackAttempt := 0; fallbackAttempt < maxFallbackRetries; fallbackAttempt++ {
-		// Select a proxy
-		selectedProxy, err := h.selector.Select(ctx)
+	// The last blocked response is kept until another proxy is tried, and
+	// passed to the client if none is
+	var blockedResp *http.Response
+	var blockedRecord RequestRecord
+	var lastBlockedErr *blockedError
+	discardBlocked := func() {
+		if blockedResp == nil {
+			return
+		}
+		metrics.ProxyRequests.WithLabelValues(metrics.ResultBlocked, metrics.MethodLabel(req.Method), metrics.ProxyLabel(blockedRecord.ProxyID)).Inc()
+		h.recordRequest(blockedRecord)
+
+		// Drain a little so the connection can be reused
+		io.CopyN(io.Discard, blockedResp.Body, blockedBodyPeek)
+		blockedResp.Body.Close()
+		blockedResp = nil
+	}
+
+	// Blocked responses get their own retries on top of the fallback attempts
+	blocked := 0
+	for fallbackAttempt := 0; fallbackAttempt < maxFallbackRetries+blocked; fallbackAttempt++ {
+		// Select a proxy (the pinned one for sticky sessions)
+		blockedCandidates = blockedCandidates[:0]
+		selectedProxy, err := h.tracedSelectProxy(ctx, cfg, opts, triedProxies, notBlocked)
+		if errors.Is(err, ErrProxyBlocked) && blockedResp == nil {
+			// The host blocked every candidate; rather than failing, use the one blocked the shortest
+			if p := h.blocks.Soonest(untried(blockedCandidates, triedProxies), host); p != nil && h.breaker.Allow(p.ID) {
+				selectedProxy, err = p, nil
+			}
+		}
 		if err != nil {
+			if blockedResp != nil {
+				// No other proxy to try, the client gets the blocked response
+				return blockedResp, blockedRecord.ProxyID, lastBlockedErr
+			}
+			metrics.SelectorErrors.WithLabelValues(selectionErrorReason(err)).Inc()
 			h.logger.Error("no proxy available - request will fail",
 				"source", "proxy",
 				"error", err,
@@ -162,6 +337,11 @@ func (h *UpstreamProxyHandler) sendWithRetry(req *http.Request, ctx context.Cont
 		}
 		triedProxies[selectedProxy.ID] = true
 
+		if blockedResp != nil {
+			metrics.Retries.WithLabelValues("blocked").Inc()
+			discardBlocked()
+		}
+
 		h.logger.Info("attempting request with proxy",
 			"source", "proxy",
 			"proxy_id", selectedProxy.ID,
@@ -170,7 +350,24 @@ func (h *UpstreamProxyHandler) sendWithRetry(req *http.Request, ctx context.Cont
 		)
 
 		// Try this proxy with retries
-		resp, err := h.tryProxyWithRetries(req, ctx, selectedProxy, perProxyRetries)
+		upstreamCtx, upstreamSpan := startUpstreamSpan(ctx, selectedProxy, len(triedProxies)-1)
+		resp, err := h.tryProxyWithRetries(req, upstreamCtx, cfg.settings, policy, selectedProxy, perProxyRetries)
+		reason := ""
+		if err == nil {
+			reason = cfg.blocked.match(resp)
+		}
+		if reason != "" {
+			upstreamSpan.SetAttributes(attrBlockedReason.String(reason))
+			endSpan(upstreamSpan, &blockedError{reason: reason})
+		} else {
+			endSpan(upstreamSpan, err)
+		}
+		if reason != "" {
+			// Blocked by this target only, the proxy itself works
+			h.breaker.Release(selectedProxy.ID)
+		} else {
+			h.reportOutcome(ctx, selectedProxy, err)
+		}
 		if err != nil {
 			lastErr = fmt.Errorf("proxy %s failed after %d retries: %w", selectedProxy.Address, perProxyRetries, err)
 			h.logger.Warn("proxy failed after all retries",
@@ -182,30 +379,83 @@ func (h *UpstreamProxyHandler) sendWithRetry(req *http.Request, ctx context.Cont
 				"error", err,
 			)
 
-			// Record the failed request to mark proxy as failed if needed
-			go func() {
-				recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
-				record := RequestRecord{
-					ProxyID:      selectedProxy.ID,
-					ProxyAddress: selectedProxy.Address,
-					RequestedURL: req.URL.String(),
-					Method:       req.Method,
-					Success:      false,
-					ResponseTime: 0,
-					ErrorMessage: err.Error(),
-					Timestamp:    time.Now(),
-				}
-				if recordErr := h.tracker.RecordRequest(recordCtx, record); recordErr != nil {
-					h.logger.Error("failed to record failed request", "error", recordErr)
-				}
+			// Record the failed request; the circuit breaker keeps the proxy out of rotation
+			metrics.ProxyRequests.WithLabelValues(metrics.ResultFailure, metrics.MethodLabel(req.Method), metrics.ProxyLabel(selectedProxy.ID)).Inc()
+			h.recordRequest(RequestRecord{
+				ProxyID:      selectedProxy.ID,
+				ClientID:     clientID,
+				ProxyAddress: selectedProxy.Address,
+				RequestedURL: req.URL.String(),
+				Method:       req.Method,
+				Success:      false,
+				ResponseTime: 0,
+				ErrorMessage: err.Error(),
+				Timestamp:    time.Now(),
+			})
+
+			// Don't resend a request the proxy may already have forwarded
+			if !policy.allows(err) {
+				h.logger.Warn("request not retried",
+					"source", "proxy",
+					"method", req.Method,
+					"replayable_body", policy.replayable,
+				)
+				break
+			}
 
-				// Immediately mark proxy as failed to prevent further selection
-				if markErr := h.tracker.UpdateProxyStatus(recordCtx, selectedProxy.ID, "failed"); markErr != nil {
-					h.logger.Error("failed to mark proxy as failed", "error", markErr)
-				}
-			}()
+			if fallbackAttempt+1 < maxFallbackRetries+blocked {
+				metrics.Retries.WithLabelValues("fallback").Inc()
+			}
+			continue
+		}
+
+		if reason != "" {
+			blocked++
+
+			// Keep the proxy away from this host until Retry-After or the cooldown passes
+			after, hasRetryAfter := retryAfter(resp, time.Now())
+			cooldown := time.Duration(cfg.settings.Blocked.CooldownSeconds) * time.Second
+			if hasRetryAfter {
+				cooldown = after
+			}
+			h.blocks.Block(selectedProxy.ID, host, cooldown)
+
+			h.logger.Warn("proxy blocked by target",
+				"source", "proxy",
+				"proxy_id", selectedProxy.ID,
+				"proxy_address", selectedProxy.Address,
+				"host", host,
+				"reason", reason,
+				"blocked_attempt", blocked,
+			)
+
+			blockedErr := &blockedError{reason: reason}
+			if blocked > cfg.settings.Blocked.MaxRetries || !policy.allows(blockedErr) {
+				// Out of retries, the client gets the blocked response
+				return resp, selectedProxy.ID, blockedErr
+			}
+
+			// Recorded as a failed request once another proxy is tried
+			blockedResp, lastBlockedErr = resp, blockedErr
+			blockedRecord = RequestRecord{
+				ProxyID:      selectedProxy.ID,
+				ClientID:     clientID,
+				ProxyAddress: selectedProxy.Address,
+				RequestedURL: req.URL.String(),
+				Method:       req.Method,
+				Success:      false,
+				StatusCode:   resp.StatusCode,
+				ErrorMessage: blockedErr.Error(),
+				Timestamp:    time.Now(),
+			}
+			lastErr = blockedErr
 
+			select {
+			case <-time.After(blockedBackoff(cfg.settings.Blocked, blocked, after)):
+			case <-ctx.Done():
+				discardBlocked()
+				return nil, 0, ctx.Err()
+			}
 			continue
 		}
 
@@ -216,17 +466,138 @@ func (h *UpstreamProxyHandler) sendWithRetry(req *http.Request, ctx context.Cont
 			"fallback_attempt", fallbackAttempt+1,
 		)
 
+		h.pinSession(opts, selectedProxy)
+
 		// Success!
 		return resp, selectedProxy.ID, nil
 	}
 
+	if blockedResp != nil {
+		// Out of proxies to try, the client gets the blocked response
+		return blockedResp, blockedRecord.ProxyID, lastBlockedErr
+	}
 	return nil, 0, fmt.Errorf("all proxies failed, last error: %w", lastErr)
 }
 
+// untried returns the proxies not tried yet
+func untried(proxies []*models.Proxy, tried map[int]bool) []*models.Proxy {
+	var result []*models.Proxy
+	for _, p := range proxies {
+		if !tried[p.ID] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// tracedSelectProxy selects a proxy like selectProxy, in a span
+func (h *UpstreamProxyHandler) tracedSelectProxy(ctx context.Context, cfg *handlerConfig, opts RouteOptions, tried map[int]bool, accept func(*models.Proxy) error) (*models.Proxy, error) {
+	ctx, span := tracer.Start(ctx, "proxy.select", trace.WithAttributes(attrFallbackIndex.Int(len(tried))))
+	p, err := h.selectProxy(ctx, cfg, opts, tried, accept)
+	if err == nil {
+		span.SetAttributes(attrProxyID.Int(p.ID))
+	}
+	endSpan(span, err)
+	return p, err
+}
+
+// selectorFor returns the selector for the request's pool and geo constraint,
+// or the snapshot's default selector
+func (h *UpstreamProxyHandler) selectorFor(ctx context.Context, cfg *handlerConfig, opts RouteOptions) (ProxySelector, error) {
+	if geo := opts.geoFilter(); !geo.IsZero() {
+		return h.pools.Constrained(ctx, opts.Pool, geo)
+	}
+	if opts.Pool == "" {
+		return cfg.selector, nil
+	}
+	return h.pools.Get(opts.Pool)
+}
+
+// selectProxy returns the proxy pinned to the request's sticky session, if it is
+// still in the active pool and hasn't failed during this request, otherwise asks the selector
+// Proxies whose circuit is open or that accept rejects (if not nil, with the error
+// returned when no proxy is left) are skipped; an allowed proxy takes a half-open
+// slot, which reportOutcome gives back.
+func (h *UpstreamProxyHandler) selectProxy(ctx context.Context, cfg *handlerConfig, opts RouteOptions, tried map[int]bool, accept func(*models.Proxy) error) (*models.Proxy, error) {
+	selector, err := h.selectorFor(ctx, cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if key := opts.sessionKey(); key != "" {
+		if proxyID, ok := h.sessions.Lookup(key); ok {
+			if p := selector.Lookup(proxyID); p != nil && !tried[proxyID] && (accept == nil || accept(p) == nil) && h.breaker.Allow(proxyID) {
+				return p, nil
+			}
+
+			// Pinned proxy failed or left the pool, fail over to a new one
+			h.sessions.Unpin(key)
+			h.logger.Info("sticky session failing over",
+				"source", "proxy",
+				"session_id", opts.SessionID,
+				"proxy_id", proxyID,
+			)
+		}
+	}
+
+	rejected := ErrCircuitOpen
+	for attempt := 0; attempt < breakerSelectAttempts; attempt++ {
+		p, err := selector.Select(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Already tried proxies are returned as is, the caller skips them
+		if tried[p.ID] {
+			return p, nil
+		}
+		if accept != nil {
+			if err := accept(p); err != nil {
+				rejected = err
+				continue
+			}
+		}
+		if h.breaker.Allow(p.ID) {
+			return p, nil
+		}
+		rejected = ErrCircuitOpen
+	}
+
+	return nil, rejected
+}
+
+// reportOutcome feeds the result of using a proxy into its circuit breaker
+// A request cancelled by the client says nothing about the proxy.
+func (h *UpstreamProxyHandler) reportOutcome(ctx context.Context, p *models.Proxy, err error) {
+	switch {
+	case err == nil:
+		h.breaker.Success(p.ID)
+	case ctx.Err() != nil:
+		h.breaker.Release(p.ID)
+	case h.breaker.Failure(p.ID):
+		h.logger.Warn("circuit breaker opened for proxy",
+			"source", "proxy",
+			"proxy_id", p.ID,
+			"proxy_address", p.Address,
+			"error", err,
+		)
+	}
+}
+
+// pinSession pins the request's sticky session (if any) to the proxy that served it
+func (h *UpstreamProxyHandler) pinSession(opts RouteOptions, p *models.Proxy) {
+	if key := opts.sessionKey(); key != "" {
+		h.sessions.Pin(key, opts, p)
+	}
+}
+
 // tryProxyWithRetries attempts to send request through a specific proxy with retries
-func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx context.Context, selectedProxy *models.Proxy, maxRetries int) (*http.Response, error) {
+// The proxy counts as in use until the response body is closed.
+func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx context.Context, settings *models.RotationSettings, policy retryPolicy, selectedProxy *models.Proxy, maxRetries int) (*http.Response, error) {
 	var lastErr error
 
+	release := h.conns.Acquire(selectedProxy.ID)
+
 	for retry := 0; retry < maxRetries; retry++ {
 		h.logger.Info("attempting request",
 			"source", "proxy",
@@ -235,10 +606,14 @@ func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx contex
 			"retry", retry+1,
 			"max_retries", maxRetries,
 		)
+		attemptCtx, attempt := tracer.Start(ctx, "proxy.attempt", trace.WithAttributes(attrAttempt.Int(retry+1)))
 
-		// Create transport for this proxy
-		transport, err := h.createTransport(selectedProxy)
+		// Reuse the proxy's transport and its keep-alive connections
+		_, transportSpan := tracer.Start(attemptCtx, "proxy.transport")
+		transport, err := h.transports.Get(selectedProxy)
+		endSpan(transportSpan, err)
 		if err != nil {
+			endSpan(attempt, err)
 			lastErr = fmt.Errorf("failed to create transport: %w", err)
 			h.logger.Warn("transport creation failed",
 				"source", "proxy",
@@ -253,9 +628,9 @@ func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx contex
 		// Create HTTP client with timeout
 		client := &http.Client{
 			Transport: transport,
-			Timeout:   time.Duration(h.settings.Timeout) * time.Second,
+			Timeout:   time.Duration(settings.Timeout) * time.Second,
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if !h.settings.FollowRedirect {
+				if !settings.FollowRedirect {
 					return http.ErrUseLastResponse
 				}
 				if len(via) >= 10 {
@@ -265,16 +640,31 @@ func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx contex
 			},
 		}
 
-		// Clone the request for retry
-		clonedReq := req.Clone(ctx)
+		// Clone the request for retry, with a fresh copy of a buffered body
+		clonedReq := req.Clone(withClientTrace(attemptCtx))
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				lastErr = fmt.Errorf("failed to replay request body: %w", err)
+				endSpan(attempt, lastErr)
+				break
+			}
+			clonedReq.Body = body
+		}
 
 		// CRITICAL FIX: Clear RequestURI for client requests
 		// RequestURI is only for server-side, not for outgoing client requests
 		clonedReq.RequestURI = ""
 
+		// Propagate the trace to the target
+		tracing.Inject(attemptCtx, clonedReq.Header)
+
 		// Send the request
+		sent := time.Now()
 		resp, err := client.Do(clonedReq)
+		observeUpstream("http", sent, err)
 		if err != nil {
+			endSpan(attempt, err)
 			lastErr = fmt.Errorf("proxy %s failed: %w", selectedProxy.Address, err)
 			h.logger.Warn("proxy request failed",
 				"source", "proxy",
@@ -286,11 +676,15 @@ func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx contex
 			)
 
 			// If this is not the last retry, continue to next retry
-			if retry < maxRetries-1 {
+			if retry < maxRetries-1 && policy.allows(err) {
+				metrics.Retries.WithLabelValues("retry").Inc()
 				continue
 			}
+			break
 		} else {
 			// Success!
+			attempt.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			attempt.End()
 			h.logger.Info("proxy request succeeded",
 				"source", "proxy",
 				"proxy_id", selectedProxy.ID,
@@ -298,58 +692,18 @@ func (h *UpstreamProxyHandler) tryProxyWithRetries(req *http.Request, ctx contex
 				"retry", retry+1,
 				"status_code", resp.StatusCode,
 			)
+			resp.Body = &countingBody{
+				ReadCloser: resp.Body,
+				onClose:    func(int64) { release() },
+			}
 			return resp, nil
 		}
 	}
 
+	release()
 	return nil, lastErr
 }
 
-// createTransport creates an HTTP transport for the given proxy
-func (h *UpstreamProxyHandler) createTransport(p *models.Proxy) (*http.Transport, error) {
-	h.logger.Info("creating transport for proxy",
-		"source", "proxy",
-		"proxy_id", p.ID,
-		"proxy_address", p.Address,
-		"protocol", p.Protocol,
-		"has_auth", p.Username != nil && *p.Username != "",
-	)
-
-	transport, err := CreateProxyTransport(p)
-	if err != nil {
-		return nil, err
-	}
-
-	// Debug: Verify proxy is set
-	if transport.Proxy != nil {
-		// Get a sample URL to test proxy function
-		testReq, _ := http.NewRequest("GET", "https://example.com", nil)
-		proxyURL, proxyErr := transport.Proxy(testReq)
-		if proxyErr != nil {
-			h.logger.Warn("proxy function returned error",
-				"source", "proxy",
-				"error", proxyErr,
-			)
-		} else if proxyURL != nil {
-			h.logger.Info("transport proxy configured",
-				"source", "proxy",
-				"proxy_url", proxyURL.String(),
-			)
-		} else {
-			h.logger.Warn("transport proxy function returned nil",
-				"source", "proxy",
-			)
-		}
-	} else {
-		h.logger.Error("transport proxy is nil - requests will go direct!",
-			"source", "proxy",
-			"proxy_id", p.ID,
-		)
-	}
-
-	return transport, nil
-}
-
 // removeHopByHopHeaders removes hop-by-hop headers that shouldn't be proxied
 func (h *UpstreamProxyHandler) removeHopByHopHeaders(req *http.Request) {
 	hopByHopHeaders := []string{
@@ -375,10 +729,25 @@ func (h *UpstreamProxyHandler) removeHopByHopHeaders(req *http.Request) {
 	}
 }
 
-// ConnectThroughProxyForDial is called by goproxy's ConnectDial
+// ConnectThroughProxyForDial is called by goproxy's ConnectDialWithReq
 // It establishes a connection through the upstream proxy pool
-func (h *UpstreamProxyHandler) ConnectThroughProxyForDial(host string) (net.Conn, int, error) {
-	return h.connectThroughProxy(host, context.Background())
+// ctx carries the authenticated client, if any
+func (h *UpstreamProxyHandler) ConnectThroughProxyForDial(ctx context.Context, host string) (net.Conn, int, error) {
+	attrs := []trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		semconv.HTTPRequestMethodKey.String(http.MethodConnect),
+		semconv.ServerAddress(host),
+	)}
+	if client := ClientFromContext(ctx); client != nil {
+		attrs = append(attrs, trace.WithAttributes(attrClientID.Int(client.ID)))
+	}
+	ctx, span := tracer.Start(ctx, "proxy.connect", attrs...)
+
+	conn, proxyID, err := h.connectThroughProxy(host, ctx)
+	if proxyID > 0 {
+		span.SetAttributes(attrProxyID.Int(proxyID))
+	}
+	endSpan(span, err)
+	return conn, proxyID, err
 }
 
 // HandleConnect handles HTTPS CONNECT requests through upstream proxy
@@ -403,25 +772,50 @@ func (h *UpstreamProxyHandler) HandleConnect(host string, ctx *goproxy.ProxyCtx)
 // connectThroughProxy establishes a connection through upstream proxy with retry logic
 func (h *UpstreamProxyHandler) connectThroughProxy(host string, ctx context.Context) (net.Conn, int, error) {
 	startTime := time.Now()
+	cfg := h.config.Load()
 
-	maxFallbackRetries := h.settings.FallbackMaxRetries
-	if !h.settings.Fallback {
+	maxFallbackRetries := cfg.settings.FallbackMaxRetries
+	if !cfg.settings.Fallback {
 		maxFallbackRetries = 1
 	}
 
 	// Use Retries setting for per-proxy retries
-	perProxyRetries := h.settings.Retries
+	perProxyRetries := cfg.settings.Retries
 	if perProxyRetries <= 0 {
 		perProxyRetries = 1 // Default to 1 if not set
 	}
 
+	// A failed CONNECT counts against the client's quota right away,
+	// an established tunnel once it is closed, with its traffic
+	client := ClientFromContext(ctx)
+	clientID := 0
+	established := false
+	if client != nil {
+		clientID = client.ID
+		defer func() {
+			if !established {
+				client.RecordUsage(0)
+			}
+		}()
+	}
+	opts := RouteOptionsFromContext(ctx)
+
+	// Only proxies whose protocol can tunnel to the host are selected
+	tunnelable := func(p *models.Proxy) error {
+		if !canTunnel(p.Protocol, host) {
+			return ErrNoTunnelProxy
+		}
+		return nil
+	}
+
 	var lastErr error
 	triedProxies := make(map[int]bool)
 
 	for fallbackAttempt := 0; fallbackAttempt < maxFallbackRetries; fallbackAttempt++ {
-		// Select a proxy
-		selectedProxy, err := h.selector.Select(ctx)
+		// Select a proxy (the pinned one for sticky sessions)
+		selectedProxy, err := h.tracedSelectProxy(ctx, cfg, opts, triedProxies, tunnelable)
 		if err != nil {
+			metrics.SelectorErrors.WithLabelValues(selectionErrorReason(err)).Inc()
 			h.logger.Error("no proxy available for CONNECT - request will fail",
 				"source", "proxy",
 				"error", err,
@@ -444,8 +838,11 @@ func (h *UpstreamProxyHandler) connectThroughProxy(host string, ctx context.Cont
 		)
 
 		// Try this proxy with retries
-		conn, err := h.tryConnectWithRetries(selectedProxy, host, perProxyRetries)
+		upstreamCtx, upstreamSpan := startUpstreamSpan(ctx, selectedProxy, len(triedProxies)-1)
+		conn, err := h.tryConnectWithRetries(upstreamCtx, cfg.settings, selectedProxy, host, perProxyRetries)
+		endSpan(upstreamSpan, err)
 		duration := int(time.Since(startTime).Milliseconds())
+		h.reportOutcome(ctx, selectedProxy, err)
 
 		if err != nil {
 			lastErr = fmt.Errorf("proxy %s failed after %d retries: %w", selectedProxy.Address, perProxyRetries, err)
@@ -460,24 +857,26 @@ func (h *UpstreamProxyHandler) connectThroughProxy(host string, ctx context.Cont
 			)
 
 			// Record the failed CONNECT request
-			go func(proxyID int, proxyAddr string, failedDuration int, failErr error) {
-				recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-				defer cancel()
-				record := RequestRecord{
-					ProxyID:      proxyID,
-					ProxyAddress: proxyAddr,
-					RequestedURL: "CONNECT://" + host,
-					Method:       "CONNECT",
-					Success:      false,
-					ResponseTime: failedDuration,
-					ErrorMessage: failErr.Error(),
-					Timestamp:    startTime,
-				}
-				if recordErr := h.tracker.RecordRequest(recordCtx, record); recordErr != nil {
-					h.logger.Error("failed to record failed CONNECT request", "error", recordErr)
-				}
-			}(selectedProxy.ID, selectedProxy.Address, duration, err)
+			metrics.ProxyRequests.WithLabelValues(metrics.ResultFailure, http.MethodConnect, metrics.ProxyLabel(selectedProxy.ID)).Inc()
+			if fallbackAttempt+1 < maxFallbackRetries {
+				metrics.Retries.WithLabelValues("fallback").Inc()
+			}
+			h.recordRequest(RequestRecord{
+				ProxyID:      selectedProxy.ID,
+				ClientID:     clientID,
+				ProxyAddress: selectedProxy.Address,
+				RequestedURL: "CONNECT://" + host,
+				Method:       "CONNECT",
+				Success:      false,
+				ResponseTime: duration,
+				ErrorMessage: err.Error(),
+				Timestamp:    startTime,
+			})
 
+			// Stop trying other proxies once the client has gone away or the server is shutting down
+			if ctx.Err() != nil {
+				return nil, 0, lastErr
+			}
 			continue
 		}
 
@@ -489,36 +888,64 @@ func (h *UpstreamProxyHandler) connectThroughProxy(host string, ctx context.Cont
 			"fallback_attempt", fallbackAttempt+1,
 		)
 
-		// Record successful CONNECT request
-		go func(successDuration int) {
-			recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			record := RequestRecord{
-				ProxyID:      selectedProxy.ID,
-				ProxyAddress: selectedProxy.Address,
-				RequestedURL: "CONNECT://" + host,
-				Method:       "CONNECT",
-				Success:      true,
-				ResponseTime: successDuration,
-				StatusCode:   200, // CONNECT 200 OK
-				Timestamp:    startTime,
-			}
-			if recordErr := h.tracker.RecordRequest(recordCtx, record); recordErr != nil {
-				h.logger.Error("failed to record successful CONNECT request", "error", recordErr)
+		h.pinSession(opts, selectedProxy)
+
+		// Record the tunnel with its traffic once it is closed
+		metrics.ProxyRequests.WithLabelValues(metrics.ResultSuccess, http.MethodConnect, metrics.ProxyLabel(selectedProxy.ID)).Inc()
+		metrics.TunnelsOpen.Inc()
+		record := RequestRecord{
+			ProxyID:      selectedProxy.ID,
+			ClientID:     clientID,
+			ProxyAddress: selectedProxy.Address,
+			RequestedURL: "CONNECT://" + host,
+			Method:       "CONNECT",
+			Success:      true,
+			ResponseTime: duration,
+			StatusCode:   200, // CONNECT 200 OK
+			Timestamp:    startTime,
+		}
+		tunnel := trackConn(conn, func(stats connStats) {
+			metrics.TunnelsOpen.Dec()
+			metrics.TunnelsClosed.WithLabelValues(stats.CloseReason).Inc()
+			metrics.TunnelBytes.WithLabelValues("sent").Add(float64(stats.BytesSent))
+			metrics.TunnelBytes.WithLabelValues("received").Add(float64(stats.BytesReceived))
+
+			if client != nil {
+				client.RecordUsage(stats.BytesSent + stats.BytesReceived)
 			}
-		}(duration)
+
+			record.BytesSent = stats.BytesSent
+			record.BytesReceived = stats.BytesReceived
+			record.Duration = stats.Duration
+			record.CloseReason = stats.CloseReason
+			h.recordRequest(record)
+
+			h.logger.Info("CONNECT tunnel closed",
+				"source", "proxy",
+				"proxy_id", record.ProxyID,
+				"host", host,
+				"bytes_sent", stats.BytesSent,
+				"bytes_received", stats.BytesReceived,
+				"duration_ms", stats.Duration.Milliseconds(),
+				"close_reason", stats.CloseReason,
+			)
+		})
 
 		// Success!
-		return conn, selectedProxy.ID, nil
+		established = true
+		return tunnel, selectedProxy.ID, nil
 	}
 
 	return nil, 0, fmt.Errorf("all proxies failed for CONNECT, last error: %w", lastErr)
 }
 
 // tryConnectWithRetries attempts to connect through a specific proxy with retries
-func (h *UpstreamProxyHandler) tryConnectWithRetries(selectedProxy *models.Proxy, host string, maxRetries int) (net.Conn, error) {
+// The proxy counts as in use until the tunnel is closed.
+func (h *UpstreamProxyHandler) tryConnectWithRetries(ctx context.Context, settings *models.RotationSettings, selectedProxy *models.Proxy, host string, maxRetries int) (net.Conn, error) {
 	var lastErr error
 
+	release := h.conns.Acquire(selectedProxy.ID)
+
 	for retry := 0; retry < maxRetries; retry++ {
 		h.logger.Info("attempting CONNECT",
 			"source", "proxy",
@@ -530,7 +957,11 @@ func (h *UpstreamProxyHandler) tryConnectWithRetries(selectedProxy *models.Proxy
 		)
 
 		// Try to connect through this proxy
-		conn, err := h.connectViaProxy(selectedProxy, host)
+		attemptCtx, attempt := tracer.Start(ctx, "proxy.attempt", trace.WithAttributes(attrAttempt.Int(retry+1)))
+		dialed := time.Now()
+		conn, err := h.connectViaProxy(withClientTrace(attemptCtx), settings, selectedProxy, host)
+		observeUpstream("connect", dialed, err)
+		endSpan(attempt, err)
 		if err != nil {
 			lastErr = fmt.Errorf("proxy %s failed: %w", selectedProxy.Address, err)
 			h.logger.Warn("proxy CONNECT failed",
@@ -543,10 +974,12 @@ func (h *UpstreamProxyHandler) tryConnectWithRetries(selectedProxy *models.Proxy
 				"error", err,
 			)
 
-			// If this is not the last retry, continue to next retry
-			if retry < maxRetries-1 {
+			// If this is not the last retry and the client is still waiting, continue to next retry
+			if retry < maxRetries-1 && ctx.Err() == nil {
+				metrics.Retries.WithLabelValues("retry").Inc()
 				continue
 			}
+			break
 		} else {
 			// Success!
 			h.logger.Info("proxy CONNECT succeeded",
@@ -556,15 +989,17 @@ func (h *UpstreamProxyHandler) tryConnectWithRetries(selectedProxy *models.Proxy
 				"host", host,
 				"retry", retry+1,
 			)
-			return conn, nil
+			return trackConn(conn, func(connStats) { release() }), nil
 		}
 	}
 
+	release()
 	return nil, lastErr
 }
 
 // connectViaProxy establishes a connection through a specific proxy
-func (h *UpstreamProxyHandler) connectViaProxy(proxy *models.Proxy, host string) (net.Conn, error) {
+// Cancelling ctx aborts the dial.
+func (h *UpstreamProxyHandler) connectViaProxy(ctx context.Context, settings *models.RotationSettings, proxy *models.Proxy, host string) (net.Conn, error) {
 	switch proxy.Protocol {
 	case "socks5":
 		// Create SOCKS5 dialer
@@ -591,7 +1026,12 @@ func (h *UpstreamProxyHandler) connectViaProxy(proxy *models.Proxy, host string)
 		}
 
 		// Connect to target host through proxy
-		conn, err := dialer.Dial("tcp", host)
+		var conn net.Conn
+		if contextDialer, ok := dialer.(proxyDialer.ContextDialer); ok {
+			conn, err = contextDialer.DialContext(ctx, "tcp", host)
+		} else {
+			conn, err = dialer.Dial("tcp", host)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to %s via SOCKS5 proxy %s: %w", host, proxy.Address, err)
 		}
@@ -601,7 +1041,12 @@ func (h *UpstreamProxyHandler) connectViaProxy(proxy *models.Proxy, host string)
 	case "http", "https":
 		// For HTTP proxies, we need to send a CONNECT request
 		// This is more complex and requires HTTP client setup
-		return h.connectViaHTTPProxy(proxy, host)
+		// https proxies get the request over TLS
+		return h.connectViaHTTPProxy(ctx, settings, proxy, host)
+
+	case "socks4", "socks4a":
+		// SOCKS4 resolves the host locally, SOCKS4a lets the proxy resolve it
+		return dialSOCKS4(ctx, proxy, host, time.Duration(settings.Timeout)*time.Second)
 
 	default:
 		return nil, fmt.Errorf("unsupported proxy protocol for CONNECT: %s", proxy.Protocol)
@@ -609,9 +1054,9 @@ func (h *UpstreamProxyHandler) connectViaProxy(proxy *models.Proxy, host string)
 }
 
 // connectViaHTTPProxy establishes a connection through HTTP proxy using CONNECT method
-func (h *UpstreamProxyHandler) connectViaHTTPProxy(proxy *models.Proxy, host string) (net.Conn, error) {
+func (h *UpstreamProxyHandler) connectViaHTTPProxy(ctx context.Context, settings *models.RotationSettings, proxy *models.Proxy, host string) (net.Conn, error) {
 	// Increase timeout for CONNECT requests (some proxies like proxy.scrape.do need more time)
-	timeout := time.Duration(h.settings.Timeout) * time.Second
+	timeout := time.Duration(settings.Timeout) * time.Second
 	if timeout < 60*time.Second {
 		timeout = 60 * time.Second // Minimum 60 seconds for CONNECT requests
 	}
@@ -623,8 +1068,15 @@ func (h *UpstreamProxyHandler) connectViaHTTPProxy(proxy *models.Proxy, host str
 		"timeout", timeout,
 	)
 
-	// Create a TCP connection to the proxy server
-	conn, err := net.DialTimeout("tcp", proxy.Address, timeout)
+	// Create a TCP connection to the proxy server, over TLS for https proxies
+	var conn net.Conn
+	var err error
+	if proxy.Protocol == "https" {
+		conn, err = dialProxyTLS(ctx, proxy, timeout)
+	} else {
+		dialer := &net.Dialer{Timeout: timeout}
+		conn, err = dialer.DialContext(ctx, "tcp", proxy.Address)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxy.Address, err)
 	}
@@ -636,6 +1088,10 @@ func (h *UpstreamProxyHandler) connectViaHTTPProxy(proxy *models.Proxy, host str
 		return nil, fmt.Errorf("failed to set connection deadline: %w", err)
 	}
 
+	// Cancelling ctx interrupts the CONNECT exchange
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
 	// Build CONNECT request
 	// Format: CONNECT host:port HTTP/1.1
 	connectReq := fmt.Sprintf("CONNECT %s HTTP/1.1\r\n", host)
@@ -706,7 +1162,11 @@ func (h *UpstreamProxyHandler) connectViaHTTPProxy(proxy *models.Proxy, host str
 		return nil, fmt.Errorf("CONNECT request failed: %s", statusLine)
 	}
 
-	// Clear the deadline after successful connection
+	// Clear the deadline after successful connection, unless ctx was cancelled meanwhile
+	if !stop() {
+		conn.Close()
+		return nil, fmt.Errorf("CONNECT request cancelled: %w", ctx.Err())
+	}
 	if err := conn.SetDeadline(time.Time{}); err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to clear connection deadline: %w", err)
@@ -722,6 +1182,60 @@ func (h *UpstreamProxyHandler) connectViaHTTPProxy(proxy *models.Proxy, host str
 	return conn, nil
 }
 
+// recordRequest queues a request for the usage writer
+func (h *UpstreamProxyHandler) recordRequest(record RequestRecord) {
+	h.tracker.Record(record)
+}
+
+// observeUpstream records the latency of an upstream request or tunnel that started at start
+func observeUpstream(kind string, start time.Time, err error) {
+	result := metrics.ResultSuccess
+	if err != nil {
+		result = metrics.ResultFailure
+	}
+	metrics.UpstreamLatency.WithLabelValues(kind, result).Observe(time.Since(start).Seconds())
+}
+
+// selectionErrorReason returns the selector_errors_total reason for a proxy selection error
+func selectionErrorReason(err error) string {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrNoTunnelProxy):
+		return "no_tunnel_proxy"
+	case errors.Is(err, ErrProxyBlocked):
+		return "blocked"
+	case errors.Is(err, ErrUnknownPool):
+		return "unknown_pool"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "no_proxy_available"
+	}
+}
+
+// countingBody counts bytes read from a response body and reports the total on Close
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	once    sync.Once
+	onClose func(n int64)
+}
+
+// Read reads from the underlying body and counts the bytes
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// Close closes the underlying body and reports the byte count once
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.onClose(b.n) })
+	return err
+}
+
 // min returns the minimum of two integers
 func min(a, b int) int {
 	if a < b {
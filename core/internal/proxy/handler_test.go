@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// newTestUpstream starts an HTTP proxy answering every request with its name
+// Requests block until release is closed, if set.
+func newTestUpstream(t *testing.T, id int, name string, release <-chan struct{}) *models.Proxy {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if release != nil {
+			<-release
+		}
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(srv.Close)
+
+	return &models.Proxy{ID: id, Address: strings.TrimPrefix(srv.URL, "http://"), Protocol: "http", Status: "active"}
+}
+
+// newTestSelector returns a round-robin selector over a fixed proxy list
+func newTestSelector(settings *models.RotationSettings, proxies ...*models.Proxy) *RoundRobinSelector {
+	s := NewRoundRobinSelector(nil, settings)
+	s.proxies = proxies
+	return s
+}
+
+// sendTestRequest sends a request through the handler and returns the upstream's name
+func sendTestRequest(t *testing.T, h *UpstreamProxyHandler) string {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	resp, _, err := h.sendWithRetry(req, context.Background())
+	if err != nil {
+		t.Errorf("sendWithRetry: %v", err)
+		return ""
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("read body: %v", err)
+	}
+	return string(body)
+}
+
+// TestUpstreamProxyHandlerUpdateConfig tests that requests in flight finish on
+// the selector they started with and new requests use the new one
+func TestUpstreamProxyHandlerUpdateConfig(t *testing.T) {
+	release := make(chan struct{})
+	settings := &models.RotationSettings{Retries: 1, Timeout: 5}
+	selectorA := newTestSelector(settings, newTestUpstream(t, 1, "a", release))
+	selectorB := newTestSelector(settings, newTestUpstream(t, 2, "b", nil))
+
+	h := NewUpstreamProxyHandler(selectorA, nil, NewSessionManager(0), NewCircuitBreaker(models.CircuitBreakerSettings{}),
+		NewBlockList(), NewConnectionTracker(), NewTransportCache(), nil, settings, logger.New("error"))
+
+	inFlight := make(chan string)
+	go func() { inFlight <- sendTestRequest(t, h) }()
+
+	// Swap once the request holds proxy a
+	for h.conns.InFlight(1) == 0 {
+		runtime.Gosched()
+	}
+	h.UpdateConfig(selectorB, settings)
+
+	if got := sendTestRequest(t, h); got != "b" {
+		t.Errorf("request after reload served by %q, want b", got)
+	}
+	close(release)
+	if got := <-inFlight; got != "a" {
+		t.Errorf("request in flight served by %q, want a", got)
+	}
+	if h.Selector() != ProxySelector(selectorB) {
+		t.Error("Selector() doesn't return the new selector")
+	}
+}
+
+// TestUpstreamProxyHandlerReloadRace swaps the configuration under concurrent
+// traffic; run with -race
+func TestUpstreamProxyHandlerReloadRace(t *testing.T) {
+	settingsA := &models.RotationSettings{Retries: 1, Timeout: 5}
+	settingsB := &models.RotationSettings{Retries: 2, Timeout: 10, Fallback: true, FallbackMaxRetries: 2}
+	selectorA := newTestSelector(settingsA, newTestUpstream(t, 1, "a", nil))
+	selectorB := newTestSelector(settingsB, newTestUpstream(t, 2, "b", nil))
+
+	h := NewUpstreamProxyHandler(selectorA, nil, NewSessionManager(0), NewCircuitBreaker(models.CircuitBreakerSettings{}),
+		NewBlockList(), NewConnectionTracker(), NewTransportCache(), nil, settingsA, logger.New("error"))
+
+	done := make(chan struct{})
+	var reloader sync.WaitGroup
+	reloader.Add(1)
+	go func() {
+		defer reloader.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				h.UpdateConfig(selectorB, settingsB)
+			} else {
+				h.UpdateConfig(selectorA, settingsA)
+			}
+		}
+	}()
+
+	var workers sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := 0; i < 25; i++ {
+				if got := sendTestRequest(t, h); got != "a" && got != "b" {
+					t.Errorf("unexpected upstream %q", got)
+				}
+			}
+		}()
+	}
+
+	workers.Wait()
+	close(done)
+	reloader.Wait()
+}
+
+// TestConnectViaProxyCancel tests that cancelling the context interrupts a
+// CONNECT dial through a proxy that never answers, for every protocol
+func TestConnectViaProxyCancel(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	// Accept connections and hold them open without a reply
+	var held []net.Conn
+	var mu sync.Mutex
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			held = append(held, conn)
+			mu.Unlock()
+		}
+	}()
+	defer func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range held {
+			conn.Close()
+		}
+	}()
+
+	settings := &models.RotationSettings{Retries: 1, Timeout: 30}
+	h := NewUpstreamProxyHandler(newTestSelector(settings), nil, NewSessionManager(0), NewCircuitBreaker(models.CircuitBreakerSettings{}),
+		NewBlockList(), NewConnectionTracker(), NewTransportCache(), nil, settings, logger.New("error"))
+
+	for _, protocol := range []string{"http", "https", "socks4", "socks5"} {
+		p := &models.Proxy{ID: 1, Address: ln.Addr().String(), Protocol: protocol}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		conn, err := h.connectViaProxy(ctx, settings, p, "10.1.2.3:443")
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s: connectViaProxy succeeded through a silent proxy", protocol)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: connectViaProxy returned %v after cancellation", protocol, elapsed)
+		}
+		cancel()
+	}
+}
+
+// TestConnectDialContext tests that the CONNECT dial context is cancelled on shutdown
+func TestConnectDialContext(t *testing.T) {
+	stop := make(chan struct{})
+	req := httptest.NewRequest(http.MethodConnect, "http://example.com:443", nil)
+
+	ctx, cancel := connectDialContext(req, stop)
+	defer cancel()
+	if ctx.Err() != nil {
+		t.Fatal("dial context cancelled before shutdown")
+	}
+
+	close(stop)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("dial context not cancelled on shutdown")
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
 	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/repository"
 	"github.com/alpkeskin/rota/core/pkg/logger"
@@ -20,8 +23,9 @@ type HealthChecker struct {
 	proxyRepo    *repository.ProxyRepository
 	settingsRepo *repository.SettingsRepository
 	tracker      *UsageTracker
+	geo          *GeoIP
 	logger       *logger.Logger
-	settings     *models.HealthCheckSettings
+	settings     atomic.Pointer[models.HealthCheckSettings] // loaded on first use, replaced by UpdateSettings
 }
 
 // NewHealthChecker creates a new health checker
@@ -29,12 +33,14 @@ func NewHealthChecker(
 	proxyRepo *repository.ProxyRepository,
 	settingsRepo *repository.SettingsRepository,
 	tracker *UsageTracker,
+	geo *GeoIP,
 	log *logger.Logger,
 ) *HealthChecker {
 	return &HealthChecker{
 		proxyRepo:    proxyRepo,
 		settingsRepo: settingsRepo,
 		tracker:      tracker,
+		geo:          geo,
 		logger:       log,
 	}
 }
@@ -43,13 +49,9 @@ func NewHealthChecker(
 func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*models.ProxyTestResult, error) {
 	startTime := time.Now()
 
-	// Load settings if not cached
-	if h.settings == nil {
-		settings, err := h.settingsRepo.GetAll(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load settings: %w", err)
-		}
-		h.settings = &settings.HealthCheck
+	settings, err := h.currentSettings(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	result := &models.ProxyTestResult{
@@ -61,6 +63,7 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 	// Create HTTP client with proxy
 	transport, err := h.createTransport(proxy)
 	if err != nil {
+		metrics.HealthChecks.WithLabelValues("error").Inc()
 		result.Status = "failed"
 		errMsg := fmt.Sprintf("failed to create transport: %v", err)
 		result.Error = &errMsg
@@ -83,12 +86,13 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   time.Duration(h.settings.Timeout) * time.Second,
+		Timeout:   time.Duration(settings.Timeout) * time.Second,
 	}
 
 	// Create request
-	req, err := http.NewRequestWithContext(ctx, "GET", h.settings.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", settings.URL, nil)
 	if err != nil {
+		metrics.HealthChecks.WithLabelValues("error").Inc()
 		result.Status = "failed"
 		errMsg := fmt.Sprintf("failed to create request: %v", err)
 		result.Error = &errMsg
@@ -96,7 +100,7 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 	}
 
 	// Add custom headers
-	for _, header := range h.settings.Headers {
+	for _, header := range settings.Headers {
 		parts := strings.SplitN(header, ":", 2)
 		if len(parts) == 2 {
 			key := strings.TrimSpace(parts[0])
@@ -106,10 +110,13 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 	}
 
 	// Send request
+	sent := time.Now()
 	resp, err := client.Do(req)
 	duration := int(time.Since(startTime).Milliseconds())
+	metrics.HealthCheckLatency.Observe(time.Since(sent).Seconds())
 
 	if err != nil {
+		metrics.HealthChecks.WithLabelValues("error").Inc()
 		result.Status = "failed"
 		errMsg := err.Error()
 
@@ -117,7 +124,7 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 		if strings.Contains(errMsg, "x509:") || strings.Contains(errMsg, "tls:") {
 			errMsg = fmt.Sprintf("TLS/SSL error: %s (Note: Certificate verification is disabled, but proxy may have issues)", err.Error())
 		} else if strings.Contains(errMsg, "timeout") {
-			errMsg = fmt.Sprintf("Connection timeout after %ds", h.settings.Timeout)
+			errMsg = fmt.Sprintf("Connection timeout after %ds", settings.Timeout)
 		} else if strings.Contains(errMsg, "connection refused") {
 			errMsg = "Connection refused - proxy may be offline"
 		}
@@ -136,9 +143,10 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 	defer resp.Body.Close()
 
 	// Check status code
-	if resp.StatusCode != h.settings.Status {
+	if resp.StatusCode != settings.Status {
+		metrics.HealthChecks.WithLabelValues("bad_status").Inc()
 		result.Status = "failed"
-		errMsg := fmt.Sprintf("unexpected status code: got %d, expected %d", resp.StatusCode, h.settings.Status)
+		errMsg := fmt.Sprintf("unexpected status code: got %d, expected %d", resp.StatusCode, settings.Status)
 		result.Error = &errMsg
 
 		// Record health check failure
@@ -152,9 +160,15 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 	}
 
 	// Success!
+	metrics.HealthChecks.WithLabelValues("passed").Inc()
 	result.Status = "active"
 	result.ResponseTime = &duration
 
+	// Discover the exit IP and its location
+	if settings.ExitIPURL != "" {
+		h.discoverExitIP(ctx, settings, client, resp, proxy, result)
+	}
+
 	// Record health check success
 	go func() {
 		recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -165,6 +179,72 @@ func (h *HealthChecker) CheckProxy(ctx context.Context, proxy *models.Proxy) (*m
 	return result, nil
 }
 
+// discoverExitIP records the proxy's egress IP reported by the exit IP echo endpoint
+// and its GeoIP location. The health check response is reused when the health check
+// URL is the echo endpoint. Failures are logged and don't fail the health check.
+func (h *HealthChecker) discoverExitIP(ctx context.Context, settings *models.HealthCheckSettings, client *http.Client, checkResp *http.Response, proxy *models.Proxy, result *models.ProxyTestResult) {
+	resp := checkResp
+	if settings.ExitIPURL != settings.URL {
+		req, err := http.NewRequestWithContext(ctx, "GET", settings.ExitIPURL, nil)
+		if err != nil {
+			h.logger.Warn("invalid exit IP URL", "url", settings.ExitIPURL, "error", err)
+			return
+		}
+		resp, err = client.Do(req)
+		if err != nil {
+			h.logger.Warn("exit IP discovery failed", "proxy_id", proxy.ID, "error", err)
+			return
+		}
+		defer resp.Body.Close()
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		h.logger.Warn("exit IP discovery failed", "proxy_id", proxy.ID, "error", err)
+		return
+	}
+
+	ip := parseExitIP(body)
+	if ip == nil {
+		h.logger.Warn("exit IP endpoint returned no IP address", "proxy_id", proxy.ID, "url", settings.ExitIPURL)
+		return
+	}
+
+	exitIP := ip.String()
+	geo := h.geo.Lookup(ip)
+	result.ExitIP = &exitIP
+	if geo.Country != "" {
+		result.Country = &geo.Country
+	}
+
+	go func() {
+		recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.proxyRepo.UpdateGeo(recordCtx, proxy.ID, exitIP, geo); err != nil {
+			h.logger.Error("failed to record proxy exit IP", "proxy_id", proxy.ID, "error", err)
+		}
+	}()
+}
+
+// UpdateSettings replaces the health check settings used by subsequent checks
+func (h *HealthChecker) UpdateSettings(settings models.HealthCheckSettings) {
+	h.settings.Store(&settings)
+}
+
+// currentSettings returns the health check settings, loading them on first use
+func (h *HealthChecker) currentSettings(ctx context.Context) (*models.HealthCheckSettings, error) {
+	if settings := h.settings.Load(); settings != nil {
+		return settings, nil
+	}
+
+	settings, err := h.settingsRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	h.UpdateSettings(settings.HealthCheck)
+	return &settings.HealthCheck, nil
+}
+
 // CheckAllProxies tests all proxies concurrently
 func (h *HealthChecker) CheckAllProxies(ctx context.Context) ([]models.ProxyTestResult, error) {
 	// Load settings
@@ -172,19 +252,57 @@ func (h *HealthChecker) CheckAllProxies(ctx context.Context) ([]models.ProxyTest
 	if err != nil {
 		return nil, fmt.Errorf("failed to load settings: %w", err)
 	}
-	h.settings = &settings.HealthCheck
+	h.UpdateSettings(settings.HealthCheck)
 
 	// Get all proxies (including failed ones for re-testing)
-	query := `
+	proxies, err := h.loadProxies(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return h.checkProxies(ctx, &settings.HealthCheck, proxies), nil
+}
+
+// CheckDueProxies tests active and idle proxies, plus failed proxies whose last
+// check is older than healthcheck.retest_failed_after_minutes (0 never retests them)
+// It returns the proxies that were checked alongside their results.
+func (h *HealthChecker) CheckDueProxies(ctx context.Context) ([]*models.Proxy, []models.ProxyTestResult, error) {
+	settings, err := h.settingsRepo.GetAll(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+	h.UpdateSettings(settings.HealthCheck)
+
+	where := "WHERE status IN ('active', 'idle')"
+	var args []interface{}
+	if retest := settings.HealthCheck.RetestFailedAfterMinutes; retest > 0 {
+		where = `WHERE status IN ('active', 'idle')
+			OR (status = 'failed' AND (last_check IS NULL OR last_check <= NOW() - make_interval(mins => $1)))`
+		args = append(args, retest)
+	}
+
+	proxies, err := h.loadProxies(ctx, where, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return proxies, h.checkProxies(ctx, &settings.HealthCheck, proxies), nil
+}
+
+// loadProxies loads proxies for health checking, filtered by an optional WHERE clause
+func (h *HealthChecker) loadProxies(ctx context.Context, where string, args ...interface{}) ([]*models.Proxy, error) {
+	query := fmt.Sprintf(`
 		SELECT
 			id, address, protocol, username, password, status,
 			requests, successful_requests, failed_requests,
-			avg_response_time, last_check, last_error, created_at, updated_at
+			avg_response_time, last_check, last_error, tls_server_name, ca_bundle,
+			created_at, updated_at
 		FROM proxies
+		%s
 		ORDER BY address
-	`
+	`, where)
 
-	rows, err := h.proxyRepo.GetDB().Pool.Query(ctx, query)
+	rows, err := h.proxyRepo.GetDB().Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get proxies: %w", err)
 	}
@@ -196,7 +314,8 @@ func (h *HealthChecker) CheckAllProxies(ctx context.Context) ([]models.ProxyTest
 		err := rows.Scan(
 			&p.ID, &p.Address, &p.Protocol, &p.Username, &p.Password, &p.Status,
 			&p.Requests, &p.SuccessfulRequests, &p.FailedRequests,
-			&p.AvgResponseTime, &p.LastCheck, &p.LastError, &p.CreatedAt, &p.UpdatedAt,
+			&p.AvgResponseTime, &p.LastCheck, &p.LastError, &p.TLSServerName, &p.CABundle,
+			&p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan proxy: %w", err)
@@ -204,14 +323,19 @@ func (h *HealthChecker) CheckAllProxies(ctx context.Context) ([]models.ProxyTest
 		proxies = append(proxies, &p)
 	}
 
+	return proxies, nil
+}
+
+// checkProxies tests the given proxies concurrently using the configured worker count
+func (h *HealthChecker) checkProxies(ctx context.Context, settings *models.HealthCheckSettings, proxies []*models.Proxy) []models.ProxyTestResult {
 	if len(proxies) == 0 {
-		return []models.ProxyTestResult{}, nil
+		return []models.ProxyTestResult{}
 	}
 
-	h.logger.Info("starting health check", "proxy_count", len(proxies), "workers", h.settings.Workers)
+	h.logger.Info("starting health check", "proxy_count", len(proxies), "workers", settings.Workers)
 
 	// Create worker pool
-	wp := workerpool.New(h.settings.Workers)
+	wp := workerpool.New(settings.Workers)
 	results := make([]models.ProxyTestResult, len(proxies))
 
 	// Submit jobs
@@ -245,7 +369,7 @@ func (h *HealthChecker) CheckAllProxies(ctx context.Context) ([]models.ProxyTest
 
 	h.logger.Info("health check completed", "proxy_count", len(proxies))
 
-	return results, nil
+	return results
 }
 
 // createTransport creates an HTTP transport for the proxy
@@ -253,25 +377,3 @@ func (h *HealthChecker) createTransport(p *models.Proxy) (*http.Transport, error
 	// Use shared transport creation utility
 	return CreateProxyTransport(p)
 }
-
-// StartPeriodicHealthCheck starts a background health check routine
-func (h *HealthChecker) StartPeriodicHealthCheck(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	h.logger.Info("starting periodic health check", "interval", interval)
-
-	for {
-		select {
-		case <-ticker.C:
-			h.logger.Info("running periodic health check")
-			_, err := h.CheckAllProxies(ctx)
-			if err != nil {
-				h.logger.Error("periodic health check failed", "error", err)
-			}
-		case <-ctx.Done():
-			h.logger.Info("stopping periodic health check")
-			return
-		}
-	}
-}
@@ -0,0 +1,158 @@
+package proxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// HealthScheduler runs health checks every healthcheck.interval_minutes
+// The interval is re-read from settings after every run and on Reload,
+// so changes apply without a restart
+type HealthScheduler struct {
+	checker      *HealthChecker
+	settingsRepo *repository.SettingsRepository
+	runRepo      *repository.HealthCheckRepository
+	logger       *logger.Logger
+	reloadChan   chan struct{}
+	stopChan     chan struct{}
+}
+
+// NewHealthScheduler creates a new health check scheduler
+func NewHealthScheduler(
+	checker *HealthChecker,
+	settingsRepo *repository.SettingsRepository,
+	runRepo *repository.HealthCheckRepository,
+	log *logger.Logger,
+) *HealthScheduler {
+	return &HealthScheduler{
+		checker:      checker,
+		settingsRepo: settingsRepo,
+		runRepo:      runRepo,
+		logger:       log,
+		reloadChan:   make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start starts the scheduler in the background
+func (s *HealthScheduler) Start() {
+	go s.worker()
+}
+
+// Stop stops the scheduler; a run in progress is cancelled
+func (s *HealthScheduler) Stop() {
+	close(s.stopChan)
+}
+
+// Reload re-reads the interval from settings and restarts the wait
+func (s *HealthScheduler) Reload() {
+	select {
+	case s.reloadChan <- struct{}{}:
+	default:
+	}
+}
+
+// UpdateSettings applies new health check settings to the checker and restarts
+// the wait with the new interval
+func (s *HealthScheduler) UpdateSettings(settings models.HealthCheckSettings) {
+	s.checker.UpdateSettings(settings)
+	s.Reload()
+}
+
+// worker waits for the configured interval and runs checks until stopped
+func (s *HealthScheduler) worker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-s.stopChan
+		cancel()
+	}()
+
+	for {
+		interval := s.interval(ctx)
+
+		// A zero interval disables scheduled checks until the next reload
+		var timer *time.Timer
+		var tick <-chan time.Time
+		if interval > 0 {
+			s.logger.Info("next scheduled health check", "interval", interval)
+			timer = time.NewTimer(interval)
+			tick = timer.C
+		} else {
+			s.logger.Info("scheduled health checks are disabled")
+		}
+
+		select {
+		case <-tick:
+			s.runOnce(ctx)
+		case <-s.reloadChan:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			s.logger.Info("health check scheduler stopped")
+			return
+		}
+	}
+}
+
+// interval returns the configured check interval
+func (s *HealthScheduler) interval(ctx context.Context) time.Duration {
+	settings, err := s.settingsRepo.GetAll(ctx)
+	if err != nil {
+		s.logger.Error("failed to load health check settings, retrying in 1 minute", "error", err)
+		return time.Minute
+	}
+	return time.Duration(settings.HealthCheck.IntervalMinutes) * time.Minute
+}
+
+// runOnce checks all due proxies and persists the run summary
+func (s *HealthScheduler) runOnce(ctx context.Context) {
+	s.logger.Info("running scheduled health check")
+
+	run := &models.HealthCheckRun{StartedAt: time.Now()}
+	proxies, results, err := s.checker.CheckDueProxies(ctx)
+	run.FinishedAt = time.Now()
+
+	if err != nil {
+		s.logger.Error("scheduled health check failed", "error", err)
+		errMsg := err.Error()
+		run.Error = &errMsg
+	}
+
+	for i, result := range results {
+		run.Checked++
+		if result.Status == "active" {
+			run.Healthy++
+			if proxies[i].Status == "failed" {
+				run.Resurrected++
+			}
+		} else {
+			run.Failed++
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err := s.runRepo.CreateRun(ctx, run); err != nil {
+		s.logger.Error("failed to record health check run", "error", err)
+		return
+	}
+
+	s.logger.Info("scheduled health check completed",
+		"checked", run.Checked,
+		"healthy", run.Healthy,
+		"failed", run.Failed,
+		"resurrected", run.Resurrected,
+		"duration_ms", run.FinishedAt.Sub(run.StartedAt).Milliseconds(),
+	)
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/metrics"
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestSelectionErrorReason tests the reasons selection errors are counted under
+func TestSelectionErrorReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{ErrCircuitOpen, "circuit_open"},
+		{fmt.Errorf("%w: residential", ErrUnknownPool), "unknown_pool"},
+		{ErrProxyBlocked, "blocked"},
+		{ErrNoTunnelProxy, "no_tunnel_proxy"},
+		{context.Canceled, "canceled"},
+		{errors.New("no proxies available"), "no_proxy_available"},
+	}
+
+	for _, tt := range tests {
+		if got := selectionErrorReason(tt.err); got != tt.want {
+			t.Errorf("selectionErrorReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestMethodLabel tests that only standard methods become label values
+func TestMethodLabel(t *testing.T) {
+	tests := map[string]string{
+		"GET":     "GET",
+		"CONNECT": "CONNECT",
+		"PATCH":   "PATCH",
+		"get":     "other",
+		"FOO123":  "other",
+		"":        "other",
+	}
+
+	for method, want := range tests {
+		if got := metrics.MethodLabel(method); got != want {
+			t.Errorf("MethodLabel(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
+
+// TestMiddlewareMetrics tests that rejected credentials and rate limited requests are counted
+func TestMiddlewareMetrics(t *testing.T) {
+	auth := NewAuthMiddleware(models.AuthenticationSettings{Enabled: true, Username: "user", Password: "pass"}, NewClientRegistry(nil, nil, nil))
+	failures := metrics.AuthFailures.WithLabelValues("proxy", "missing_credentials")
+	before := testutil.ToFloat64(failures)
+
+	if _, resp := auth.HandleRequest(httptest.NewRequest("GET", "http://example.com/", nil), nil); resp == nil {
+		t.Fatal("request without credentials was accepted")
+	}
+	if got := testutil.ToFloat64(failures) - before; got != 1 {
+		t.Errorf("auth failures increased by %v, want 1", got)
+	}
+
+	limit := NewRateLimitMiddleware(models.RateLimitSettings{Enabled: true, Interval: 60, MaxRequests: 1})
+	rejections := metrics.RateLimitRejections.WithLabelValues("ip")
+	before = testutil.ToFloat64(rejections)
+
+	for i := 0; i < 3; i++ {
+		limit.HandleRequest(httptest.NewRequest("GET", "http://example.com/", nil), nil)
+	}
+	if got := testutil.ToFloat64(rejections) - before; got != 2 {
+		t.Errorf("rate limit rejections increased by %v, want 2", got)
+	}
+}
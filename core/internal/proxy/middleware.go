@@ -2,29 +2,35 @@ package proxy
 
 import (
 	"encoding/base64"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 	"sync"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
 	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/elazarl/goproxy"
 	"golang.org/x/time/rate"
 )
 
 // AuthMiddleware handles proxy authentication
+// Accepts the shared credential from settings or any enabled per-client credential
 type AuthMiddleware struct {
 	enabled  bool
 	username string
 	password string
+	clients  *ClientRegistry
 	mu       sync.RWMutex
 }
 
 // NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(settings models.AuthenticationSettings) *AuthMiddleware {
+func NewAuthMiddleware(settings models.AuthenticationSettings, clients *ClientRegistry) *AuthMiddleware {
 	return &AuthMiddleware{
 		enabled:  settings.Enabled,
 		username: settings.Username,
 		password: settings.Password,
+		clients:  clients,
 	}
 }
 
@@ -38,49 +44,94 @@ func (m *AuthMiddleware) UpdateSettings(settings models.AuthenticationSettings)
 	m.password = settings.Password
 }
 
+// Required reports whether clients must authenticate
+func (m *AuthMiddleware) Required() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.enabled
+}
+
 // HandleRequest validates proxy authentication for HTTP requests
 func (m *AuthMiddleware) HandleRequest(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if !m.enabled {
+	return m.authenticate(req, m.enabled)
+}
+
+// HandleRouteRequest validates proxy authentication for reverse-proxy route requests
+// Credentials are checked when the route requires them, regardless of the global setting.
+func (m *AuthMiddleware) HandleRouteRequest(req *http.Request, required bool) (*http.Request, *http.Response) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.authenticate(req, required)
+}
+
+// authenticate attaches routing options and the authenticated client to the request
+// The caller must hold m.mu.
+func (m *AuthMiddleware) authenticate(req *http.Request, required bool) (*http.Request, *http.Response) {
+	if !required {
+		// Routing parameters in the username and headers still apply without authentication
+		username, _, _ := parseProxyAuth(req.Header.Get("Proxy-Authorization"))
+		req = req.WithContext(WithRouteOptions(req.Context(), routeOptions(req, username)))
 		return req, nil
 	}
 
 	// Check Proxy-Authorization header
 	proxyAuth := req.Header.Get("Proxy-Authorization")
 	if proxyAuth == "" {
+		metrics.AuthFailures.WithLabelValues("proxy", "missing_credentials").Inc()
 		return req, m.unauthorized()
 	}
 
 	// Parse Basic authentication
-	if !strings.HasPrefix(proxyAuth, "Basic ") {
+	username, password, ok := parseProxyAuth(proxyAuth)
+	if !ok {
+		metrics.AuthFailures.WithLabelValues("proxy", "malformed_credentials").Inc()
 		return req, m.unauthorized()
 	}
 
+	// Routing parameters (e.g. "-session-<id>") are not part of the credential
+	opts := routeOptions(req, username)
+
+	// Validate credentials: shared credential first, then per-client credentials
+	reqCtx := WithRouteOptions(req.Context(), opts)
+	if m.username == "" || opts.Username != m.username || password != m.password {
+		client := m.clients.Authenticate(opts.Username, password)
+		if client == nil {
+			metrics.AuthFailures.WithLabelValues("proxy", "invalid_credentials").Inc()
+			return req, m.unauthorized()
+		}
+		reqCtx = WithClient(reqCtx, client)
+	}
+	req = req.WithContext(reqCtx)
+
+	// Authentication successful, remove the header before forwarding
+	req.Header.Del("Proxy-Authorization")
+	return req, nil
+}
+
+// parseProxyAuth decodes a Basic Proxy-Authorization header into username and password
+func parseProxyAuth(proxyAuth string) (string, string, bool) {
+	if !strings.HasPrefix(proxyAuth, "Basic ") {
+		return "", "", false
+	}
+
 	encoded := strings.TrimPrefix(proxyAuth, "Basic ")
 	decoded, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		return req, m.unauthorized()
+		return "", "", false
 	}
 
 	// Split username:password
 	credentials := strings.SplitN(string(decoded), ":", 2)
 	if len(credentials) != 2 {
-		return req, m.unauthorized()
+		return "", "", false
 	}
 
-	username := credentials[0]
-	password := credentials[1]
-
-	// Validate credentials
-	if username != m.username || password != m.password {
-		return req, m.unauthorized()
-	}
-
-	// Authentication successful, remove the header before forwarding
-	req.Header.Del("Proxy-Authorization")
-	return req, nil
+	return credentials[0], credentials[1], true
 }
 
 // HandleConnect validates proxy authentication for HTTPS CONNECT requests
@@ -133,17 +184,25 @@ func (m *RateLimitMiddleware) UpdateSettings(settings models.RateLimitSettings)
 }
 
 // HandleRequest validates rate limits for HTTP requests
+// Per-client limits and quotas apply even when the global per-IP limit is disabled
 func (m *RateLimitMiddleware) HandleRequest(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
-	if !m.enabled {
-		return req, nil
+	if !m.allowIP(req) {
+		metrics.RateLimitRejections.WithLabelValues("ip").Inc()
+		return req, m.tooManyRequests()
 	}
 
-	// Get client IP
-	clientIP := m.getClientIP(req)
-
-	// Check rate limit
-	if !m.allow(clientIP) {
-		return req, m.tooManyRequests()
+	if client := ClientFromContext(req.Context()); client != nil {
+		if err := client.Admit(); err != nil {
+			reason := "client_rate"
+			if errors.Is(err, ErrClientQuotaExceeded) {
+				reason = "client_quota"
+			}
+			metrics.RateLimitRejections.WithLabelValues(reason).Inc()
+			resp := m.tooManyRequests()
+			resp.Header.Set("Content-Type", "text/plain")
+			resp.Body = io.NopCloser(strings.NewReader(err.Error()))
+			return req, resp
+		}
 	}
 
 	return req, nil
@@ -154,6 +213,18 @@ func (m *RateLimitMiddleware) HandleConnect(req *http.Request, ctx *goproxy.Prox
 	return m.HandleRequest(req, ctx)
 }
 
+// allowIP applies the per-IP limit, if enabled, to the request's client IP
+func (m *RateLimitMiddleware) allowIP(req *http.Request) bool {
+	m.mu.RLock()
+	enabled := m.enabled
+	m.mu.RUnlock()
+	if !enabled {
+		return true
+	}
+
+	return m.allow(m.getClientIP(req))
+}
+
 // allow checks if the request is allowed based on rate limiting
 func (m *RateLimitMiddleware) allow(clientIP string) bool {
 	m.mu.Lock()
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// PoolHeader lets clients pick a proxy pool per request instead of using
+// "-pool-<name>" in the proxy username. It is stripped before forwarding.
+const PoolHeader = "X-Rota-Pool"
+
+// RouteOptions are per-request routing hints parsed from the proxy username
+// Clients append them to their username, e.g. "alice-pool-residential-session-abc123"
+type RouteOptions struct {
+	Username  string // Username without routing parameters
+	SessionID string // Sticky session id ("-session-<id>")
+	Pool      string // Proxy pool name ("-pool-<name>" or the X-Rota-Pool header)
+	Country   string // Exit country ISO code ("-country-<code>"), upper-cased
+	ASN       int    // Exit autonomous system number ("-asn-<number>")
+	Route     string // Reverse-proxy route name, set for requests served through a route
+}
+
+// usernameParams lists the recognised "-<key>-<value>" username parameters
+var usernameParams = map[string]bool{
+	"session": true,
+	"pool":    true,
+	"country": true,
+	"asn":     true,
+}
+
+// parseUsername splits a proxy username into the account name and routing options
+// Usernames that don't end in well-formed parameters are returned unchanged
+func parseUsername(username string) RouteOptions {
+	opts := RouteOptions{Username: username}
+
+	parts := strings.Split(username, "-")
+	start := -1
+	for i := 1; i < len(parts); i++ {
+		if usernameParams[parts[i]] {
+			start = i
+			break
+		}
+	}
+	if start == -1 || (len(parts)-start)%2 != 0 {
+		return opts
+	}
+
+	params := RouteOptions{Username: strings.Join(parts[:start], "-")}
+	for i := start; i < len(parts); i += 2 {
+		key, value := parts[i], parts[i+1]
+		if value == "" {
+			return opts
+		}
+		switch key {
+		case "session":
+			params.SessionID = value
+		case "pool":
+			params.Pool = value
+		case "country":
+			if len(value) != 2 {
+				return opts
+			}
+			params.Country = strings.ToUpper(value)
+		case "asn":
+			asn, err := strconv.Atoi(value)
+			if err != nil || asn <= 0 {
+				return opts
+			}
+			params.ASN = asn
+		default:
+			return opts
+		}
+	}
+
+	return params
+}
+
+// routeOptions returns the routing options for a request
+// The X-Rota-Pool header takes precedence over the username parameter
+func routeOptions(req *http.Request, username string) RouteOptions {
+	opts := parseUsername(username)
+	if pool := strings.TrimSpace(req.Header.Get(PoolHeader)); pool != "" {
+		opts.Pool = pool
+	}
+	req.Header.Del(PoolHeader)
+	return opts
+}
+
+// geoFilter returns the exit location constraint requested by the client
+func (o RouteOptions) geoFilter() models.GeoFilter {
+	return models.GeoFilter{Country: o.Country, ASN: o.ASN}
+}
+
+// sessionKey returns the sticky session key, scoped per account, or "" if none
+func (o RouteOptions) sessionKey() string {
+	if o.SessionID == "" {
+		return ""
+	}
+	return o.Username + ":" + o.SessionID
+}
+
+// routeOptionsContextKey stores RouteOptions in a request context
+type routeOptionsContextKey struct{}
+
+// WithRouteOptions returns a copy of ctx carrying the routing options
+func WithRouteOptions(ctx context.Context, opts RouteOptions) context.Context {
+	return context.WithValue(ctx, routeOptionsContextKey{}, opts)
+}
+
+// RouteOptionsFromContext returns the routing options for a request
+func RouteOptionsFromContext(ctx context.Context) RouteOptions {
+	opts, _ := ctx.Value(routeOptionsContextKey{}).(RouteOptions)
+	return opts
+}
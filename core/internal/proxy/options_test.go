@@ -0,0 +1,32 @@
+package proxy
+
+import "testing"
+
+// TestParseUsername tests splitting routing parameters off proxy usernames
+func TestParseUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     RouteOptions
+	}{
+		{"alice", RouteOptions{Username: "alice"}},
+		{"alice-session-abc123", RouteOptions{Username: "alice", SessionID: "abc123"}},
+		{"team-a-session-42", RouteOptions{Username: "team-a", SessionID: "42"}},
+		{"my-scraper", RouteOptions{Username: "my-scraper"}},
+		{"alice-session", RouteOptions{Username: "alice-session"}},
+		{"alice-session-", RouteOptions{Username: "alice-session-"}},
+		{"session-abc", RouteOptions{Username: "session-abc"}},
+		{"alice-pool-residential", RouteOptions{Username: "alice", Pool: "residential"}},
+		{"alice-pool-us_east-session-7", RouteOptions{Username: "alice", Pool: "us_east", SessionID: "7"}},
+		{"alice-session-7-pool", RouteOptions{Username: "alice-session-7-pool"}},
+		{"alice-country-de", RouteOptions{Username: "alice", Country: "DE"}},
+		{"alice-pool-residential-country-us-asn-7922", RouteOptions{Username: "alice", Pool: "residential", Country: "US", ASN: 7922}},
+		{"alice-country-germany", RouteOptions{Username: "alice-country-germany"}},
+		{"alice-asn-aws", RouteOptions{Username: "alice-asn-aws"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseUsername(tt.username); got != tt.want {
+			t.Errorf("parseUsername(%q) = %+v, want %+v", tt.username, got, tt.want)
+		}
+	}
+}
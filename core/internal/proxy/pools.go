@@ -0,0 +1,346 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// ErrUnknownPool is returned when a request names a pool that doesn't exist
+var ErrUnknownPool = errors.New("unknown proxy pool")
+
+// constrainedIdleTTL is how long an unused geo-constrained selector is kept
+const constrainedIdleTTL = 10 * time.Minute
+
+// constrainedMaxEntries caps the geo-constrained selectors kept at once; the least
+// recently used one is dropped to make room
+const constrainedMaxEntries = 1000
+
+// filterable is implemented by every selector through BaseSelector
+type filterable interface {
+	setFilter(f proxyFilter)
+	setSource(source func() []*models.Proxy)
+	Proxies() []*models.Proxy
+}
+
+// poolEntry is a pool definition and the selector serving it
+type poolEntry struct {
+	def      models.ProxyPool
+	geo      models.GeoFilter
+	settings *models.RotationSettings // rotation settings the selector was built from
+	selector ProxySelector
+	lastUsed atomic.Int64 // unix nanoseconds, for geo-constrained entries
+}
+
+// touch marks the entry as used now
+func (e *poolEntry) touch() {
+	e.lastUsed.Store(time.Now().UnixNano())
+}
+
+// stale reports whether the entry must be rebuilt for the given definition and settings
+func (e *poolEntry) stale(def models.ProxyPool, settings *models.RotationSettings) bool {
+	return e.settings != settings || e.def.Method != def.Method || !maps.Equal(e.def.Tags, def.Tags)
+}
+
+// withDef returns a copy of the entry for an updated pool definition, sharing its selector
+// Published entries are read without holding the manager's lock, so they are never modified.
+func (e *poolEntry) withDef(def models.ProxyPool) *poolEntry {
+	updated := &poolEntry{def: def, geo: e.geo, settings: e.settings, selector: e.selector}
+	updated.lastUsed.Store(e.lastUsed.Load())
+	return updated
+}
+
+// PoolManager keeps a selector per named proxy pool
+// Each pool selects among proxies carrying all of its tags and may override the rotation method.
+// Requests with a geo constraint (e.g. "-country-de") get selectors created on first use,
+// on top of the named pool or the whole proxy list, filtering that selector's
+// proxies in memory.
+type PoolManager struct {
+	proxyRepo *repository.ProxyRepository
+	poolRepo  *repository.PoolRepository
+	conns     *ConnectionTracker
+	windows   *RateWindows
+	logger    *logger.Logger
+
+	mu              sync.RWMutex
+	defaultSelector func() ProxySelector // selector over the whole proxy list
+	settings        *models.RotationSettings
+	routePools      []models.ProxyPool    // ad hoc pools for routes selecting by tags
+	pools           map[string]*poolEntry // keyed by pool name
+	constrained     map[string]*poolEntry // keyed by pool name and geo filter
+}
+
+// NewPoolManager creates a new pool manager
+func NewPoolManager(
+	proxyRepo *repository.ProxyRepository,
+	poolRepo *repository.PoolRepository,
+	settings *models.RotationSettings,
+	conns *ConnectionTracker,
+	windows *RateWindows,
+	log *logger.Logger,
+) *PoolManager {
+	return &PoolManager{
+		proxyRepo:   proxyRepo,
+		poolRepo:    poolRepo,
+		conns:       conns,
+		windows:     windows,
+		logger:      log,
+		settings:    settings,
+		pools:       make(map[string]*poolEntry),
+		constrained: make(map[string]*poolEntry),
+	}
+}
+
+// SetDefaultSelector sets where geo constraints without a pool get their proxies
+func (m *PoolManager) SetDefaultSelector(selector func() ProxySelector) {
+	m.mu.Lock()
+	m.defaultSelector = selector
+	m.mu.Unlock()
+}
+
+// Refresh reloads pool definitions and refreshes each pool's proxy list
+// Selectors are kept across refreshes unless the pool or rotation settings changed.
+// Geo-constrained selectors unused for constrainedIdleTTL are dropped.
+func (m *PoolManager) Refresh(ctx context.Context) error {
+	defs, err := m.poolRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load proxy pools: %w", err)
+	}
+
+	m.mu.RLock()
+	defs = append(defs, m.routePools...)
+	settings := m.settings
+	current := m.pools
+	currentConstrained := m.constrained
+	m.mu.RUnlock()
+
+	pools := make(map[string]*poolEntry, len(defs))
+	for _, def := range defs {
+		entry, ok := current[def.Name]
+		if !ok || entry.stale(def, settings) {
+			entry, err = newPoolEntry(m.proxyRepo, m.conns, m.windows, settings, def, models.GeoFilter{}, nil)
+			if err != nil {
+				return err
+			}
+		} else {
+			entry = entry.withDef(def)
+		}
+
+		if err := entry.selector.Refresh(ctx); err != nil {
+			m.logger.Warn("proxy pool has no usable proxies", "pool", def.Name, "error", err)
+		}
+		pools[def.Name] = entry
+	}
+
+	// Constrained selectors filter the refreshed pools
+	m.mu.Lock()
+	m.pools = pools
+	m.mu.Unlock()
+
+	idleSince := time.Now().Add(-constrainedIdleTTL).UnixNano()
+	constrained := make(map[string]*poolEntry, len(currentConstrained))
+	for key, entry := range currentConstrained {
+		if entry.lastUsed.Load() < idleSince {
+			continue
+		}
+
+		def := models.ProxyPool{}
+		if entry.def.Name != "" {
+			named, ok := pools[entry.def.Name]
+			if !ok {
+				continue // pool was deleted
+			}
+			def = named.def
+		}
+
+		if entry.stale(def, settings) {
+			rebuilt, err := newPoolEntry(m.proxyRepo, m.conns, m.windows, settings, def, entry.geo, m.parentProxies(def.Name))
+			if err != nil {
+				return err
+			}
+			rebuilt.lastUsed.Store(entry.lastUsed.Load())
+			entry = rebuilt
+		} else {
+			entry = entry.withDef(def)
+		}
+
+		// An empty result is expected for constraints nothing matches yet
+		_ = entry.selector.Refresh(ctx)
+		constrained[key] = entry
+	}
+
+	m.mu.Lock()
+	for key, entry := range m.constrained {
+		if updated, ok := constrained[key]; ok {
+			// Keep uses of the replaced entry during the refresh
+			if used := entry.lastUsed.Load(); used > updated.lastUsed.Load() {
+				updated.lastUsed.Store(used)
+			}
+		} else if _, ok := currentConstrained[key]; !ok {
+			// Keep constrained selectors created while refreshing
+			constrained[key] = entry
+		}
+	}
+	m.constrained = constrained
+	m.mu.Unlock()
+
+	return nil
+}
+
+// UpdateSettings rebuilds every pool selector from new rotation settings
+func (m *PoolManager) UpdateSettings(ctx context.Context, settings *models.RotationSettings) error {
+	m.mu.Lock()
+	m.settings = settings
+	m.mu.Unlock()
+
+	return m.Refresh(ctx)
+}
+
+// SetRoutePools replaces the ad hoc pools used by routes that select proxies by tags
+// and refreshes every pool.
+func (m *PoolManager) SetRoutePools(ctx context.Context, defs []models.ProxyPool) error {
+	m.mu.Lock()
+	m.routePools = defs
+	m.mu.Unlock()
+
+	return m.Refresh(ctx)
+}
+
+// Get returns the selector for the named pool
+func (m *PoolManager) Get(name string) (ProxySelector, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.pools[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPool, name)
+	}
+	return entry.selector, nil
+}
+
+// Constrained returns a selector for the named pool (or all proxies when name is
+// empty) restricted to proxies matching the geo filter
+func (m *PoolManager) Constrained(ctx context.Context, name string, geo models.GeoFilter) (ProxySelector, error) {
+	key := constraintKey(name, geo)
+
+	m.mu.RLock()
+	entry, ok := m.constrained[key]
+	named, namedOK := m.pools[name]
+	settings := m.settings
+	m.mu.RUnlock()
+
+	if ok {
+		entry.touch()
+		return entry.selector, nil
+	}
+
+	def := models.ProxyPool{}
+	if name != "" {
+		if !namedOK {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPool, name)
+		}
+		def = named.def
+	}
+
+	entry, err := newPoolEntry(m.proxyRepo, m.conns, m.windows, settings, def, geo, m.parentProxies(name))
+	if err != nil {
+		return nil, err
+	}
+	entry.touch()
+
+	// Cache the selector even when nothing matches yet; the periodic refresh picks up changes
+	if err := entry.selector.Refresh(ctx); err != nil {
+		m.logger.Debug("no proxies match geo constraint", "pool", name, "country", geo.Country, "asn", geo.ASN, "error", err)
+	}
+
+	m.mu.Lock()
+	if existing, ok := m.constrained[key]; ok {
+		entry = existing
+	} else {
+		if len(m.constrained) >= constrainedMaxEntries {
+			m.evictConstrainedLocked()
+		}
+		m.constrained[key] = entry
+	}
+	m.mu.Unlock()
+
+	return entry.selector, nil
+}
+
+// evictConstrainedLocked drops the least recently used constrained selector
+// m.mu must be held.
+func (m *PoolManager) evictConstrainedLocked() {
+	var oldestKey string
+	var oldest int64
+	for key, entry := range m.constrained {
+		if used := entry.lastUsed.Load(); oldestKey == "" || used < oldest {
+			oldestKey, oldest = key, used
+		}
+	}
+	delete(m.constrained, oldestKey)
+}
+
+// parentProxies returns the current proxies of the named pool, or of the whole
+// proxy list when name is empty, for constrained selectors to filter
+func (m *PoolManager) parentProxies(name string) func() []*models.Proxy {
+	return func() []*models.Proxy {
+		m.mu.RLock()
+		var parent ProxySelector
+		if name == "" {
+			if m.defaultSelector != nil {
+				parent = m.defaultSelector()
+			}
+		} else if entry, ok := m.pools[name]; ok {
+			parent = entry.selector
+		}
+		m.mu.RUnlock()
+
+		if f, ok := parent.(filterable); ok {
+			return f.Proxies()
+		}
+		return nil
+	}
+}
+
+// constraintKey identifies a geo-constrained selector
+func constraintKey(name string, geo models.GeoFilter) string {
+	excluded := slices.Clone(geo.ExcludeASN)
+	slices.Sort(excluded)
+	return fmt.Sprintf("%s|%s|%d|%v", name, geo.Country, geo.ASN, excluded)
+}
+
+// newPoolEntry creates a selector restricted to the pool's tags and the geo filter,
+// using the pool's rotation method when it sets one
+// With a source, the selector filters the source's proxies by geo in memory
+// instead of querying the database.
+func newPoolEntry(repo *repository.ProxyRepository, conns *ConnectionTracker, windows *RateWindows, settings *models.RotationSettings, def models.ProxyPool, geo models.GeoFilter, source func() []*models.Proxy) (*poolEntry, error) {
+	poolSettings := *settings
+	if def.Method != "" {
+		poolSettings.Method = def.Method
+	}
+
+	selector, err := NewProxySelector(repo, &poolSettings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create selector for pool %s: %w", def.Name, err)
+	}
+
+	if f, ok := selector.(filterable); ok {
+		f.setFilter(proxyFilter{Tags: def.Tags, Geo: geo})
+		if source != nil {
+			f.setSource(source)
+		}
+	}
+	shareConnections(selector, conns)
+	shareRateWindows(selector, windows)
+
+	return &poolEntry{def: def, geo: geo, settings: settings, selector: selector}, nil
+}
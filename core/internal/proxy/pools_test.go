@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// newTestPoolManager returns a pool manager whose default selector holds the given proxies
+func newTestPoolManager(proxies ...*models.Proxy) *PoolManager {
+	settings := &models.RotationSettings{Method: "random"}
+	m := NewPoolManager(nil, nil, settings, NewConnectionTracker(), NewRateWindows(), logger.New("error"))
+
+	selector := NewRandomSelector(nil, settings)
+	selector.proxies = proxies
+	m.SetDefaultSelector(func() ProxySelector { return selector })
+	return m
+}
+
+// TestPoolManagerConstrained tests that geo constraints filter the parent's proxies in memory
+func TestPoolManagerConstrained(t *testing.T) {
+	de, us := "DE", "US"
+	asn := 3320
+	m := newTestPoolManager(
+		&models.Proxy{ID: 1, Country: &de, ASN: &asn},
+		&models.Proxy{ID: 2, Country: &us},
+		&models.Proxy{ID: 3, Country: &de},
+	)
+	ctx := context.Background()
+
+	selector, err := m.Constrained(ctx, "", models.GeoFilter{Country: "de", ExcludeASN: []int{16509}})
+	if err != nil {
+		t.Fatalf("Constrained: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		p, err := selector.Select(ctx)
+		if err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		// Proxy 3 has no known ASN, so it can't pass the exclusion
+		if p.ID != 1 {
+			t.Fatalf("Select = proxy %d, want the German proxy with a known ASN", p.ID)
+		}
+	}
+
+	// Constraints nothing matches are served without error until a proxy matches
+	selector, err = m.Constrained(ctx, "", models.GeoFilter{ASN: 1})
+	if err != nil {
+		t.Fatalf("Constrained without matches: %v", err)
+	}
+	if _, err := selector.Select(ctx); err == nil {
+		t.Error("Select succeeded without a matching proxy")
+	}
+	if err := selector.Refresh(ctx); !errors.Is(err, ErrNoProxies) {
+		t.Errorf("Refresh without matches = %v, want ErrNoProxies", err)
+	}
+
+	if _, err := m.Constrained(ctx, "missing", models.GeoFilter{Country: "de"}); err == nil {
+		t.Error("Constrained accepted an unknown pool")
+	}
+}
+
+// TestPoolManagerConstrainedCap tests that the least recently used constraint is dropped at the cap
+func TestPoolManagerConstrainedCap(t *testing.T) {
+	m := newTestPoolManager(&models.Proxy{ID: 1})
+	ctx := context.Background()
+
+	for asn := 1; asn <= constrainedMaxEntries+10; asn++ {
+		if _, err := m.Constrained(ctx, "", models.GeoFilter{ASN: asn}); err != nil {
+			t.Fatalf("Constrained: %v", err)
+		}
+	}
+
+	if got := len(m.constrained); got != constrainedMaxEntries {
+		t.Errorf("kept %d constrained selectors, want %d", got, constrainedMaxEntries)
+	}
+	if _, ok := m.constrained[constraintKey("", models.GeoFilter{ASN: constrainedMaxEntries + 10})]; !ok {
+		t.Error("newest constraint was dropped")
+	}
+}
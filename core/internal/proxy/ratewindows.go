@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// RateWindows holds the sliding window of recent selections per upstream proxy
+// One registry is shared by every rate-limited selector (the default one, pool
+// selectors and geo-constrained ones), so a proxy's limit holds whichever
+// selector picks it.
+type RateWindows struct {
+	mu      sync.Mutex
+	windows map[int]*slidingWindow // keyed by proxy ID
+	window  time.Duration          // window length of the last selection, for Cleanup
+}
+
+// NewRateWindows creates a new rate window registry
+func NewRateWindows() *RateWindows {
+	return &RateWindows{
+		windows: make(map[int]*slidingWindow),
+	}
+}
+
+// take records a selection of the first proxy, starting at start and wrapping
+// around, that has fewer than limit selections within window
+// It returns the proxy's index, or -1 and how long until a slot frees up.
+func (r *RateWindows) take(proxies []*models.Proxy, start, limit int, window time.Duration, now time.Time) (int, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.window = window
+
+	var earliest time.Time
+	for i := range proxies {
+		index := (start + i) % len(proxies)
+		w := r.get(proxies[index].ID, limit)
+
+		availableAt := w.availableAt(window)
+		if !availableAt.After(now) {
+			w.record(now)
+			return index, 0
+		}
+
+		if earliest.IsZero() || availableAt.Before(earliest) {
+			earliest = availableAt
+		}
+	}
+
+	return -1, earliest.Sub(now)
+}
+
+// get returns the proxy's window sized for limit, creating it on first use
+func (r *RateWindows) get(proxyID, limit int) *slidingWindow {
+	w, ok := r.windows[proxyID]
+	if !ok {
+		w = newSlidingWindow(limit)
+		r.windows[proxyID] = w
+	} else if len(w.times) != limit {
+		// The limit changed with the rotation settings
+		w.resize(limit)
+	}
+	return w
+}
+
+// missing returns the proxies that have no window yet
+func (r *RateWindows) missing(proxyIDs []int) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ids []int
+	for _, id := range proxyIDs {
+		if _, ok := r.windows[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// seed adds windows for proxies that have none yet, from loaded windows or empty
+// Windows created meanwhile are kept, since they hold newer selections.
+func (r *RateWindows) seed(proxyIDs []int, loaded map[int]*slidingWindow, limit int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range proxyIDs {
+		if _, ok := r.windows[id]; ok {
+			continue
+		}
+		if w, ok := loaded[id]; ok {
+			r.windows[id] = w
+		} else {
+			r.windows[id] = newSlidingWindow(limit)
+		}
+	}
+}
+
+// Cleanup drops the windows of proxies not selected within the last window
+// Such windows are empty, and are seeded again when a selector loads the proxy.
+func (r *RateWindows) Cleanup() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-r.window)
+	for id, w := range r.windows {
+		if w.newest().Before(cutoff) {
+			delete(r.windows, id)
+		}
+	}
+}
+
+// newest returns the time of the most recent selection, zero if there is none
+func (w *slidingWindow) newest() time.Time {
+	return w.times[(w.next+len(w.times)-1)%len(w.times)]
+}
+
+// resize changes the window to admit limit selections, keeping the most recent ones
+func (w *slidingWindow) resize(limit int) {
+	resized := newSlidingWindow(limit)
+	for i := range w.times {
+		if t := w.times[(w.next+i)%len(w.times)]; !t.IsZero() {
+			resized.record(t)
+		}
+	}
+	*w = *resized
+}
+
+// rateLimitAware is implemented by selectors that enforce per-proxy request limits
+type rateLimitAware interface {
+	setRateWindows(windows *RateWindows)
+}
+
+// shareRateWindows makes the selector count selections in the shared windows, if it limits them
+func shareRateWindows(selector ProxySelector, windows *RateWindows) {
+	if r, ok := selector.(rateLimitAware); ok {
+		r.setRateWindows(windows)
+	}
+}
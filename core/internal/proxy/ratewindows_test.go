@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRateWindowsShared tests that selectors sharing windows enforce one limit per proxy
+func TestRateWindowsShared(t *testing.T) {
+	pool, now := newTestRateLimitedSelector(2, 60, 0, 1)
+	constrained, _ := newTestRateLimitedSelector(2, 60, 0, 1)
+	constrained.now = pool.now
+
+	windows := NewRateWindows()
+	shareRateWindows(pool, windows)
+	shareRateWindows(constrained, windows)
+
+	ctx := context.Background()
+	if _, err := pool.Select(ctx); err != nil {
+		t.Fatalf("pool Select: %v", err)
+	}
+	if _, err := constrained.Select(ctx); err != nil {
+		t.Fatalf("constrained Select: %v", err)
+	}
+	if _, err := constrained.Select(ctx); err == nil {
+		t.Fatal("constrained selector exceeded the proxy's limit")
+	}
+	if _, err := pool.Select(ctx); err == nil {
+		t.Fatal("pool selector exceeded the proxy's limit")
+	}
+
+	*now = now.Add(60 * time.Second)
+	if _, err := constrained.Select(ctx); err != nil {
+		t.Fatalf("Select after the window: %v", err)
+	}
+}
+
+// TestSlidingWindowResize tests that a changed limit keeps the most recent selections
+func TestSlidingWindowResize(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := newSlidingWindow(3)
+	for i := 0; i < 3; i++ {
+		w.record(base.Add(time.Duration(i) * time.Second))
+	}
+
+	w.resize(2)
+	if got := w.availableAt(time.Minute); !got.Equal(base.Add(time.Second + time.Minute)) {
+		t.Errorf("shrunk window available at %v, want the second selection plus a minute", got)
+	}
+	if got := w.newest(); !got.Equal(base.Add(2 * time.Second)) {
+		t.Errorf("newest = %v, want the last selection", got)
+	}
+
+	w.resize(4)
+	if got := w.availableAt(time.Minute); !got.IsZero() {
+		t.Errorf("grown window available at %v, want a free slot", got)
+	}
+}
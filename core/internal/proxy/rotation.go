@@ -3,8 +3,10 @@ package proxy
 import (
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
+	mathrand "math/rand/v2"
 	"sync"
 	"time"
 
@@ -12,10 +14,14 @@ import (
 	"github.com/alpkeskin/rota/core/internal/repository"
 )
 
+// ErrNoProxies is returned by Refresh when no active or idle proxy matches the selector's filters
+var ErrNoProxies = errors.New("no active or idle proxies found matching filters")
+
 // ProxySelector defines the interface for proxy selection strategies
 type ProxySelector interface {
 	Select(ctx context.Context) (*models.Proxy, error)
 	Refresh(ctx context.Context) error
+	Lookup(id int) *models.Proxy
 }
 
 // BaseSelector contains common fields for all selectors
@@ -23,9 +29,17 @@ type BaseSelector struct {
 	repo     *repository.ProxyRepository
 	proxies  []*models.Proxy
 	settings *models.RotationSettings
+	filter   proxyFilter            // restricts loaded proxies (pools, geo constraints)
+	source   func() []*models.Proxy // if set, proxies are loaded from it instead of the database
 	mu       sync.RWMutex
 }
 
+// proxyFilter restricts the proxies a selector loads
+type proxyFilter struct {
+	Tags models.Tags      // proxies must carry all of these tags
+	Geo  models.GeoFilter // exit location constraint
+}
+
 // RandomSelector selects a random proxy
 type RandomSelector struct {
 	*BaseSelector
@@ -127,12 +141,16 @@ func (s *RoundRobinSelector) Refresh(ctx context.Context) error {
 	return nil
 }
 
-// LeastConnectionsSelector selects the proxy with the lowest usage count
+// LeastConnectionsSelector selects the proxy with the fewest in-flight requests
+// It uses power-of-two choices: two random proxies are compared and the less busy
+// one wins, which avoids sending every concurrent request to the same proxy.
 type LeastConnectionsSelector struct {
 	*BaseSelector
+	conns *ConnectionTracker
 }
 
 // NewLeastConnectionsSelector creates a new least connections selector
+// It counts its own connections until shareConnections gives it the handler's tracker.
 func NewLeastConnectionsSelector(repo *repository.ProxyRepository, settings *models.RotationSettings) *LeastConnectionsSelector {
 	return &LeastConnectionsSelector{
 		BaseSelector: &BaseSelector{
@@ -140,27 +158,42 @@ func NewLeastConnectionsSelector(repo *repository.ProxyRepository, settings *mod
 			proxies:  make([]*models.Proxy, 0),
 			settings: settings,
 		},
+		conns: NewConnectionTracker(),
 	}
 }
 
-// Select returns the proxy with the lowest request count
+// Select returns the less busy of two randomly chosen proxies
 func (s *LeastConnectionsSelector) Select(ctx context.Context) (*models.Proxy, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if len(s.proxies) == 0 {
+	n := len(s.proxies)
+	if n == 0 {
 		return nil, fmt.Errorf("no proxies available")
 	}
+	if n == 1 {
+		return s.proxies[0], nil
+	}
 
-	// Find proxy with minimum requests
-	minProxy := s.proxies[0]
-	for _, proxy := range s.proxies[1:] {
-		if proxy.Requests < minProxy.Requests {
-			minProxy = proxy
-		}
+	// Pick two distinct proxies
+	i := mathrand.IntN(n)
+	j := mathrand.IntN(n - 1)
+	if j >= i {
+		j++
+	}
+
+	a, b := s.proxies[i], s.proxies[j]
+	if s.conns.InFlight(b.ID) < s.conns.InFlight(a.ID) {
+		return b, nil
 	}
+	return a, nil
+}
 
-	return minProxy, nil
+// setConnections makes the selector use the shared in-flight counters
+func (s *LeastConnectionsSelector) setConnections(conns *ConnectionTracker) {
+	s.mu.Lock()
+	s.conns = conns
+	s.mu.Unlock()
 }
 
 // Refresh reloads the proxy list from database
@@ -226,31 +259,58 @@ func (s *TimeBasedSelector) Refresh(ctx context.Context) error {
 	return nil
 }
 
-// RateLimitedSelector selects proxies that haven't exceeded per-minute rate limit
+// RateLimitedSelector selects proxies that haven't exceeded their per-window request limit
+// Each proxy has an in-memory sliding window holding the times of its last
+// maxRequestsPerMinute selections, so limits are enforced exactly without querying
+// the database on Select. Windows live in a RateWindows registry shared with the
+// other rate-limited selectors, and are seeded from proxy_requests when a proxy is
+// first loaded, so a restart or settings reload doesn't reset them.
+// When every proxy is at its limit, Select waits for a free slot for up to
+// rate_limited.max_wait_seconds or the caller's deadline, whichever comes first.
 type RateLimitedSelector struct {
 	*BaseSelector
 	maxRequestsPerMinute int
 	windowSeconds        int
+	maxWait              time.Duration
 	currentIndex         int
-	cache                []*models.Proxy
-	cacheExpiry          time.Time
-	cacheDuration         time.Duration
+	windows              *RateWindows // guarded by mu
+	now                  func() time.Time
+}
+
+// slidingWindow is a ring buffer of a proxy's most recent selection times
+type slidingWindow struct {
+	times []time.Time // times[next] is the oldest
+	next  int
+}
+
+// newSlidingWindow creates a window admitting limit selections
+func newSlidingWindow(limit int) *slidingWindow {
+	return &slidingWindow{times: make([]time.Time, limit)}
+}
+
+// availableAt returns when the proxy may be selected again
+func (w *slidingWindow) availableAt(window time.Duration) time.Time {
+	oldest := w.times[w.next]
+	if oldest.IsZero() {
+		return oldest
+	}
+	return oldest.Add(window)
+}
+
+// record adds a selection, evicting the oldest one
+func (w *slidingWindow) record(t time.Time) {
+	w.times[w.next] = t
+	w.next = (w.next + 1) % len(w.times)
 }
 
 // NewRateLimitedSelector creates a new rate-limited selector
+// It keeps its own windows until shareRateWindows gives it the server's registry.
 func NewRateLimitedSelector(
 	repo *repository.ProxyRepository,
 	settings *models.RotationSettings,
 	maxRequestsPerMinute int,
 	windowSeconds int,
 ) *RateLimitedSelector {
-	// Default cache duration: 2 seconds to reduce database queries
-	cacheDuration := 2 * time.Second
-	if windowSeconds < 10 {
-		// For very short windows, cache for shorter duration
-		cacheDuration = time.Duration(windowSeconds/5) * time.Second
-	}
-
 	return &RateLimitedSelector{
 		BaseSelector: &BaseSelector{
 			repo:     repo,
@@ -259,181 +319,237 @@ func NewRateLimitedSelector(
 		},
 		maxRequestsPerMinute: maxRequestsPerMinute,
 		windowSeconds:        windowSeconds,
+		maxWait:              time.Duration(settings.RateLimited.MaxWaitSeconds) * time.Second,
 		currentIndex:         0,
-		cache:                make([]*models.Proxy, 0),
-		cacheExpiry:          time.Time{},
-		cacheDuration:        cacheDuration,
+		windows:              NewRateWindows(),
+		now:                  time.Now,
 	}
 }
 
-// Select returns a proxy that hasn't exceeded the rate limit
+// Select returns the next proxy in round-robin order that hasn't reached the rate limit,
+// waiting for one to free up if necessary
 func (s *RateLimitedSelector) Select(ctx context.Context) (*models.Proxy, error) {
-	s.mu.RLock()
-	allProxies := s.proxies
-	cachedProxies := s.cache
-	cacheValid := time.Now().Before(s.cacheExpiry) && len(cachedProxies) > 0
-	s.mu.RUnlock()
+	var deadline time.Time
+	for {
+		proxy, wait, err := s.trySelect()
+		if proxy != nil || err != nil {
+			return proxy, err
+		}
 
-	if len(allProxies) == 0 {
-		return nil, fmt.Errorf("no proxies available")
-	}
+		if deadline.IsZero() {
+			deadline = s.now().Add(s.maxWait)
+			if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+				deadline = d
+			}
+		}
 
-	var availableProxies []*models.Proxy
-	var err error
+		if s.now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("all proxies have reached rate limit (%d requests/%d seconds). Please wait or increase the limit",
+				s.maxRequestsPerMinute, s.windowSeconds)
+		}
 
-	// Use cache if valid
-	if cacheValid {
-		availableProxies = cachedProxies
-	} else {
-		// Cache expired or empty, refresh from database
-		availableProxies, err = s.getAvailableProxies(ctx, allProxies)
-		if err != nil {
-			// Fallback: if query fails, use all proxies and log warning
-			fmt.Printf("[RATE_LIMITED] Failed to query rate limits, using all proxies: %v\n", err)
-			availableProxies = allProxies
+		// Another waiter may take the slot first, in which case we wait again
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
 		}
+	}
+}
+
+// trySelect takes a slot from the first proxy under its limit, starting at the
+// round-robin position; otherwise it returns how long until a slot frees up
+func (s *RateLimitedSelector) trySelect() (*models.Proxy, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		// Update cache
-		s.mu.Lock()
-		s.cache = availableProxies
-		s.cacheExpiry = time.Now().Add(s.cacheDuration)
-		s.mu.Unlock()
+	if len(s.proxies) == 0 {
+		return nil, 0, fmt.Errorf("no proxies available")
 	}
 
-	if len(availableProxies) == 0 {
-		// All proxies are at limit - return error
-		return nil, fmt.Errorf("all proxies have reached rate limit (%d requests/%d seconds). Please wait or increase the limit", 
-			s.maxRequestsPerMinute, s.windowSeconds)
+	window := time.Duration(s.windowSeconds) * time.Second
+	index, wait := s.windows.take(s.proxies, s.currentIndex, s.maxRequestsPerMinute, window, s.now())
+	if index < 0 {
+		return nil, wait, nil
 	}
 
-	// Round-robin selection from available proxies
+	s.currentIndex = (index + 1) % len(s.proxies)
+	return s.proxies[index], 0, nil
+}
+
+// setRateWindows makes the selector count selections in the shared windows
+func (s *RateLimitedSelector) setRateWindows(windows *RateWindows) {
 	s.mu.Lock()
-	selectedProxy := availableProxies[s.currentIndex%len(availableProxies)]
-	s.currentIndex = (s.currentIndex + 1) % len(availableProxies)
+	s.windows = windows
 	s.mu.Unlock()
-
-	return selectedProxy, nil
 }
 
-// getAvailableProxies queries the database for proxies under the rate limit
-func (s *RateLimitedSelector) getAvailableProxies(ctx context.Context, allProxies []*models.Proxy) ([]*models.Proxy, error) {
-	if len(allProxies) == 0 {
-		return nil, fmt.Errorf("no proxies to check")
-	}
-
-	// Build query to count requests per proxy in last windowSeconds
-	// Use make_interval for safer interval construction
+// loadWindows builds sliding windows from the requests recorded for the proxies
+// within the last windowSeconds
+func (s *RateLimitedSelector) loadWindows(ctx context.Context, proxyIDs []int) (map[int]*slidingWindow, error) {
 	query := `
-		SELECT 
-			proxy_id,
-			COUNT(*) as request_count
+		SELECT proxy_id, timestamp
 		FROM proxy_requests
-		WHERE 
+		WHERE
 			proxy_id = ANY($1)
 			AND timestamp >= NOW() - make_interval(secs => $2)
-			AND success = true
-		GROUP BY proxy_id
-		HAVING COUNT(*) < $3
+		ORDER BY timestamp
 	`
 
-	// Get proxy IDs
-	proxyIDs := make([]int, len(allProxies))
-	for i, p := range allProxies {
-		proxyIDs[i] = p.ID
-	}
-
-	// Execute query with parameterized interval
-	rows, err := s.repo.GetDB().Pool.Query(
-		ctx,
-		query,
-		proxyIDs,
-		s.windowSeconds,
-		s.maxRequestsPerMinute,
-	)
+	rows, err := s.repo.GetDB().Pool.Query(ctx, query, proxyIDs, s.windowSeconds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query rate limits: %w", err)
+		return nil, fmt.Errorf("failed to query recent requests: %w", err)
 	}
 	defer rows.Close()
 
-	// Collect proxy IDs that are under limit
-	availableProxyIDs := make(map[int]bool)
+	windows := make(map[int]*slidingWindow, len(proxyIDs))
 	for rows.Next() {
 		var proxyID int
-		var count int64
-		if err := rows.Scan(&proxyID, &count); err != nil {
-			continue
+		var timestamp time.Time
+		if err := rows.Scan(&proxyID, &timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan recent request: %w", err)
 		}
-		availableProxyIDs[proxyID] = true
-	}
-
-	// Filter proxies that are available
-	// Include proxies that:
-	// 1. Are in the availableProxyIDs map (under limit)
-	// 2. Have no recent requests (not in query results but also not over limit)
-	availableProxies := make([]*models.Proxy, 0)
-	for _, proxy := range allProxies {
-		if availableProxyIDs[proxy.ID] {
-			// Proxy is under limit
-			availableProxies = append(availableProxies, proxy)
-		} else {
-			// Check if proxy has any requests in the time window
-			// If no requests found, it means it's available (zero requests)
-			hasRequests, err := s.proxyHasRecentRequests(ctx, proxy.ID)
-			if err == nil && !hasRequests {
-				// No recent requests, so it's available
-				availableProxies = append(availableProxies, proxy)
-			}
+
+		w, ok := windows[proxyID]
+		if !ok {
+			w = newSlidingWindow(s.maxRequestsPerMinute)
+			windows[proxyID] = w
 		}
+		w.record(timestamp)
 	}
 
-	return availableProxies, nil
-}
-
-// proxyHasRecentRequests checks if proxy has any requests in the time window
-func (s *RateLimitedSelector) proxyHasRecentRequests(ctx context.Context, proxyID int) (bool, error) {
-	query := `
-		SELECT COUNT(*) 
-		FROM proxy_requests 
-		WHERE proxy_id = $1 
-		AND timestamp >= NOW() - make_interval(secs => $2)
-		LIMIT 1
-	`
-	var count int64
-	err := s.repo.GetDB().Pool.QueryRow(
-		ctx,
-		query,
-		proxyID,
-		s.windowSeconds,
-	).Scan(&count)
-	
-	if err != nil {
-		return false, err
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query recent requests: %w", err)
 	}
-	
-	return count > 0, nil
+
+	return windows, nil
 }
 
 // Refresh reloads the proxy list from database
+// Proxies without a window in the shared registry are seeded from their recorded
+// requests; existing windows are kept.
 func (s *RateLimitedSelector) Refresh(ctx context.Context) error {
 	proxies, err := s.loadActiveProxiesWithSettings(ctx, s.settings)
 	if err != nil {
 		return err
 	}
 
+	s.mu.RLock()
+	windows := s.windows
+	s.mu.RUnlock()
+
+	ids := make([]int, len(proxies))
+	for i, p := range proxies {
+		ids[i] = p.ID
+	}
+	if newIDs := windows.missing(ids); len(newIDs) > 0 {
+		seeded, err := s.loadWindows(ctx, newIDs)
+		if err != nil {
+			fmt.Printf("[RATE_LIMITED] Failed to load recent requests, starting with empty windows: %v\n", err)
+		}
+		windows.seed(newIDs, seeded, s.maxRequestsPerMinute)
+	}
+
 	s.mu.Lock()
 	s.proxies = proxies
 	// Reset index if out of bounds
 	if s.currentIndex >= len(proxies) {
 		s.currentIndex = 0
 	}
-	// Invalidate cache on refresh
-	s.cache = make([]*models.Proxy, 0)
-	s.cacheExpiry = time.Time{}
 	s.mu.Unlock()
 
 	return nil
 }
 
+// Lookup returns the proxy with the given ID if it is in the current pool
+func (b *BaseSelector) Lookup(id int) *models.Proxy {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, p := range b.proxies {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// setFilter restricts the selector to proxies matching the filter
+func (b *BaseSelector) setFilter(f proxyFilter) {
+	b.mu.Lock()
+	b.filter = f
+	b.mu.Unlock()
+}
+
+// setSource makes the selector load its proxies from another selector's list
+// Only the geo part of the filter applies to them, in memory.
+func (b *BaseSelector) setSource(source func() []*models.Proxy) {
+	b.mu.Lock()
+	b.source = source
+	b.mu.Unlock()
+}
+
+// Proxies returns the selector's current proxy list
+func (b *BaseSelector) Proxies() []*models.Proxy {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.proxies
+}
+
+// loadSourceProxies returns the source's proxies matching the geo filter and settings
+func (b *BaseSelector) loadSourceProxies(source func() []*models.Proxy, geo models.GeoFilter, settings *models.RotationSettings) ([]*models.Proxy, error) {
+	proxies := make([]*models.Proxy, 0)
+	for _, p := range source() {
+		if geo.Matches(p.Country, p.ASN) && allowedBySettings(p, settings) {
+			proxies = append(proxies, p)
+		}
+	}
+
+	if len(proxies) == 0 {
+		return nil, ErrNoProxies
+	}
+
+	return proxies, nil
+}
+
+// allowedBySettings reports whether the proxy passes the rotation settings filters
+func allowedBySettings(p *models.Proxy, settings *models.RotationSettings) bool {
+	if settings == nil {
+		return true
+	}
+
+	// Protocol filter
+	if len(settings.AllowedProtocols) > 0 {
+		allowed := false
+		for _, protocol := range settings.AllowedProtocols {
+			if p.Protocol == protocol {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	// Max response time filter
+	if settings.MaxResponseTime > 0 && p.AvgResponseTime > settings.MaxResponseTime {
+		return false
+	}
+
+	// Min success rate filter
+	if settings.MinSuccessRate > 0 && p.Requests > 0 {
+		successRate := (float64(p.SuccessfulRequests) / float64(p.Requests)) * 100
+		if successRate < settings.MinSuccessRate {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Helper function to load active proxies from database
 func (b *BaseSelector) loadActiveProxies(ctx context.Context) ([]*models.Proxy, error) {
 	return b.loadActiveProxiesWithSettings(ctx, nil)
@@ -446,13 +562,34 @@ func (b *BaseSelector) loadActiveProxiesWithSettings(ctx context.Context, settin
 		SELECT
 			id, address, protocol, username, password, status,
 			requests, successful_requests, failed_requests,
-			avg_response_time, last_check, last_error, created_at, updated_at
+			avg_response_time, last_check, last_error, tags, tls_server_name, ca_bundle,
+			country, asn, created_at, updated_at
 		FROM proxies
 		WHERE status IN ('active', 'idle')
-		ORDER BY address
 	`
 
-	rows, err := b.repo.GetDB().Pool.Query(ctx, query)
+	b.mu.RLock()
+	filter := b.filter
+	source := b.source
+	b.mu.RUnlock()
+
+	if source != nil {
+		return b.loadSourceProxies(source, filter.Geo, settings)
+	}
+
+	var args []interface{}
+	if len(filter.Tags) > 0 {
+		args = append(args, filter.Tags)
+		query += fmt.Sprintf(" AND tags @> $%d", len(args))
+	}
+	geoClauses, geoArgs := repository.GeoConditions(filter.Geo, len(args)+1)
+	for _, clause := range geoClauses {
+		query += " AND " + clause
+	}
+	args = append(args, geoArgs...)
+	query += " ORDER BY address"
+
+	rows, err := b.repo.GetDB().Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load proxies: %w", err)
 	}
@@ -464,47 +601,23 @@ func (b *BaseSelector) loadActiveProxiesWithSettings(ctx context.Context, settin
 		err := rows.Scan(
 			&p.ID, &p.Address, &p.Protocol, &p.Username, &p.Password, &p.Status,
 			&p.Requests, &p.SuccessfulRequests, &p.FailedRequests,
-			&p.AvgResponseTime, &p.LastCheck, &p.LastError, &p.CreatedAt, &p.UpdatedAt,
+			&p.AvgResponseTime, &p.LastCheck, &p.LastError, &p.Tags, &p.TLSServerName, &p.CABundle,
+			&p.Country, &p.ASN, &p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan proxy: %w", err)
 		}
 
 		// Apply filters if settings provided
-		if settings != nil {
-			// Protocol filter
-			if len(settings.AllowedProtocols) > 0 {
-				allowed := false
-				for _, protocol := range settings.AllowedProtocols {
-					if p.Protocol == protocol {
-						allowed = true
-						break
-					}
-				}
-				if !allowed {
-					continue
-				}
-			}
-
-			// Max response time filter
-			if settings.MaxResponseTime > 0 && p.AvgResponseTime > settings.MaxResponseTime {
-				continue
-			}
-
-			// Min success rate filter
-			if settings.MinSuccessRate > 0 && p.Requests > 0 {
-				successRate := (float64(p.SuccessfulRequests) / float64(p.Requests)) * 100
-				if successRate < settings.MinSuccessRate {
-					continue
-				}
-			}
+		if !allowedBySettings(&p, settings) {
+			continue
 		}
 
 		proxies = append(proxies, &p)
 	}
 
 	if len(proxies) == 0 {
-		return nil, fmt.Errorf("no active or idle proxies found matching filters")
+		return nil, ErrNoProxies
 	}
 
 	return proxies, nil
@@ -1,7 +1,10 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/alpkeskin/rota/core/internal/database"
 	"github.com/alpkeskin/rota/core/internal/models"
@@ -85,3 +88,79 @@ func TestNewProxySelector_RateLimited_Variants(t *testing.T) {
 	}
 }
 
+
+// newTestRateLimitedSelector returns a selector over the given proxy IDs driven by a manual clock
+func newTestRateLimitedSelector(limit, windowSeconds, maxWaitSeconds int, ids ...int) (*RateLimitedSelector, *time.Time) {
+	settings := &models.RotationSettings{
+		RateLimited: models.RateLimitedSettings{MaxWaitSeconds: maxWaitSeconds},
+	}
+	s := NewRateLimitedSelector(nil, settings, limit, windowSeconds)
+	for _, id := range ids {
+		s.proxies = append(s.proxies, &models.Proxy{ID: id})
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+// TestRateLimitedSelector_SlidingWindow tests exact per-proxy limits in memory
+func TestRateLimitedSelector_SlidingWindow(t *testing.T) {
+	s, now := newTestRateLimitedSelector(2, 60, 0, 1, 2)
+	ctx := context.Background()
+
+	// Round-robin across proxies until both are at the limit
+	var got []int
+	for i := 0; i < 4; i++ {
+		p, err := s.Select(ctx)
+		if err != nil {
+			t.Fatalf("Select %d: %v", i, err)
+		}
+		got = append(got, p.ID)
+		*now = now.Add(10 * time.Second)
+	}
+	if fmt.Sprint(got) != "[1 2 1 2]" {
+		t.Fatalf("selected %v, want [1 2 1 2]", got)
+	}
+
+	if _, err := s.Select(ctx); err == nil {
+		t.Fatal("Select succeeded with every proxy at its limit")
+	}
+
+	// The first selection of proxy 1 leaves the window at 60s
+	*now = s.windows.windows[1].times[0].Add(60 * time.Second)
+	p, err := s.Select(ctx)
+	if err != nil || p.ID != 1 {
+		t.Fatalf("Select = %v, %v, want proxy 1 once its oldest request left the window", p, err)
+	}
+}
+
+// TestRateLimitedSelector_Wait tests waiting for a free slot up to the deadline
+func TestRateLimitedSelector_Wait(t *testing.T) {
+	s, _ := newTestRateLimitedSelector(1, 1, 5, 1)
+	s.now = time.Now
+
+	if _, err := s.Select(context.Background()); err != nil {
+		t.Fatalf("first Select: %v", err)
+	}
+
+	// The slot frees up after 1s, beyond the caller's deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := s.Select(ctx); err == nil {
+		t.Fatal("Select succeeded past the caller's deadline")
+	}
+	if time.Since(start) > 40*time.Millisecond {
+		t.Error("Select waited although the slot frees up after the deadline")
+	}
+
+	// Within max_wait_seconds the selector waits for the slot
+	start = time.Now()
+	if _, err := s.Select(context.Background()); err != nil {
+		t.Fatalf("Select did not wait for a free slot: %v", err)
+	}
+	if time.Since(start) < 500*time.Millisecond {
+		t.Error("Select returned a proxy still at its limit")
+	}
+}
@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alpkeskin/rota/core/internal/metrics"
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/elazarl/goproxy"
+)
+
+// routePoolPrefix names the ad hoc pool of a route selecting proxies by tags
+// Pool names can't contain ':', so these never collide with named pools.
+const routePoolPrefix = "route:"
+
+// route is a route definition with its parsed target and own rate limiter
+type route struct {
+	def       models.ProxyRoute
+	target    *url.URL
+	rateLimit *RateLimitMiddleware
+}
+
+// RouteTable serves direct (non-proxy) requests on the proxy port whose path
+// matches a configured route, forwarding them to the route's target through
+// the proxy pool, e.g. "/hyperliquid/info" to "https://api.hyperliquid.xyz/info".
+type RouteTable struct {
+	handler   *UpstreamProxyHandler
+	auth      *AuthMiddleware
+	rateLimit *RateLimitMiddleware
+	logger    *logger.Logger
+
+	mu     sync.RWMutex
+	routes []*route // longest prefix first
+}
+
+// NewRouteTable creates an empty route table
+func NewRouteTable(handler *UpstreamProxyHandler, auth *AuthMiddleware, rateLimit *RateLimitMiddleware, log *logger.Logger) *RouteTable {
+	return &RouteTable{
+		handler:   handler,
+		auth:      auth,
+		rateLimit: rateLimit,
+		logger:    log,
+	}
+}
+
+// Update replaces the route definitions
+// Routes whose target doesn't parse are skipped. Per-IP limiters are kept for
+// routes whose rate limit didn't change.
+func (t *RouteTable) Update(defs []models.ProxyRoute) {
+	t.mu.RLock()
+	current := make(map[string]*route, len(t.routes))
+	for _, r := range t.routes {
+		current[r.def.Name] = r
+	}
+	t.mu.RUnlock()
+
+	routes := make([]*route, 0, len(defs))
+	for _, def := range defs {
+		target, err := url.Parse(def.Target)
+		if err != nil || target.Host == "" {
+			t.logger.Warn("skipping route with invalid target", "route", def.Name, "target", def.Target, "error", err)
+			continue
+		}
+
+		r := &route{def: def, target: target}
+		if old, ok := current[def.Name]; ok && old.def.RateLimit == def.RateLimit {
+			r.rateLimit = old.rateLimit
+		} else {
+			r.rateLimit = NewRateLimitMiddleware(def.RateLimit)
+		}
+		routes = append(routes, r)
+	}
+
+	slices.SortStableFunc(routes, func(a, b *route) int {
+		return len(b.def.PathPrefix) - len(a.def.PathPrefix)
+	})
+
+	t.mu.Lock()
+	t.routes = routes
+	t.mu.Unlock()
+}
+
+// match returns the route serving a request, or nil
+// Only direct requests (origin-form, e.g. "GET /hyperliquid/info") are routed;
+// proxy requests carry an absolute URL.
+func (t *RouteTable) match(req *http.Request) *route {
+	if req.URL.IsAbs() || req.Method == http.MethodConnect {
+		return nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, r := range t.routes {
+		prefix := r.def.PathPrefix
+		if req.URL.Path == prefix || strings.HasPrefix(req.URL.Path, prefix+"/") {
+			return r
+		}
+	}
+	return nil
+}
+
+// serve forwards a request matched by the route to its target
+func (t *RouteTable) serve(w http.ResponseWriter, req *http.Request, r *route) {
+	// Authentication (attaches routing options and the authenticated client)
+	req, resp := t.auth.HandleRouteRequest(req, r.def.AuthRequired)
+	if resp != nil {
+		writeResponse(w, resp)
+		return
+	}
+
+	outReq := r.rewrite(req)
+	proxyCtx := &goproxy.ProxyCtx{Req: outReq}
+
+	// Global rate limit and client quotas, then the route's own per-IP limit
+	if _, resp := t.rateLimit.HandleRequest(outReq, proxyCtx); resp != nil {
+		writeResponse(w, resp)
+		return
+	}
+	if !r.rateLimit.allowIP(outReq) {
+		metrics.RateLimitRejections.WithLabelValues("route").Inc()
+		t.logger.Info("rate limited",
+			"source", "route",
+			"route", r.def.Name,
+			"path", req.URL.Path,
+		)
+		writeResponse(w, r.rateLimit.tooManyRequests())
+		return
+	}
+
+	_, resp = t.handler.HandleRequest(outReq, proxyCtx)
+	writeResponse(w, resp)
+}
+
+// rewrite returns a copy of the request addressed to the route's target
+// The path after the prefix is appended to the target path and the query is kept.
+func (r *route) rewrite(req *http.Request) *http.Request {
+	opts := RouteOptionsFromContext(req.Context())
+	opts.Route = r.def.Name
+	if r.def.Pool != "" {
+		opts.Pool = r.def.Pool
+	} else if len(r.def.Tags) > 0 {
+		opts.Pool = routePoolPrefix + r.def.Name
+	}
+
+	out := req.Clone(WithRouteOptions(req.Context(), opts))
+
+	target := *r.target
+	target.Path = strings.TrimSuffix(target.Path, "/") + strings.TrimPrefix(req.URL.Path, r.def.PathPrefix)
+	if target.Path == "" {
+		target.Path = "/"
+	}
+	target.RawPath = ""
+	target.RawQuery = req.URL.RawQuery
+	out.URL = &target
+	out.Host = target.Host
+	out.RequestURI = ""
+
+	return out
+}
+
+// pool returns the ad hoc pool for a route selecting proxies by tags
+func (r *route) pool() (models.ProxyPool, bool) {
+	if r.def.Pool != "" || len(r.def.Tags) == 0 {
+		return models.ProxyPool{}, false
+	}
+	return models.ProxyPool{Name: routePoolPrefix + r.def.Name, Tags: r.def.Tags}, true
+}
+
+// Pools returns the ad hoc pools of routes selecting proxies by tags
+func (t *RouteTable) Pools() []models.ProxyPool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var pools []models.ProxyPool
+	for _, r := range t.routes {
+		if pool, ok := r.pool(); ok {
+			pools = append(pools, pool)
+		}
+	}
+	return pools
+}
+
+// CleanupLimiters bounds the per-IP limiters of every route
+func (t *RouteTable) CleanupLimiters() {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, r := range t.routes {
+		r.rateLimit.CleanupLimiters()
+	}
+}
+
+// writeResponse copies a handler response to the client
+func writeResponse(w http.ResponseWriter, resp *http.Response) {
+	if resp == nil {
+		http.Error(w, "No response from handler", http.StatusInternalServerError)
+		return
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	if resp.Body != nil {
+		io.Copy(w, resp.Body)
+	}
+}
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// TestRouteTable tests longest-prefix matching and target rewriting
+func TestRouteTable(t *testing.T) {
+	table := NewRouteTable(nil, nil, nil, logger.New("error"))
+	table.Update([]models.ProxyRoute{
+		{Name: "api", PathPrefix: "/api", Target: "https://api.example.com/v1/"},
+		{Name: "api_beta", PathPrefix: "/api/beta", Target: "http://beta.example.com", Tags: models.Tags{"tier": "premium"}},
+		{Name: "broken", PathPrefix: "/broken", Target: "not a url"},
+	})
+
+	tests := []struct {
+		target string
+		route  string
+		url    string
+		pool   string
+	}{
+		{"/api", "api", "https://api.example.com/v1", ""},
+		{"/api/info?type=meta", "api", "https://api.example.com/v1/info?type=meta", ""},
+		{"/api/beta/x", "api_beta", "http://beta.example.com/x", "route:api_beta"},
+		{"/apix", "", "", ""},
+		{"/broken/x", "", "", ""},
+		{"http://example.com/api/info", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		r := table.match(req)
+		if r == nil {
+			if tt.route != "" {
+				t.Errorf("%s: no route, want %s", tt.target, tt.route)
+			}
+			continue
+		}
+		if r.def.Name != tt.route {
+			t.Errorf("%s: route = %s, want %s", tt.target, r.def.Name, tt.route)
+			continue
+		}
+
+		out := r.rewrite(req)
+		opts := RouteOptionsFromContext(out.Context())
+		if out.URL.String() != tt.url || out.Host != out.URL.Host || out.RequestURI != "" {
+			t.Errorf("%s: rewritten to %s (host %s), want %s", tt.target, out.URL, out.Host, tt.url)
+		}
+		if opts.Route != tt.route || opts.Pool != tt.pool {
+			t.Errorf("%s: options = %+v, want route %s and pool %q", tt.target, opts, tt.route, tt.pool)
+		}
+	}
+
+	if pools := table.Pools(); len(pools) != 1 || pools[0].Name != "route:api_beta" {
+		t.Errorf("Pools() = %+v, want the api_beta tag pool", pools)
+	}
+}
@@ -3,15 +3,17 @@ package proxy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
-	"net/url"
-	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
+	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/internal/tracing"
 	"github.com/alpkeskin/rota/core/pkg/logger"
 	"github.com/elazarl/goproxy"
 )
@@ -22,13 +24,24 @@ var startTime = time.Now()
 type Server struct {
 	proxy          *goproxy.ProxyHttpServer
 	server         *http.Server
+	socks          *SOCKS5Server // nil when the SOCKS5 port is disabled
+	routes         *RouteTable
 	logger         *logger.Logger
 	port           int
-	selector       ProxySelector
+	pools          *PoolManager
+	sessions       *SessionManager
+	breaker        *CircuitBreaker
+	blocks         *BlockList
+	conns          *ConnectionTracker
+	rateWindows    *RateWindows
+	transports     *TransportCache
 	tracker        *UsageTracker
 	handler        *UpstreamProxyHandler
 	authMiddleware *AuthMiddleware
 	rateLimitMw    *RateLimitMiddleware
+	timeout        *atomic.Int64 // per-request read/write deadline (rotation.timeout), in nanoseconds
+	clients        *ClientRegistry
+	healthChecks   *HealthScheduler
 	proxyRepo      *repository.ProxyRepository
 	settingsRepo   *repository.SettingsRepository
 	refreshTicker  *time.Ticker
@@ -37,11 +50,16 @@ type Server struct {
 }
 
 // New creates a new proxy server instance
+// A socks5Port of 0 disables the SOCKS5 listener.
 func New(
 	port int,
+	socks5Port int,
 	log *logger.Logger,
 	proxyRepo *repository.ProxyRepository,
 	settingsRepo *repository.SettingsRepository,
+	clientRepo *repository.ClientRepository,
+	poolRepo *repository.PoolRepository,
+	healthChecks *HealthScheduler,
 ) (*Server, error) {
 	// Load settings
 	ctx := context.Background()
@@ -50,11 +68,19 @@ func New(
 		return nil, fmt.Errorf("failed to load settings: %w", err)
 	}
 
+	// In-flight request and tunnel counts per proxy, for least-connections rotation
+	conns := NewConnectionTracker()
+
+	// Recent selections per proxy, for rate-limited rotation
+	rateWindows := NewRateWindows()
+
 	// Create proxy selector based on rotation settings
 	selector, err := NewProxySelector(proxyRepo, &settings.Rotation)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create proxy selector: %w", err)
 	}
+	shareConnections(selector, conns)
+	shareRateWindows(selector, rateWindows)
 
 	// Initial refresh of proxy list
 	if err := selector.Refresh(ctx); err != nil {
@@ -63,31 +89,62 @@ func New(
 		log.Info("proxy server initialized successfully")
 	}
 
+	// Create per-pool selectors for tag-based routing
+	pools := NewPoolManager(proxyRepo, poolRepo, &settings.Rotation, conns, rateWindows, log)
+	if err := pools.Refresh(ctx); err != nil {
+		log.Warn("failed to load proxy pools - only the default pool will be available", "error", err)
+	}
+
 	// Create usage tracker
-	tracker := NewUsageTracker(proxyRepo)
+	tracker := NewUsageTracker(proxyRepo, log)
+
+	// Create sticky session pin table
+	sessions := NewSessionManager(time.Duration(settings.Rotation.StickySessionTTL) * time.Second)
+
+	// Create per-proxy circuit breaker, shared by HTTP and CONNECT requests
+	breaker := NewCircuitBreaker(settings.Rotation.CircuitBreaker)
+
+	// Remember which proxies each target host has blocked
+	blocks := NewBlockList()
+
+	// Cache one transport per upstream proxy to reuse keep-alive connections
+	transports := NewTransportCache()
 
 	// Create upstream proxy handler
-	handler := NewUpstreamProxyHandler(selector, tracker, &settings.Rotation, log)
+	handler := NewUpstreamProxyHandler(selector, pools, sessions, breaker, blocks, conns, transports, tracker, &settings.Rotation, log)
+	pools.SetDefaultSelector(handler.Selector)
+
+	// Load per-client proxy credentials
+	clients := NewClientRegistry(clientRepo, tracker, log)
+	if err := clients.Refresh(ctx); err != nil {
+		log.Warn("failed to load proxy clients - only the shared credential will be accepted", "error", err)
+	}
 
 	// Create middlewares
-	authMiddleware := NewAuthMiddleware(settings.Authentication)
+	authMiddleware := NewAuthMiddleware(settings.Authentication, clients)
 	rateLimitMw := NewRateLimitMiddleware(settings.RateLimit)
 
+	// Closed on shutdown to stop background tasks and interrupt upstream dials
+	stopChan := make(chan struct{})
+
 	// Create goproxy instance
 	proxyServer := goproxy.NewProxyHttpServer()
 	proxyServer.Verbose = log.Logger.Enabled(context.Background(), -4) // Enable verbose if debug level
 
-	// CRITICAL: Set ConnectDial to route HTTPS through upstream proxy
+	// CRITICAL: Set ConnectDialWithReq to route HTTPS through upstream proxy
 	// This is called for ALL CONNECT requests (HTTPS tunneling)
-	proxyServer.ConnectDial = func(network string, addr string) (net.Conn, error) {
+	// The request carries the authenticated client set by the CONNECT middleware
+	proxyServer.ConnectDialWithReq = func(req *http.Request, network string, addr string) (net.Conn, error) {
 		log.Info("ConnectDial called",
 			"source", "proxy",
 			"network", network,
 			"addr", addr,
 		)
 
-		// Connect through upstream proxy with retry logic
-		conn, _, err := handler.ConnectThroughProxyForDial(addr)
+		// Connect through upstream proxy with retry logic, continuing the client's trace
+		dialCtx, cancel := connectDialContext(req, stopChan)
+		defer cancel()
+		conn, _, err := handler.ConnectThroughProxyForDial(dialCtx, addr)
 		if err != nil {
 			log.Error("ConnectDial failed",
 				"source", "proxy",
@@ -109,40 +166,14 @@ func New(
 
 	// HTTP requests
 	proxyServer.OnRequest().DoFunc(func(req *http.Request, ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
-		// Intercept /hyperliquid/{path} requests and rewrite to api.hyperliquid.xyz
-		if req.URL.Path == "/hyperliquid" || strings.HasPrefix(req.URL.Path, "/hyperliquid/") {
-			// Extract the path after /hyperliquid
-			hyperliquidPath := strings.TrimPrefix(req.URL.Path, "/hyperliquid")
-			if hyperliquidPath == "" {
-				hyperliquidPath = "/"
-			}
-
-			// Build new URL: https://api.hyperliquid.xyz/{path}
-			newURL := fmt.Sprintf("https://api.hyperliquid.xyz%s", hyperliquidPath)
-			if req.URL.RawQuery != "" {
-				newURL += "?" + req.URL.RawQuery
-			}
-
-			// Parse the new URL
-			parsedURL, err := url.Parse(newURL)
-			if err != nil {
-				log.Error("failed to parse hyperliquid URL", "url", newURL, "error", err)
-				return req, goproxy.NewResponse(req, goproxy.ContentTypeText, http.StatusBadGateway, "Failed to parse hyperliquid URL")
-			}
-
-			// Update request URL
-			req.URL = parsedURL
-			req.Host = "api.hyperliquid.xyz"
-			req.Header.Set("Host", "api.hyperliquid.xyz")
-		}
-
-		// Authentication middleware
-		if req, resp := authMiddleware.HandleRequest(req, ctx); resp != nil {
+		// Authentication middleware (attaches the authenticated client to the request)
+		var resp *http.Response
+		if req, resp = authMiddleware.HandleRequest(req, ctx); resp != nil {
 			return req, resp
 		}
 
 		// Rate limiting middleware
-		if req, resp := rateLimitMw.HandleRequest(req, ctx); resp != nil {
+		if req, resp = rateLimitMw.HandleRequest(req, ctx); resp != nil {
 			return req, resp
 		}
 
@@ -153,10 +184,13 @@ func New(
 	// HTTPS CONNECT requests - middleware only (actual dial handled by ConnectDial above)
 	proxyServer.OnRequest().HandleConnect(goproxy.FuncHttpsHandler(func(host string, ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
 		// Authentication middleware
-		if _, resp := authMiddleware.HandleConnect(ctx.Req, ctx); resp != nil {
+		req, resp := authMiddleware.HandleConnect(ctx.Req, ctx)
+		if resp != nil {
 			ctx.Resp = resp
 			return goproxy.RejectConnect, host
 		}
+		// Keep the authenticated client for ConnectDialWithReq
+		ctx.Req = req
 
 		// Rate limiting middleware
 		if _, resp := rateLimitMw.HandleConnect(ctx.Req, ctx); resp != nil {
@@ -168,8 +202,15 @@ func New(
 		return goproxy.OkConnect, host
 	}))
 
-	// Create a wrapper handler that intercepts direct HTTP requests to /hyperliquid/*
-	// before they reach goproxy (which only handles proxy requests)
+	// Reverse-proxy routes, loaded from settings below
+	routes := NewRouteTable(handler, authMiddleware, rateLimitMw, log)
+
+	// Request deadlines follow rotation.timeout, which can change at runtime
+	timeout := new(atomic.Int64)
+	timeout.Store(int64(time.Duration(settings.Rotation.Timeout) * time.Second))
+
+	// Create a wrapper handler that serves direct HTTP requests (health and
+	// reverse-proxy routes) before they reach goproxy (which only handles proxy requests)
 	wrapperHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Info("incoming request",
 			"source", "proxy",
@@ -178,6 +219,13 @@ func New(
 			"host", r.Host,
 		)
 
+		// Deadlines are set per request rather than through http.Server timeouts, so a
+		// changed rotation.timeout applies to the next request; hijacking clears them
+		deadline := time.Now().Add(time.Duration(timeout.Load()))
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(deadline)
+		_ = rc.SetWriteDeadline(deadline)
+
 		if r.URL.Path == "/health" && r.Method == http.MethodGet {
 			response := map[string]interface{}{
 				"status":  "healthy",
@@ -190,94 +238,13 @@ func New(
 			return
 		}
 
-		// Check if this is a direct HTTP request to /hyperliquid/*
-		if r.URL.Path == "/hyperliquid" || strings.HasPrefix(r.URL.Path, "/hyperliquid/") {
-			log.Info("intercepting hyperliquid request",
-				"source", "proxy",
-				"path", r.URL.Path,
-			)
-			// This is a direct HTTP request, not a proxy request
-			// Handle it directly by calling the handler logic
-
-			// Extract the path after /hyperliquid
-			hyperliquidPath := strings.TrimPrefix(r.URL.Path, "/hyperliquid")
-			if hyperliquidPath == "" {
-				hyperliquidPath = "/"
-			}
-
-			// Build new URL: https://api.hyperliquid.xyz/{path}
-			newURL := fmt.Sprintf("https://api.hyperliquid.xyz%s", hyperliquidPath)
-			if r.URL.RawQuery != "" {
-				newURL += "?" + r.URL.RawQuery
-			}
-
-			// Parse the new URL
-			parsedURL, err := url.Parse(newURL)
-			if err != nil {
-				log.Error("failed to parse hyperliquid URL", "url", newURL, "error", err)
-				http.Error(w, "Failed to parse hyperliquid URL", http.StatusBadGateway)
-				return
-			}
-
-			// Create a new request with the rewritten URL
-			// Clone preserves the body, method, headers, etc.
-			newReq := r.Clone(r.Context())
-			newReq.URL = parsedURL
-			newReq.Host = "api.hyperliquid.xyz"
-			newReq.Header.Set("Host", "api.hyperliquid.xyz")
-			// Ensure RequestURI is cleared for client requests
-			newReq.RequestURI = ""
-
-			// Create a proxy context for the handler
-			// The handler uses ctx.Req.Context(), so we need to set Req to newReq
-			proxyCtx := &goproxy.ProxyCtx{
-				Req:     newReq, // Handler uses ctx.Req.Context()
-				Resp:    nil,
-				Session: 0,
-			}
-
-			// Process through middleware and handler
-			// Authentication middleware (skip for public endpoint - /hyperliquid/* is public)
-			// Rate limiting middleware
-			if _, resp := rateLimitMw.HandleRequest(newReq, proxyCtx); resp != nil {
-				log.Info("rate limited",
-					"source", "hl-proxy",
-					"path", r.URL.Path,
-				)
-				resp.Write(w)
-				return
-			}
-
-			// Main handler - this will forward through proxy pool
-			// The handler modifies the request, so we pass newReq
-			log.Info("calling handler for hyperliquid request",
-				"source", "hl-proxy",
-				"url", newReq.URL.String(),
-			)
-			_, resp := handler.HandleRequest(newReq, proxyCtx)
-			if resp != nil {
-				// Copy response headers
-				for key, values := range resp.Header {
-					for _, value := range values {
-						w.Header().Add(key, value)
-					}
-				}
-				w.WriteHeader(resp.StatusCode)
-
-				// Copy response body
-				if resp.Body != nil {
-					defer resp.Body.Close()
-					io.Copy(w, resp.Body)
-				}
-				return
-			}
-
-			// If no response, return error
-			log.Error("no response from handler",
-				"source", "proxy",
+		if route := routes.match(r); route != nil {
+			log.Info("serving route request",
+				"source", "route",
+				"route", route.def.Name,
 				"path", r.URL.Path,
 			)
-			http.Error(w, "No response from handler", http.StatusInternalServerError)
+			routes.serve(w, r, route)
 			return
 		}
 
@@ -290,26 +257,46 @@ func New(
 	})
 
 	httpServer := &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      wrapperHandler,
-		ReadTimeout:  time.Duration(settings.Rotation.Timeout) * time.Second,
-		WriteTimeout: time.Duration(settings.Rotation.Timeout) * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           wrapperHandler,
+		ReadHeaderTimeout: 30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+
+	// SOCKS5 clients share authentication, rate limiting and upstream routing
+	var socks *SOCKS5Server
+	if socks5Port > 0 {
+		socks = NewSOCKS5Server(socks5Port, handler, authMiddleware, rateLimitMw, log)
 	}
 
 	s := &Server{
 		proxy:          proxyServer,
 		server:         httpServer,
+		socks:          socks,
+		routes:         routes,
 		logger:         log,
 		port:           port,
-		selector:       selector,
+		pools:          pools,
+		sessions:       sessions,
+		breaker:        breaker,
+		blocks:         blocks,
+		conns:          conns,
+		rateWindows:    rateWindows,
+		transports:     transports,
 		tracker:        tracker,
 		handler:        handler,
 		authMiddleware: authMiddleware,
 		rateLimitMw:    rateLimitMw,
+		timeout:        timeout,
+		clients:        clients,
+		healthChecks:   healthChecks,
 		proxyRepo:      proxyRepo,
 		settingsRepo:   settingsRepo,
-		stopChan:       make(chan struct{}),
+		stopChan:       stopChan,
+	}
+
+	if err := s.RefreshRoutes(ctx); err != nil {
+		log.Warn("failed to load reverse-proxy routes - no routes will be served", "error", err)
 	}
 
 	// Start background tasks
@@ -320,18 +307,32 @@ func New(
 
 // startBackgroundTasks starts periodic background tasks
 func (s *Server) startBackgroundTasks() {
+	// Scheduled health checks (interval from healthcheck settings)
+	s.healthChecks.Start()
+
+	// Batched writes of request records
+	s.tracker.Start()
+
 	// Refresh proxy list every 30 seconds
+	s.updateProxyMetrics(context.Background())
 	s.refreshTicker = time.NewTicker(30 * time.Second)
 	go func() {
 		for {
 			select {
 			case <-s.refreshTicker.C:
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-				if err := s.selector.Refresh(ctx); err != nil {
+				if err := s.handler.Selector().Refresh(ctx); err != nil {
 					s.logger.Error("failed to refresh proxy list", "error", err)
 				} else {
 					s.logger.Info("proxy list refreshed")
 				}
+				if err := s.pools.Refresh(ctx); err != nil {
+					s.logger.Error("failed to refresh proxy pools", "error", err)
+				}
+				if err := s.clients.Refresh(ctx); err != nil {
+					s.logger.Error("failed to refresh proxy clients", "error", err)
+				}
+				s.updateProxyMetrics(ctx)
 				cancel()
 			case <-s.stopChan:
 				return
@@ -346,7 +347,12 @@ func (s *Server) startBackgroundTasks() {
 			select {
 			case <-s.cleanupTicker.C:
 				s.rateLimitMw.CleanupLimiters()
-				s.logger.Info("cleaned up rate limiters")
+				s.routes.CleanupLimiters()
+				s.sessions.Cleanup()
+				s.blocks.Cleanup()
+				s.transports.Cleanup()
+				s.rateWindows.Cleanup()
+				s.logger.Info("cleaned up rate limiters, expired sessions and blocks, and unused transports")
 			case <-s.stopChan:
 				return
 			}
@@ -354,10 +360,39 @@ func (s *Server) startBackgroundTasks() {
 	}()
 }
 
+// updateProxyMetrics sets the proxies gauge to the current count per status
+func (s *Server) updateProxyMetrics(ctx context.Context) {
+	counts, err := s.proxyRepo.CountByStatus(ctx)
+	if err != nil {
+		s.logger.Error("failed to count proxies by status", "error", err)
+		return
+	}
+
+	// Statuses without proxies are reported as zero
+	for _, status := range []string{"active", "idle", "failed"} {
+		metrics.Proxies.WithLabelValues(status).Set(float64(counts[status]))
+	}
+	for status, count := range counts {
+		metrics.Proxies.WithLabelValues(status).Set(float64(count))
+	}
+}
+
 // Start starts the proxy server
 func (s *Server) Start() error {
 	s.logger.Info("starting proxy server", "port", s.port)
 
+	if s.socks != nil {
+		if err := s.socks.Listen(); err != nil {
+			return err
+		}
+		s.logger.Info("starting SOCKS5 server", "port", s.socks.port)
+		go func() {
+			if err := s.socks.Serve(); err != nil {
+				s.logger.Error("SOCKS5 server stopped", "error", err)
+			}
+		}()
+	}
+
 	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("proxy server failed: %w", err)
 	}
@@ -365,20 +400,48 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// connectDialContext returns the context for dialing the upstream of a CONNECT tunnel
+// It continues the client's trace and is cancelled when the client goes away or
+// stop is closed, so neither waits for a slow upstream.
+func connectDialContext(req *http.Request, stop <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(tracing.Extract(req.Context(), req.Header))
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
 // Shutdown gracefully shuts down the proxy server
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("shutting down proxy server")
 
 	// Stop background tasks
 	close(s.stopChan)
+	s.healthChecks.Stop()
 	if s.refreshTicker != nil {
 		s.refreshTicker.Stop()
 	}
 	if s.cleanupTicker != nil {
 		s.cleanupTicker.Stop()
 	}
+	if s.socks != nil {
+		if err := s.socks.Shutdown(ctx); err != nil {
+			s.logger.Warn("failed to close SOCKS5 listener", "error", err)
+		}
+	}
+
+	err := s.server.Shutdown(ctx)
+	s.transports.Close()
 
-	return s.server.Shutdown(ctx)
+	// Write the records of finished requests
+	if closeErr := s.tracker.Close(ctx); closeErr != nil {
+		s.logger.Warn("failed to flush request records", "error", closeErr)
+	}
+	return err
 }
 
 // ReloadSettings reloads settings from database and updates components
@@ -388,26 +451,102 @@ func (s *Server) ReloadSettings(ctx context.Context) error {
 		return fmt.Errorf("failed to load settings: %w", err)
 	}
 
-	// Update middleware settings
-	s.authMiddleware.UpdateSettings(settings.Authentication)
-	s.rateLimitMw.UpdateSettings(settings.RateLimit)
+	if err := s.ApplySettings(ctx, settings); err != nil {
+		return err
+	}
+
+	// Pick up a changed health check interval
+	s.healthChecks.UpdateSettings(settings.HealthCheck)
 
-	// Update handler settings
-	s.handler.settings = &settings.Rotation
+	return s.RefreshRoutes(ctx)
+}
 
+// ApplySettings updates the running server's middlewares, rotation and timeouts
+// Health check settings are applied separately through the health scheduler.
+// The new selector is built before anything changes, so failing to build it
+// leaves every setting as it was; an empty proxy list is not a failure.
+func (s *Server) ApplySettings(ctx context.Context, settings *models.Settings) error {
 	// Recreate selector if rotation method changed
 	newSelector, err := NewProxySelector(s.proxyRepo, &settings.Rotation)
 	if err != nil {
 		return fmt.Errorf("failed to create new selector: %w", err)
 	}
+	shareConnections(newSelector, s.conns)
+	shareRateWindows(newSelector, s.rateWindows)
 
 	if err := newSelector.Refresh(ctx); err != nil {
-		return fmt.Errorf("failed to refresh new selector: %w", err)
+		if !errors.Is(err, ErrNoProxies) {
+			return fmt.Errorf("failed to refresh new selector: %w", err)
+		}
+		s.logger.Warn("no proxies match the new rotation settings - requests will fail until proxies are added", "error", err)
 	}
 
-	s.selector = newSelector
-	s.handler.selector = newSelector
+	// Update middleware settings
+	s.authMiddleware.UpdateSettings(settings.Authentication)
+	s.rateLimitMw.UpdateSettings(settings.RateLimit)
+
+	s.sessions.SetTTL(time.Duration(settings.Rotation.StickySessionTTL) * time.Second)
+	s.breaker.UpdateSettings(settings.Rotation.CircuitBreaker)
+	s.timeout.Store(int64(time.Duration(settings.Rotation.Timeout) * time.Second))
+
+	// Swap the handler's selector and rotation settings together; requests in
+	// flight finish on the previous ones
+	s.handler.UpdateConfig(newSelector, &settings.Rotation)
+
+	// Reload clients and rebuild pool selectors from the new rotation settings
+	// The settings above stay applied if this fails; the periodic refresh retries it
+	var errs []error
+	if err := s.clients.Refresh(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.pools.UpdateSettings(ctx, &settings.Rotation); err != nil {
+		errs = append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
 
 	s.logger.Info("settings reloaded successfully")
 	return nil
 }
+
+// RefreshClients reloads per-client proxy credentials and limits from the database
+func (s *Server) RefreshClients(ctx context.Context) error {
+	return s.clients.Refresh(ctx)
+}
+
+// RefreshPools reloads proxy pool definitions and their proxy lists from the database
+func (s *Server) RefreshPools(ctx context.Context) error {
+	return s.pools.Refresh(ctx)
+}
+
+// RefreshRoutes reloads the reverse-proxy route table from settings
+func (s *Server) RefreshRoutes(ctx context.Context) error {
+	defs, err := s.settingsRepo.GetRoutes(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.routes.Update(defs)
+	return s.pools.SetRoutePools(ctx, s.routes.Pools())
+}
+
+// ListSessions returns the active sticky sessions
+func (s *Server) ListSessions() []models.StickySession {
+	return s.sessions.List()
+}
+
+// ListCircuits returns the proxies whose circuit breaker is open, half-open or counting failures
+func (s *Server) ListCircuits() []models.CircuitState {
+	return s.breaker.List()
+}
+
+// ListTransports returns the connection pools kept for upstream proxies
+func (s *Server) ListTransports() []models.TransportStats {
+	return s.transports.Stats()
+}
+
+// InvalidateProxies drops the cached transports of updated or deleted proxies
+func (s *Server) InvalidateProxies(proxyIDs ...int) {
+	s.transports.Invalidate(proxyIDs...)
+}
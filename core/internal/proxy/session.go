@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// SessionManager pins sticky sessions to upstream proxies in memory
+// A pin expires once it has been idle for the configured TTL
+type SessionManager struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]*models.StickySession
+}
+
+// NewSessionManager creates a new session manager; a ttl <= 0 disables sticky sessions
+func NewSessionManager(ttl time.Duration) *SessionManager {
+	return &SessionManager{
+		ttl:      ttl,
+		sessions: make(map[string]*models.StickySession),
+	}
+}
+
+// SetTTL updates the idle TTL; a ttl <= 0 disables sticky sessions and drops all pins
+func (m *SessionManager) SetTTL(ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ttl = ttl
+	if ttl <= 0 {
+		m.sessions = make(map[string]*models.StickySession)
+	}
+}
+
+// Lookup returns the proxy ID pinned to the session key, if the pin is still live
+func (m *SessionManager) Lookup(key string) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[key]
+	if !ok {
+		return 0, false
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		delete(m.sessions, key)
+		return 0, false
+	}
+
+	return session.ProxyID, true
+}
+
+// Pin pins the session to the proxy (or refreshes an existing pin) and counts the request
+func (m *SessionManager) Pin(key string, opts RouteOptions, proxy *models.Proxy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	session, ok := m.sessions[key]
+	if !ok || session.ProxyID != proxy.ID {
+		session = &models.StickySession{
+			Username:     opts.Username,
+			SessionID:    opts.SessionID,
+			ProxyID:      proxy.ID,
+			ProxyAddress: proxy.Address,
+			CreatedAt:    now,
+		}
+		m.sessions[key] = session
+	}
+
+	session.Requests++
+	session.LastUsed = now
+	session.ExpiresAt = now.Add(m.ttl)
+}
+
+// Unpin removes the session pin so the next request picks a new proxy
+func (m *SessionManager) Unpin(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, key)
+}
+
+// List returns the live sessions, most recently used first
+func (m *SessionManager) List() []models.StickySession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	sessions := make([]models.StickySession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		if now.After(session.ExpiresAt) {
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsed.After(sessions[j].LastUsed)
+	})
+
+	return sessions
+}
+
+// Cleanup removes expired pins
+// Should be called periodically to prevent memory leaks
+func (m *SessionManager) Cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, session := range m.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(m.sessions, key)
+		}
+	}
+}
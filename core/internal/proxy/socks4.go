@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// SOCKS4 protocol constants
+const (
+	socks4Version        = 4
+	socks4CmdConnect     = 1
+	socks4RequestGranted = 90
+)
+
+// socks4Errors describes the SOCKS4 reply codes for rejected requests
+var socks4Errors = map[byte]string{
+	91: "request rejected or failed",
+	92: "request rejected because the SOCKS server cannot connect to identd on the client",
+	93: "request rejected because the client program and identd report different user-ids",
+}
+
+// dialSOCKS4 connects to target (host:port) through a SOCKS4 or SOCKS4a proxy
+// SOCKS4 only carries IPv4 addresses, so host names are resolved locally.
+// SOCKS4a sends host names to the proxy to resolve (remote DNS); IPv4 literals
+// are sent as addresses with either variant. IPv6 targets cannot be reached.
+// The proxy username is sent as the SOCKS4 user id.
+func dialSOCKS4(ctx context.Context, p *models.Proxy, target string, timeout time.Duration) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target address %s: %w", target, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target port %s: %w", portStr, err)
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Resolve the destination the way the protocol variant requires
+	var ip net.IP
+	var hostname string
+	if literal := net.ParseIP(host); literal != nil {
+		if ip = literal.To4(); ip == nil {
+			return nil, fmt.Errorf("%s proxies cannot connect to IPv6 address %s", p.Protocol, host)
+		}
+	} else if p.Protocol == "socks4a" {
+		// 0.0.0.x with x != 0 tells the proxy a host name follows
+		ip = net.IPv4(0, 0, 0, 1).To4()
+		hostname = host
+	} else {
+		ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s for SOCKS4: %w", host, err)
+		}
+		ip = ips[0].To4()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", p.Address, err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to set connection deadline: %w", err)
+		}
+	}
+
+	// Cancelling ctx interrupts the handshake
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := socks4Handshake(conn, p, ip, uint16(port), hostname); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SOCKS4 proxy %s failed to connect to %s: %w", p.Address, target, err)
+	}
+
+	// Clear the deadline after successful connection, unless ctx was cancelled meanwhile
+	if !stop() {
+		conn.Close()
+		return nil, fmt.Errorf("SOCKS4 proxy %s: %w", p.Address, ctx.Err())
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to clear connection deadline: %w", err)
+	}
+
+	return conn, nil
+}
+
+// socks4Handshake sends a CONNECT request and checks the reply
+func socks4Handshake(conn io.ReadWriter, p *models.Proxy, ip net.IP, port uint16, hostname string) error {
+	req := []byte{socks4Version, socks4CmdConnect, byte(port >> 8), byte(port)}
+	req = append(req, ip...)
+	if p.Username != nil {
+		req = append(req, *p.Username...)
+	}
+	req = append(req, 0)
+	if hostname != "" {
+		req = append(req, hostname...)
+		req = append(req, 0)
+	}
+
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// Reply: VN (0), CD, DSTPORT (2), DSTIP (4)
+	reply := make([]byte, 8)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to read reply: %w", err)
+	}
+
+	if reply[1] != socks4RequestGranted {
+		if msg, ok := socks4Errors[reply[1]]; ok {
+			return errors.New(msg)
+		}
+		return fmt.Errorf("unknown reply code %d", reply[1])
+	}
+
+	return nil
+}
+
+// canTunnel reports whether a proxy of the given protocol can open a CONNECT tunnel to host
+func canTunnel(protocol, host string) bool {
+	switch protocol {
+	case "http", "https", "socks5":
+		return true
+	case "socks4", "socks4a":
+		// SOCKS4 addresses are IPv4 only
+		h, _, err := net.SplitHostPort(host)
+		if err != nil {
+			h = host
+		}
+		ip := net.ParseIP(h)
+		return ip == nil || ip.To4() != nil
+	default:
+		return false
+	}
+}
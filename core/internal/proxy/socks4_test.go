@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// socks4Request is a CONNECT request as seen by the fake SOCKS4 server
+type socks4Request struct {
+	port     uint16
+	ip       net.IP
+	userID   string
+	hostname string
+}
+
+// startSOCKS4Server accepts one connection, replies with code and echoes the tunnel
+func startSOCKS4Server(t *testing.T, code byte) (string, <-chan socks4Request) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	requests := make(chan socks4Request, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return
+		}
+		req := socks4Request{
+			port: uint16(header[2])<<8 | uint16(header[3]),
+			ip:   net.IP(header[4:8]),
+		}
+		userID, _ := r.ReadString(0)
+		req.userID = userID[:len(userID)-1]
+		if bytes.Equal(req.ip[:3], []byte{0, 0, 0}) && req.ip[3] != 0 {
+			hostname, _ := r.ReadString(0)
+			req.hostname = hostname[:len(hostname)-1]
+		}
+		requests <- req
+
+		conn.Write([]byte{0, code, 0, 0, 0, 0, 0, 0})
+		if code == socks4RequestGranted {
+			io.Copy(conn, r)
+		}
+	}()
+
+	return ln.Addr().String(), requests
+}
+
+// TestDialSOCKS4a tests that SOCKS4a sends host names for remote resolution with the user id
+func TestDialSOCKS4a(t *testing.T) {
+	addr, requests := startSOCKS4Server(t, socks4RequestGranted)
+	user := "alice"
+	p := &models.Proxy{Address: addr, Protocol: "socks4a", Username: &user}
+
+	conn, err := dialSOCKS4(context.Background(), p, "example.com:443", 5*time.Second)
+	if err != nil {
+		t.Fatalf("dialSOCKS4: %v", err)
+	}
+	defer conn.Close()
+
+	req := <-requests
+	if req.hostname != "example.com" || req.port != 443 || req.userID != "alice" {
+		t.Errorf("request = %+v, want example.com:443 as alice", req)
+	}
+
+	// The connection is a working tunnel after the handshake
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("tunnel echo = %q, %v", buf, err)
+	}
+}
+
+// TestDialSOCKS4 tests that SOCKS4 sends IPv4 addresses and reports rejections
+func TestDialSOCKS4(t *testing.T) {
+	addr, requests := startSOCKS4Server(t, 91)
+	p := &models.Proxy{Address: addr, Protocol: "socks4"}
+
+	_, err := dialSOCKS4(context.Background(), p, "10.1.2.3:8080", 5*time.Second)
+	if err == nil {
+		t.Fatal("dialSOCKS4 succeeded on a rejected request")
+	}
+
+	req := <-requests
+	if !req.ip.Equal(net.IPv4(10, 1, 2, 3)) || req.port != 8080 || req.hostname != "" {
+		t.Errorf("request = %+v, want 10.1.2.3:8080 without host name", req)
+	}
+
+	if _, err := dialSOCKS4(context.Background(), p, "[::1]:443", time.Second); err == nil {
+		t.Error("dialSOCKS4 accepted an IPv6 target")
+	}
+}
+
+// TestCanTunnel tests which protocols can carry CONNECT tunnels
+func TestCanTunnel(t *testing.T) {
+	tests := []struct {
+		protocol string
+		host     string
+		want     bool
+	}{
+		{"http", "example.com:443", true},
+		{"socks5", "[::1]:443", true},
+		{"socks4", "example.com:443", true},
+		{"socks4a", "1.2.3.4:443", true},
+		{"socks4a", "[2001:db8::1]:443", false},
+		{"ftp", "example.com:443", false},
+	}
+
+	for _, tt := range tests {
+		if got := canTunnel(tt.protocol, tt.host); got != tt.want {
+			t.Errorf("canTunnel(%q, %q) = %v, want %v", tt.protocol, tt.host, got, tt.want)
+		}
+	}
+}
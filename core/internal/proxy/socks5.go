@@ -0,0 +1,531 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// SOCKS5 protocol constants (RFC 1928, RFC 1929)
+const (
+	socks5Version = 5
+
+	socks5AuthNone         = 0x00
+	socks5AuthPassword     = 0x02
+	socks5AuthNoAcceptable = 0xff
+	socks5AuthVersion      = 1
+
+	socks5CmdConnect      = 1
+	socks5CmdUDPAssociate = 3
+
+	socks5AddrIPv4   = 1
+	socks5AddrDomain = 3
+	socks5AddrIPv6   = 4
+
+	socks5ReplySucceeded           = 0x00
+	socks5ReplyFailure             = 0x01
+	socks5ReplyNotAllowed          = 0x02
+	socks5ReplyHostUnreachable     = 0x04
+	socks5ReplyCommandNotSupported = 0x07
+	socks5ReplyAddrNotSupported    = 0x08
+)
+
+// socks5HandshakeTimeout bounds the greeting, authentication and request of a SOCKS5 client
+const socks5HandshakeTimeout = 30 * time.Second
+
+// socks5DNSTimeout bounds one DNS query relayed for a UDP ASSOCIATE client
+const socks5DNSTimeout = 10 * time.Second
+
+// SOCKS5Server accepts SOCKS5 clients next to the HTTP proxy port
+// Clients authenticate with the same credentials as the HTTP proxy (username/password
+// auth), are rate limited by the same middleware, and CONNECT requests are routed
+// through the upstream proxies like HTTPS CONNECT, with retries, fallback and usage
+// recording. UDP ASSOCIATE relays DNS queries (port 53) over TCP through an upstream
+// proxy; other UDP traffic is dropped, since upstream proxies cannot carry it.
+type SOCKS5Server struct {
+	port      int
+	auth      *AuthMiddleware
+	rateLimit *RateLimitMiddleware
+	dial      func(ctx context.Context, host string) (net.Conn, int, error)
+	logger    *logger.Logger
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewSOCKS5Server creates a SOCKS5 server that dials through the upstream proxy handler
+func NewSOCKS5Server(port int, handler *UpstreamProxyHandler, auth *AuthMiddleware, rateLimit *RateLimitMiddleware, log *logger.Logger) *SOCKS5Server {
+	return &SOCKS5Server{
+		port:      port,
+		auth:      auth,
+		rateLimit: rateLimit,
+		dial:      handler.ConnectThroughProxyForDial,
+		logger:    log,
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Listen opens the SOCKS5 port
+func (s *SOCKS5Server) Listen() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on SOCKS5 port %d: %w", s.port, err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+	return nil
+}
+
+// Serve accepts clients until the server is shut down
+func (s *SOCKS5Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return fmt.Errorf("SOCKS5 accept failed: %w", err)
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer s.untrack(conn)
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Shutdown stops accepting clients and closes open connections and tunnels
+func (s *SOCKS5Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
+}
+
+// track registers an open client connection, unless the server is shutting down
+func (s *SOCKS5Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+// untrack closes a client connection and forgets it
+func (s *SOCKS5Server) untrack(conn net.Conn) {
+	conn.Close()
+
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+// serveConn runs one SOCKS5 session
+func (s *SOCKS5Server) serveConn(conn net.Conn) {
+	if err := conn.SetDeadline(time.Now().Add(socks5HandshakeTimeout)); err != nil {
+		return
+	}
+
+	req, err := s.authenticate(conn)
+	if err != nil {
+		s.logger.Warn("SOCKS5 authentication failed",
+			"source", "proxy",
+			"client_addr", conn.RemoteAddr().String(),
+			"error", err,
+		)
+		return
+	}
+
+	// Request: VER CMD RSV ATYP DST.ADDR DST.PORT
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if header[0] != socks5Version {
+		return
+	}
+	target, err := readSOCKS5Addr(conn)
+	if err != nil {
+		writeSOCKS5Reply(conn, socks5ReplyAddrNotSupported, nil)
+		return
+	}
+
+	switch header[1] {
+	case socks5CmdConnect:
+		s.handleConnect(conn, req, target)
+	case socks5CmdUDPAssociate:
+		s.handleUDPAssociate(conn, req, target)
+	default:
+		writeSOCKS5Reply(conn, socks5ReplyCommandNotSupported, nil)
+	}
+}
+
+// authenticate negotiates the auth method and checks the credentials with the
+// HTTP proxy's auth middleware
+// It returns a CONNECT request standing in for the client, carrying the
+// authenticated client and routing options for the rate limiter and the handler.
+func (s *SOCKS5Server) authenticate(conn net.Conn) (*http.Request, error) {
+	// Greeting: VER NMETHODS METHODS...
+	greeting := make([]byte, 2)
+	if _, err := io.ReadFull(conn, greeting); err != nil {
+		return nil, fmt.Errorf("failed to read greeting: %w", err)
+	}
+	if greeting[0] != socks5Version {
+		return nil, fmt.Errorf("unsupported SOCKS version %d", greeting[0])
+	}
+	methods := make([]byte, greeting[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return nil, fmt.Errorf("failed to read auth methods: %w", err)
+	}
+
+	// Username/password auth is preferred even when authentication is disabled,
+	// so the username can carry routing parameters (e.g. "-session-<id>")
+	method := byte(socks5AuthNoAcceptable)
+	if bytes.IndexByte(methods, socks5AuthPassword) >= 0 {
+		method = socks5AuthPassword
+	} else if bytes.IndexByte(methods, socks5AuthNone) >= 0 && !s.auth.Required() {
+		method = socks5AuthNone
+	}
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return nil, err
+	}
+	if method == socks5AuthNoAcceptable {
+		return nil, errors.New("client offered no acceptable auth method")
+	}
+
+	req := &http.Request{
+		Method:     http.MethodConnect,
+		URL:        &url.URL{},
+		Header:     make(http.Header),
+		RemoteAddr: conn.RemoteAddr().String(),
+	}
+	req = req.WithContext(context.Background())
+
+	if method == socks5AuthPassword {
+		// Sub-negotiation: VER ULEN UNAME PLEN PASSWD
+		username, password, err := readSOCKS5Credentials(conn)
+		if err != nil {
+			return nil, err
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	req, resp := s.auth.HandleConnect(req, nil)
+	if method == socks5AuthPassword {
+		status := byte(0x00)
+		if resp != nil {
+			status = 0x01
+		}
+		if _, err := conn.Write([]byte{socks5AuthVersion, status}); err != nil {
+			return nil, err
+		}
+	}
+	if resp != nil {
+		return nil, errors.New("invalid credentials")
+	}
+
+	return req, nil
+}
+
+// handleConnect opens a tunnel through an upstream proxy and relays it
+func (s *SOCKS5Server) handleConnect(conn net.Conn, req *http.Request, target string) {
+	if _, resp := s.rateLimit.HandleConnect(req, nil); resp != nil {
+		writeSOCKS5Reply(conn, socks5ReplyNotAllowed, nil)
+		return
+	}
+
+	// Selection, retries and usage recording are shared with HTTPS CONNECT
+	upstream, proxyID, err := s.dial(req.Context(), target)
+	if err != nil {
+		s.logger.Warn("SOCKS5 CONNECT failed",
+			"source", "proxy",
+			"host", target,
+			"error", err,
+		)
+		writeSOCKS5Reply(conn, socks5ReplyHostUnreachable, nil)
+		return
+	}
+	defer upstream.Close()
+
+	if err := writeSOCKS5Reply(conn, socks5ReplySucceeded, nil); err != nil {
+		return
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	s.logger.Info("SOCKS5 tunnel established",
+		"source", "proxy",
+		"proxy_id", proxyID,
+		"host", target,
+	)
+
+	relayConns(conn, upstream)
+}
+
+// handleUDPAssociate relays the client's DNS queries until its control connection closes
+func (s *SOCKS5Server) handleUDPAssociate(conn net.Conn, req *http.Request, expected string) {
+	local, ok := conn.LocalAddr().(*net.TCPAddr)
+	if !ok {
+		writeSOCKS5Reply(conn, socks5ReplyFailure, nil)
+		return
+	}
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: local.IP})
+	if err != nil {
+		s.logger.Error("failed to open SOCKS5 UDP relay", "source", "proxy", "error", err)
+		writeSOCKS5Reply(conn, socks5ReplyFailure, nil)
+		return
+	}
+	defer udp.Close()
+
+	if err := writeSOCKS5Reply(conn, socks5ReplySucceeded, udp.LocalAddr()); err != nil {
+		return
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	// The association ends when the control connection closes
+	go func() {
+		io.Copy(io.Discard, conn)
+		udp.Close()
+	}()
+
+	// Only datagrams from the client's address are relayed; a port given in the
+	// request restricts the source port too
+	var clientIP net.IP
+	if remote, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		clientIP = remote.IP
+	}
+	expectedPort := 0
+	if _, port, err := net.SplitHostPort(expected); err == nil {
+		expectedPort, _ = strconv.Atoi(port)
+	}
+
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := udp.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if !from.IP.Equal(clientIP) || (expectedPort != 0 && from.Port != expectedPort) {
+			continue
+		}
+
+		// Datagram: RSV(2) FRAG ATYP DST.ADDR DST.PORT DATA
+		if n < 4 || buf[2] != 0 {
+			continue // fragmented datagrams are not supported
+		}
+		r := bytes.NewReader(buf[3:n])
+		target, err := readSOCKS5Addr(r)
+		if err != nil {
+			continue
+		}
+		header := append([]byte(nil), buf[:n-r.Len()]...)
+		query := append([]byte(nil), buf[n-r.Len():n]...)
+
+		if _, port, _ := net.SplitHostPort(target); port != "53" {
+			s.logger.Debug("dropping SOCKS5 UDP datagram to non-DNS port",
+				"source", "proxy",
+				"host", target,
+			)
+			continue
+		}
+
+		go s.relayDNS(req, udp, from, target, header, query)
+	}
+}
+
+// relayDNS sends one DNS query over TCP through an upstream proxy and returns the
+// answer to the client in a SOCKS5 UDP datagram
+func (s *SOCKS5Server) relayDNS(req *http.Request, udp *net.UDPConn, client *net.UDPAddr, target string, header, query []byte) {
+	if _, resp := s.rateLimit.HandleConnect(req, nil); resp != nil {
+		return
+	}
+
+	upstream, _, err := s.dial(req.Context(), target)
+	if err != nil {
+		s.logger.Warn("SOCKS5 DNS relay failed",
+			"source", "proxy",
+			"host", target,
+			"error", err,
+		)
+		return
+	}
+	defer upstream.Close()
+
+	if err := upstream.SetDeadline(time.Now().Add(socks5DNSTimeout)); err != nil {
+		return
+	}
+
+	// DNS over TCP prefixes each message with its length
+	msg := make([]byte, 2, 2+len(query))
+	binary.BigEndian.PutUint16(msg, uint16(len(query)))
+	if _, err := upstream.Write(append(msg, query...)); err != nil {
+		return
+	}
+
+	length := make([]byte, 2)
+	if _, err := io.ReadFull(upstream, length); err != nil {
+		return
+	}
+	answer := make([]byte, binary.BigEndian.Uint16(length))
+	if _, err := io.ReadFull(upstream, answer); err != nil {
+		return
+	}
+
+	udp.WriteToUDP(append(header, answer...), client)
+}
+
+// relayConns copies between the client and the upstream until both directions are done
+// A finished direction half-closes its destination; a failed one closes both ends.
+func relayConns(client, upstream net.Conn) {
+	var wg sync.WaitGroup
+	copyAndCloseWrite := func(dst, src net.Conn) {
+		defer wg.Done()
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			src.Close()
+			return
+		}
+		if c, ok := dst.(halfCloser); ok {
+			c.CloseWrite()
+		} else {
+			dst.Close()
+		}
+	}
+
+	wg.Add(2)
+	go copyAndCloseWrite(upstream, client)
+	go copyAndCloseWrite(client, upstream)
+	wg.Wait()
+}
+
+// readSOCKS5Credentials reads a username/password sub-negotiation
+func readSOCKS5Credentials(r io.Reader) (string, string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", "", fmt.Errorf("failed to read credentials: %w", err)
+	}
+	if header[0] != socks5AuthVersion {
+		return "", "", fmt.Errorf("unsupported auth version %d", header[0])
+	}
+	username := make([]byte, header[1])
+	if _, err := io.ReadFull(r, username); err != nil {
+		return "", "", fmt.Errorf("failed to read username: %w", err)
+	}
+
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(r, length); err != nil {
+		return "", "", fmt.Errorf("failed to read password: %w", err)
+	}
+	password := make([]byte, length[0])
+	if _, err := io.ReadFull(r, password); err != nil {
+		return "", "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	return string(username), string(password), nil
+}
+
+// readSOCKS5Addr reads ATYP DST.ADDR DST.PORT and returns it as host:port
+func readSOCKS5Addr(r io.Reader) (string, error) {
+	atyp := make([]byte, 1)
+	if _, err := io.ReadFull(r, atyp); err != nil {
+		return "", err
+	}
+
+	var host string
+	switch atyp[0] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		size := net.IPv4len
+		if atyp[0] == socks5AddrIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socks5AddrDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return "", err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("unsupported address type %d", atyp[0])
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// writeSOCKS5Reply sends a reply with the bound address, or 0.0.0.0:0 if addr is nil
+func writeSOCKS5Reply(w io.Writer, code byte, addr net.Addr) error {
+	ip := net.IPv4zero.To4()
+	port := 0
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		ip = udpAddr.IP
+		port = udpAddr.Port
+	}
+
+	reply := []byte{socks5Version, code, 0}
+	if ip4 := ip.To4(); ip4 != nil {
+		reply = append(reply, socks5AddrIPv4)
+		reply = append(reply, ip4...)
+	} else {
+		reply = append(reply, socks5AddrIPv6)
+		reply = append(reply, ip.To16()...)
+	}
+	reply = binary.BigEndian.AppendUint16(reply, uint16(port))
+
+	_, err := w.Write(reply)
+	return err
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	proxyDialer "golang.org/x/net/proxy"
+)
+
+// startSOCKS5Server runs a SOCKS5 server requiring alice/secret whose upstream
+// dials are answered by upstream
+func startSOCKS5Server(t *testing.T, upstream func(host string, conn net.Conn)) string {
+	t.Helper()
+
+	log := logger.New("error")
+	auth := NewAuthMiddleware(models.AuthenticationSettings{Enabled: true, Username: "alice", Password: "secret"}, NewClientRegistry(nil, nil, log))
+	s := &SOCKS5Server{
+		auth:      auth,
+		rateLimit: NewRateLimitMiddleware(models.RateLimitSettings{}),
+		dial: func(ctx context.Context, host string) (net.Conn, int, error) {
+			client, server := net.Pipe()
+			go upstream(host, server)
+			return client, 1, nil
+		},
+		logger: log,
+		conns:  make(map[net.Conn]struct{}),
+	}
+
+	if err := s.Listen(); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go s.Serve()
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// TestSOCKS5Connect tests authenticated CONNECT tunnels
+func TestSOCKS5Connect(t *testing.T) {
+	addr := startSOCKS5Server(t, func(host string, conn net.Conn) {
+		defer conn.Close()
+		io.WriteString(conn, host+" ")
+		io.Copy(conn, conn)
+	})
+
+	dialer, _ := proxyDialer.SOCKS5("tcp", addr, &proxyDialer.Auth{User: "alice", Password: "secret"}, proxyDialer.Direct)
+	conn, err := dialer.Dial("tcp", "example.com:443")
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	io.WriteString(conn, "ping")
+	buf := make([]byte, len("example.com:443 ping"))
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "example.com:443 ping" {
+		t.Errorf("tunnel = %q, %v", buf, err)
+	}
+
+	wrong, _ := proxyDialer.SOCKS5("tcp", addr, &proxyDialer.Auth{User: "alice", Password: "wrong"}, proxyDialer.Direct)
+	if _, err := wrong.Dial("tcp", "example.com:443"); err == nil {
+		t.Error("Dial succeeded with a wrong password")
+	}
+
+	anonymous, _ := proxyDialer.SOCKS5("tcp", addr, nil, proxyDialer.Direct)
+	if _, err := anonymous.Dial("tcp", "example.com:443"); err == nil {
+		t.Error("Dial succeeded without credentials")
+	}
+}
+
+// TestSOCKS5UDPAssociateDNS tests that DNS datagrams are relayed over TCP upstreams
+func TestSOCKS5UDPAssociateDNS(t *testing.T) {
+	addr := startSOCKS5Server(t, func(host string, conn net.Conn) {
+		defer conn.Close()
+		length := make([]byte, 2)
+		io.ReadFull(conn, length)
+		query := make([]byte, binary.BigEndian.Uint16(length))
+		io.ReadFull(conn, query)
+
+		answer := append([]byte("answer from "+host+" to "), query...)
+		conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(answer))))
+		conn.Write(answer)
+	})
+
+	control, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer control.Close()
+	control.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Greeting, username/password auth, then UDP ASSOCIATE for any client port
+	control.Write([]byte{5, 1, socks5AuthPassword})
+	control.Write(append(append([]byte{1, 5}, "alice"...), append([]byte{6}, "secret"...)...))
+	control.Write([]byte{5, socks5CmdUDPAssociate, 0, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+
+	reply := make([]byte, 2+2+10)
+	if _, err := io.ReadFull(control, reply); err != nil {
+		t.Fatalf("read replies: %v", err)
+	}
+	if reply[1] != socks5AuthPassword || reply[3] != 0 || reply[5] != socks5ReplySucceeded {
+		t.Fatalf("replies = %v, want accepted auth and UDP ASSOCIATE", reply)
+	}
+	relay := &net.UDPAddr{IP: net.IP(reply[8:12]), Port: int(binary.BigEndian.Uint16(reply[12:14]))}
+
+	udp, err := net.DialUDP("udp", nil, relay)
+	if err != nil {
+		t.Fatalf("dial relay: %v", err)
+	}
+	defer udp.Close()
+	udp.SetDeadline(time.Now().Add(5 * time.Second))
+
+	header := []byte{0, 0, 0, socks5AddrIPv4, 1, 1, 1, 1, 0, 53}
+	udp.Write(append(header, "query"...))
+
+	buf := make([]byte, 512)
+	n, err := udp.Read(buf)
+	if err != nil {
+		t.Fatalf("read answer: %v", err)
+	}
+	if !bytes.Equal(buf[:len(header)], header) || string(buf[len(header):n]) != "answer from 1.1.1.1:53 to query" {
+		t.Errorf("datagram = %q, want the answer behind the request header", buf[:n])
+	}
+}
@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// proxyTLSHandshakeTimeout bounds the TLS handshake with an https proxy when no other timeout applies
+const proxyTLSHandshakeTimeout = 30 * time.Second
+
+// proxyTLSConfig returns the TLS config for the connection to an https proxy itself
+// The certificate is verified against the proxy's CA bundle, or the system roots if
+// it has none, for the proxy's TLS server name, or the host of its address.
+func proxyTLSConfig(p *models.Proxy) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if p.TLSServerName != nil && *p.TLSServerName != "" {
+		config.ServerName = *p.TLSServerName
+	} else {
+		host, _, err := net.SplitHostPort(p.Address)
+		if err != nil {
+			host = p.Address
+		}
+		config.ServerName = host
+	}
+
+	if p.CABundle != nil && *p.CABundle != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(*p.CABundle)) {
+			return nil, errors.New("CA bundle contains no valid PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// dialProxyTLS connects to an https proxy and completes the TLS handshake
+func dialProxyTLS(ctx context.Context, p *models.Proxy, timeout time.Duration) (net.Conn, error) {
+	config, err := proxyTLSConfig(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS settings for proxy %s: %w", p.Address, err)
+	}
+
+	if timeout <= 0 {
+		timeout = proxyTLSHandshakeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return nil, fmt.Errorf("TLS connection to proxy %s failed: %w", p.Address, err)
+	}
+
+	return conn, nil
+}
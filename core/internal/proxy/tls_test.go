@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// newTLSProxy starts an https forward proxy that answers every request itself
+func newTLSProxy(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied "+r.URL.String())
+	}))
+	t.Cleanup(server.Close)
+
+	bundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	return server, bundle
+}
+
+// TestDialProxyTLS tests certificate verification against the proxy's CA bundle and server name
+func TestDialProxyTLS(t *testing.T) {
+	server, bundle := newTLSProxy(t)
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	// The test certificate is valid for example.com, not for some.other.host
+	serverName := "example.com"
+	p := &models.Proxy{Address: address, Protocol: "https", TLSServerName: &serverName, CABundle: &bundle}
+	conn, err := dialProxyTLS(context.Background(), p, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dialProxyTLS with CA bundle: %v", err)
+	}
+	conn.Close()
+
+	wrongName := "some.other.host"
+	p.TLSServerName = &wrongName
+	if _, err := dialProxyTLS(context.Background(), p, 5*time.Second); err == nil {
+		t.Error("dialProxyTLS accepted a certificate for another server name")
+	}
+
+	p.TLSServerName, p.CABundle = nil, nil
+	if _, err := dialProxyTLS(context.Background(), p, 5*time.Second); err == nil {
+		t.Error("dialProxyTLS trusted a certificate outside the system roots")
+	}
+}
+
+// TestCreateProxyTransportHTTPS tests that requests reach an https proxy over TLS
+func TestCreateProxyTransportHTTPS(t *testing.T) {
+	server, bundle := newTLSProxy(t)
+
+	p := &models.Proxy{Address: strings.TrimPrefix(server.URL, "https://"), Protocol: "https", CABundle: &bundle}
+	transport, err := CreateProxyTransport(p)
+	if err != nil {
+		t.Fatalf("CreateProxyTransport: %v", err)
+	}
+	defer transport.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: transport}).Get("http://target.test/ip")
+	if err != nil {
+		t.Fatalf("GET through https proxy: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "proxied http://target.test/ip" {
+		t.Errorf("body = %q, want the proxy's answer for the absolute URL", body)
+	}
+
+	invalid := "not a certificate"
+	p.CABundle = &invalid
+	if _, err := CreateProxyTransport(p); err == nil {
+		t.Error("CreateProxyTransport accepted an invalid CA bundle")
+	}
+}
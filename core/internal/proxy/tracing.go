@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes of the proxy request lifecycle
+const (
+	attrProxyID       = attribute.Key("rota.proxy.id")
+	attrProxyProtocol = attribute.Key("rota.proxy.protocol")
+	attrFallbackIndex = attribute.Key("rota.fallback.index") // 0 for the first proxy tried
+	attrAttempt       = attribute.Key("rota.attempt")        // 1 for the first try through a proxy
+	attrClientID      = attribute.Key("rota.client.id")
+	attrRoute         = attribute.Key("rota.route")
+	attrPool          = attribute.Key("rota.pool")
+	attrBlockedReason = attribute.Key("rota.blocked.reason")
+)
+
+// tracer creates the spans of proxied requests
+var tracer = tracing.Tracer()
+
+// startRequestSpan starts the server span of a proxied request, continuing the
+// client's trace if it sent a traceparent
+func startRequestSpan(ctx context.Context, name string, req *http.Request, host string) (context.Context, trace.Span) {
+	ctx = tracing.Extract(ctx, req.Header)
+
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.ServerAddress(host),
+	}
+	if client := ClientFromContext(req.Context()); client != nil {
+		attrs = append(attrs, attrClientID.Int(client.ID))
+	}
+	opts := RouteOptionsFromContext(req.Context())
+	if opts.Route != "" {
+		attrs = append(attrs, attrRoute.String(opts.Route))
+	}
+	if opts.Pool != "" {
+		attrs = append(attrs, attrPool.String(opts.Pool))
+	}
+
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// startUpstreamSpan starts the span of using one upstream proxy for a request
+func startUpstreamSpan(ctx context.Context, p *models.Proxy, fallbackIndex int) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "proxy.upstream", trace.WithAttributes(
+		attrProxyID.Int(p.ID),
+		attrProxyProtocol.String(p.Protocol),
+		attrFallbackIndex.Int(fallbackIndex),
+	))
+}
+
+// endSpan marks the span failed if err is set and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// withClientTrace adds events for the connection, TLS handshake and first
+// response byte of an upstream request to the span in ctx
+func withClientTrace(ctx context.Context) context.Context {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return ctx
+	}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			span.AddEvent("connect_start", trace.WithAttributes(semconv.NetworkPeerAddress(addr)))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			span.AddEvent("connect_done", trace.WithAttributes(attribute.Bool("error", err != nil)))
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.AddEvent("got_conn", trace.WithAttributes(attribute.Bool("reused", info.Reused)))
+		},
+		TLSHandshakeStart: func() {
+			span.AddEvent("tls_handshake_start")
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			span.AddEvent("tls_handshake_done", trace.WithAttributes(attribute.Bool("error", err != nil)))
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			span.AddEvent("wrote_request")
+		},
+		GotFirstResponseByte: func() {
+			span.AddEvent("first_byte")
+		},
+	})
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/internal/tracing"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestTracing tests that a request's spans reach an OTLP collector in the
+// client's trace and that the trace is propagated to the target
+func TestTracing(t *testing.T) {
+	var mu sync.Mutex
+	var spans []*tracepb.Span
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var export collectortrace.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &export); err != nil {
+			t.Errorf("collector: %v", err)
+		}
+
+		mu.Lock()
+		for _, rs := range export.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	shutdown, err := tracing.Setup(context.Background(), collector.URL)
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	p := &models.Proxy{ID: 7, Address: strings.TrimPrefix(upstream.URL, "http://"), Protocol: "http"}
+	settings := &models.RotationSettings{Retries: 1, Timeout: 5}
+	h := NewUpstreamProxyHandler(newTestSelector(settings, p), nil, NewSessionManager(0), NewCircuitBreaker(models.CircuitBreakerSettings{}),
+		NewBlockList(), NewConnectionTracker(), NewTransportCache(), nil, settings, logger.New("error"))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	req.Header.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	ctx, span := startRequestSpan(context.Background(), "proxy.request", req, "example.com")
+	resp, _, err := h.sendWithRetry(req, ctx)
+	if err != nil {
+		t.Fatalf("sendWithRetry: %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if !strings.HasPrefix(traceparent, "00-"+traceID+"-") {
+		t.Errorf("target received traceparent %q, want trace %s", traceparent, traceID)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	byName := make(map[string]*tracepb.Span)
+	for _, s := range spans {
+		if got := hex.EncodeToString(s.TraceId); got != traceID {
+			t.Errorf("span %s in trace %s, want %s", s.Name, got, traceID)
+		}
+		byName[s.Name] = s
+	}
+	for _, name := range []string{"proxy.request", "proxy.select", "proxy.upstream", "proxy.attempt", "proxy.transport"} {
+		if byName[name] == nil {
+			t.Errorf("span %s not exported", name)
+		}
+	}
+
+	if s := byName["proxy.upstream"]; s != nil && intAttr(s, "rota.proxy.id") != 7 {
+		t.Errorf("proxy.upstream rota.proxy.id = %d, want 7", intAttr(s, "rota.proxy.id"))
+	}
+	if s := byName["proxy.attempt"]; s != nil && intAttr(s, "rota.attempt") != 1 {
+		t.Errorf("proxy.attempt rota.attempt = %d, want 1", intAttr(s, "rota.attempt"))
+	}
+}
+
+// intAttr returns an int attribute of an exported span, or -1 if it is missing
+func intAttr(s *tracepb.Span, key string) int64 {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return kv.Value.GetIntValue()
+		}
+	}
+	return -1
+}
@@ -1,14 +1,15 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/alpkeskin/rota/core/internal/models"
-	"h12.io/socks"
 	proxyDialer "golang.org/x/net/proxy"
 )
 
@@ -64,10 +65,21 @@ func CreateProxyTransport(p *models.Proxy) (*http.Transport, error) {
 	case "http", "https":
 		// Set proxy URL - http.Transport will handle authentication headers automatically
 		transport.Proxy = http.ProxyURL(parsedURL)
+		if p.Protocol == "https" {
+			// With an https proxy the transport uses this to dial the proxy itself;
+			// TLSClientConfig still applies to https targets inside the tunnel
+			if _, err := proxyTLSConfig(p); err != nil {
+				return nil, fmt.Errorf("invalid TLS settings for proxy %s: %w", authMasked, err)
+			}
+			transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialProxyTLS(ctx, p, 0)
+			}
+		}
 	case "socks4", "socks4a":
-		// Create SOCKS4/SOCKS4A dialer using h12.io/socks
-		// The Dial function accepts URI format: socks4://[user@]host:port
-		transport.Dial = socks.Dial(proxyURL)
+		// SOCKS4 resolves target hosts locally, SOCKS4A sends them to the proxy
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialSOCKS4(ctx, p, addr, 0)
+		}
 	case "socks5":
 		// Create SOCKS5 dialer
 		var auth *proxyDialer.Auth
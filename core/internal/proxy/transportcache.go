@@ -0,0 +1,229 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+const (
+	// Idle connections kept per upstream proxy, in total and per target host
+	transportMaxIdleConns        = 32
+	transportMaxIdleConnsPerHost = 8
+
+	// transportIdleTTL is how long an unused transport is cached; longer than
+	// IdleConnTimeout, so its idle connections are closed by then
+	transportIdleTTL = 10 * time.Minute
+)
+
+// transportKey identifies the proxy settings a transport was built from
+// A transport is rebuilt when any of them change.
+type transportKey struct {
+	protocol      string
+	address       string
+	username      string
+	password      string
+	tlsServerName string
+	caBundle      string
+}
+
+// newTransportKey returns the key of the proxy's current settings
+func newTransportKey(p *models.Proxy) transportKey {
+	return transportKey{
+		protocol:      p.Protocol,
+		address:       p.Address,
+		username:      stringValue(p.Username),
+		password:      stringValue(p.Password),
+		tlsServerName: stringValue(p.TLSServerName),
+		caBundle:      stringValue(p.CABundle),
+	}
+}
+
+// stringValue returns the string pointed to, or "" for nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// cachedTransport is a transport with its connection pool counters
+type cachedTransport struct {
+	key       transportKey
+	transport *http.Transport
+	createdAt time.Time
+	lastUsed  time.Time // guarded by TransportCache.mu
+
+	open     atomic.Int64
+	dials    atomic.Int64
+	requests atomic.Int64
+}
+
+// TransportCache keeps one transport per upstream proxy, so keep-alive
+// connections to the proxy are reused across requests
+type TransportCache struct {
+	mu      sync.Mutex
+	entries map[int]*cachedTransport // keyed by proxy ID
+	now     func() time.Time
+}
+
+// NewTransportCache creates an empty transport cache
+func NewTransportCache() *TransportCache {
+	return &TransportCache{
+		entries: make(map[int]*cachedTransport),
+		now:     time.Now,
+	}
+}
+
+// Get returns the proxy's transport, creating it on first use or when the
+// proxy's address, credentials or TLS settings changed
+func (c *TransportCache) Get(p *models.Proxy) (*http.Transport, error) {
+	key := newTransportKey(p)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[p.ID]
+	if !ok || entry.key != key {
+		transport, err := CreateProxyTransport(p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entry.transport.CloseIdleConnections()
+		}
+
+		entry = &cachedTransport{key: key, transport: transport, createdAt: c.now()}
+		entry.countConnections()
+		c.entries[p.ID] = entry
+	}
+
+	entry.lastUsed = c.now()
+	entry.requests.Add(1)
+	return entry.transport, nil
+}
+
+// Invalidate drops the transports of updated or deleted proxies and closes
+// their idle connections; requests in flight finish on the old connections
+func (c *TransportCache) Invalidate(proxyIDs ...int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, id := range proxyIDs {
+		if entry, ok := c.entries[id]; ok {
+			entry.transport.CloseIdleConnections()
+			delete(c.entries, id)
+		}
+	}
+}
+
+// Cleanup drops transports unused for transportIdleTTL
+func (c *TransportCache) Cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := c.now().Add(-transportIdleTTL)
+	for id, entry := range c.entries {
+		if entry.lastUsed.Before(cutoff) {
+			entry.transport.CloseIdleConnections()
+			delete(c.entries, id)
+		}
+	}
+}
+
+// Close closes the idle connections of every transport and empties the cache
+func (c *TransportCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, entry := range c.entries {
+		entry.transport.CloseIdleConnections()
+		delete(c.entries, id)
+	}
+}
+
+// Stats returns the connection pool of every cached transport, by proxy ID
+func (c *TransportCache) Stats() []models.TransportStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := make([]models.TransportStats, 0, len(c.entries))
+	for id, entry := range c.entries {
+		stats = append(stats, models.TransportStats{
+			ProxyID:         id,
+			ProxyAddress:    entry.key.address,
+			OpenConnections: entry.open.Load(),
+			Dials:           entry.dials.Load(),
+			Requests:        entry.requests.Load(),
+			CreatedAt:       entry.createdAt,
+			LastUsed:        entry.lastUsed,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ProxyID < stats[j].ProxyID
+	})
+	return stats
+}
+
+// countConnections bounds the transport's idle pool and wraps its dialers to
+// count the connections opened to the proxy
+func (e *cachedTransport) countConnections() {
+	t := e.transport
+	t.MaxIdleConns = transportMaxIdleConns
+	t.MaxIdleConnsPerHost = transportMaxIdleConnsPerHost
+
+	dial := t.DialContext
+	if t.Dial != nil {
+		legacyDial := t.Dial
+		dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return legacyDial(network, addr)
+		}
+		t.Dial = nil
+	}
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+	}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return e.track(dial(ctx, network, addr))
+	}
+
+	// https proxies are dialed with their own TLS dialer
+	if dialTLS := t.DialTLSContext; dialTLS != nil {
+		t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return e.track(dialTLS(ctx, network, addr))
+		}
+	}
+}
+
+// track counts a newly dialed connection until it is closed
+func (e *cachedTransport) track(conn net.Conn, err error) (net.Conn, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	e.dials.Add(1)
+	e.open.Add(1)
+	return &countedConn{Conn: conn, open: &e.open}, nil
+}
+
+// countedConn decrements the open connection count once when closed
+type countedConn struct {
+	net.Conn
+	open   *atomic.Int64
+	closed atomic.Bool
+}
+
+// Close closes the connection
+func (c *countedConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		c.open.Add(-1)
+	}
+	return c.Conn.Close()
+}
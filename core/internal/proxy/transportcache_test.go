@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// newTestProxyServer starts an HTTP proxy answering every request itself
+func newTestProxyServer(tb testing.TB) *models.Proxy {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	tb.Cleanup(srv.Close)
+
+	return &models.Proxy{ID: 1, Address: strings.TrimPrefix(srv.URL, "http://"), Protocol: "http"}
+}
+
+// doTestRequest sends a request through the transport and drains the response
+func doTestRequest(tb testing.TB, transport *http.Transport) {
+	resp, err := (&http.Client{Transport: transport}).Get("http://example.com/")
+	if err != nil {
+		tb.Fatalf("request: %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// TestTransportCache tests connection reuse, rebuilding on credential changes
+// and invalidation
+func TestTransportCache(t *testing.T) {
+	cache := NewTransportCache()
+	defer cache.Close()
+	p := newTestProxyServer(t)
+
+	first, err := cache.Get(p)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	doTestRequest(t, first)
+	second, _ := cache.Get(p)
+	if second != first {
+		t.Fatal("Get returned a new transport for an unchanged proxy")
+	}
+	doTestRequest(t, second)
+
+	stats := cache.Stats()
+	if len(stats) != 1 || stats[0].Requests != 2 || stats[0].Dials != 1 || stats[0].OpenConnections != 1 {
+		t.Fatalf("Stats() = %+v, want 2 requests over 1 open connection", stats)
+	}
+
+	username := "user"
+	changed := *p
+	changed.Username = &username
+	if rebuilt, _ := cache.Get(&changed); rebuilt == first {
+		t.Error("Get reused the transport after the credentials changed")
+	}
+
+	cache.Invalidate(p.ID)
+	if stats := cache.Stats(); len(stats) != 0 {
+		t.Errorf("Stats() after Invalidate = %+v, want none", stats)
+	}
+}
+
+// BenchmarkProxyTransport compares a transport per request attempt with the
+// cached transport
+func BenchmarkProxyTransport(b *testing.B) {
+	p := newTestProxyServer(b)
+
+	b.Run("per_attempt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			transport, err := CreateProxyTransport(p)
+			if err != nil {
+				b.Fatalf("CreateProxyTransport: %v", err)
+			}
+			doTestRequest(b, transport)
+			transport.CloseIdleConnections()
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		cache := NewTransportCache()
+		defer cache.Close()
+
+		for i := 0; i < b.N; i++ {
+			transport, err := cache.Get(p)
+			if err != nil {
+				b.Fatalf("Get: %v", err)
+			}
+			doTestRequest(b, transport)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tunnel close reasons, stored in proxy_requests.close_reason
+const (
+	CloseReasonClient   = "client_closed"   // the client side finished first
+	CloseReasonUpstream = "upstream_closed" // the upstream proxy or target closed the connection
+	CloseReasonTimeout  = "timeout"         // a read or write deadline expired
+	CloseReasonError    = "upstream_error"  // reading from or writing to the upstream failed
+)
+
+// connStats describes a connection once it is closed
+type connStats struct {
+	BytesSent     int64 // written to the upstream
+	BytesReceived int64 // read from the upstream
+	Duration      time.Duration
+	CloseReason   string
+}
+
+// halfCloser is implemented by TCP connections; goproxy half-closes tunnels when both ends support it
+type halfCloser interface {
+	CloseRead() error
+	CloseWrite() error
+}
+
+// trackedConn counts the bytes through an upstream connection and reports them
+// to onClose once, when the connection is closed
+type trackedConn struct {
+	net.Conn
+	started  time.Time
+	sent     atomic.Int64
+	received atomic.Int64
+
+	mu      sync.Mutex
+	reason  string // first event that ended the tunnel
+	once    sync.Once
+	onClose func(stats connStats)
+}
+
+// Read reads from the upstream and counts the bytes
+func (c *trackedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.received.Add(int64(n))
+	if err != nil {
+		c.setReason(closeReason(err))
+	}
+	return n, err
+}
+
+// Write writes to the upstream and counts the bytes
+func (c *trackedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.sent.Add(int64(n))
+	if err != nil {
+		c.setReason(closeReason(err))
+	}
+	return n, err
+}
+
+// Close closes the connection and reports its stats once
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.setReason(CloseReasonClient)
+	c.once.Do(func() {
+		c.mu.Lock()
+		reason := c.reason
+		c.mu.Unlock()
+
+		c.onClose(connStats{
+			BytesSent:     c.sent.Load(),
+			BytesReceived: c.received.Load(),
+			Duration:      time.Since(c.started),
+			CloseReason:   reason,
+		})
+	})
+	return err
+}
+
+// setReason records why the tunnel ended, unless an earlier event already did
+func (c *trackedConn) setReason(reason string) {
+	c.mu.Lock()
+	if c.reason == "" {
+		c.reason = reason
+	}
+	c.mu.Unlock()
+}
+
+// closeReason classifies a read or write error
+func closeReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, io.EOF):
+		return CloseReasonUpstream
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return CloseReasonTimeout
+	case errors.Is(err, net.ErrClosed):
+		// Closed locally while a copy was still running
+		return CloseReasonClient
+	default:
+		return CloseReasonError
+	}
+}
+
+// trackedHalfCloser is a trackedConn over a connection that supports half-close
+type trackedHalfCloser struct {
+	*trackedConn
+}
+
+// CloseRead shuts down the reading side of the connection
+func (c trackedHalfCloser) CloseRead() error {
+	return c.Conn.(halfCloser).CloseRead()
+}
+
+// CloseWrite shuts down the writing side of the connection
+// goproxy calls it once the client has finished sending.
+func (c trackedHalfCloser) CloseWrite() error {
+	c.setReason(CloseReasonClient)
+	return c.Conn.(halfCloser).CloseWrite()
+}
+
+// trackConn wraps conn so onClose gets its stats when it is closed, keeping half-close support
+func trackConn(conn net.Conn, onClose func(stats connStats)) net.Conn {
+	tracked := &trackedConn{Conn: conn, started: time.Now(), onClose: onClose}
+	if _, ok := conn.(halfCloser); ok {
+		return trackedHalfCloser{tracked}
+	}
+	return tracked
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// TestTrackConnHalfClose tests that tracked TCP connections stay half-closable for goproxy
+func TestTrackConnHalfClose(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	closed := 0
+	tracked := trackConn(conn, func(connStats) { closed++ })
+	if _, ok := tracked.(halfCloser); !ok {
+		t.Error("tracked TCP connection lost half-close support")
+	}
+
+	tracked.Close()
+	tracked.Close()
+	if closed != 1 {
+		t.Errorf("onClose ran %d times, want 1", closed)
+	}
+
+	client, server := net.Pipe()
+	defer server.Close()
+	if _, ok := trackConn(client, func(connStats) {}).(halfCloser); ok {
+		t.Error("tracked pipe claims half-close support")
+	}
+}
+
+// TestTrackConnStats tests byte counting and close reasons
+func TestTrackConnStats(t *testing.T) {
+	upstream, target := net.Pipe()
+
+	var stats connStats
+	tunnel := trackConn(upstream, func(s connStats) { stats = s })
+
+	go func() {
+		buf := make([]byte, 5)
+		io.ReadFull(target, buf)
+		target.Write([]byte("response"))
+		target.Close()
+	}()
+
+	if _, err := tunnel.Write([]byte("hello")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := io.ReadAll(tunnel); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	tunnel.Close()
+
+	if stats.BytesSent != 5 || stats.BytesReceived != 8 {
+		t.Errorf("stats = %+v, want 5 bytes sent and 8 received", stats)
+	}
+	if stats.CloseReason != CloseReasonUpstream {
+		t.Errorf("close reason = %q, want %q", stats.CloseReason, CloseReasonUpstream)
+	}
+
+	// Closing before the upstream ends the tunnel is a client close
+	upstream, target = net.Pipe()
+	defer target.Close()
+	tunnel = trackConn(upstream, func(s connStats) { stats = s })
+	tunnel.Close()
+	if stats.CloseReason != CloseReasonClient {
+		t.Errorf("close reason = %q, want %q", stats.CloseReason, CloseReasonClient)
+	}
+}
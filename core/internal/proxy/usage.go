@@ -2,27 +2,68 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
 	"github.com/alpkeskin/rota/core/internal/repository"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// Usage writer limits
+const (
+	usageQueueSize      = 10000                 // records waiting to be written
+	usageBatchSize      = 500                   // records written per flush at most
+	usageFlushInterval  = time.Second           // how long a partial batch waits
+	usageFlushTimeout   = 10 * time.Second      // per flush
+	usageEnqueueTimeout = 25 * time.Millisecond // how long a full queue holds up the caller
+)
+
+// requestColumns are the proxy_requests columns written by the usage writer
+var requestColumns = []string{
+	"proxy_id", "proxy_address", "method", "url", "status_code", "success", "response_time", "error", "timestamp", "client_id",
+	"bytes_sent", "bytes_received", "duration", "close_reason",
+}
+
 // UsageTracker tracks proxy usage and updates statistics
+// Request records and client usage are queued and written in batches by a
+// background writer, so proxied requests never wait on the database.
 type UsageTracker struct {
-	repo *repository.ProxyRepository
+	repo        *repository.ProxyRepository
+	logger      *logger.Logger
+	queue       chan RequestRecord
+	clientQueue chan clientUsage
+	done        chan struct{} // closed by Close
+	stopped     chan struct{} // closed when the writer has flushed and exited
+	started     atomic.Bool
+	closed      atomic.Bool
+	startOnce   sync.Once
+	closeOnce   sync.Once
 }
 
 // NewUsageTracker creates a new usage tracker
-func NewUsageTracker(repo *repository.ProxyRepository) *UsageTracker {
+// Queued request records are only written once Start is called.
+func NewUsageTracker(repo *repository.ProxyRepository, log *logger.Logger) *UsageTracker {
 	return &UsageTracker{
-		repo: repo,
+		repo:        repo,
+		logger:      log,
+		queue:       make(chan RequestRecord, usageQueueSize),
+		clientQueue: make(chan clientUsage, usageQueueSize),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
 	}
 }
 
 // RequestRecord represents a single proxy request
 type RequestRecord struct {
 	ProxyID      int
+	ClientID     int // 0 for anonymous or shared-credential requests
 	ProxyAddress string
 	RequestedURL string
 	Method       string
@@ -31,31 +72,345 @@ type RequestRecord struct {
 	StatusCode   int
 	ErrorMessage string
 	Timestamp    time.Time
+
+	// Traffic, set once the response body or CONNECT tunnel is closed
+	BytesSent     int64         // request body or client-to-target tunnel bytes
+	BytesReceived int64         // response body or target-to-client tunnel bytes
+	Duration      time.Duration // CONNECT tunnel lifetime
+	CloseReason   string        // why the CONNECT tunnel ended
 }
 
-// RecordRequest records a proxy request and updates statistics
-func (t *UsageTracker) RecordRequest(ctx context.Context, record RequestRecord) error {
-	// Insert into proxy_requests hypertable
-	if err := t.insertProxyRequest(ctx, record); err != nil {
-		return fmt.Errorf("failed to insert proxy request: %w", err)
+// clientUsage is a proxy client's requests and bytes on a day (see repository.UsageDay)
+type clientUsage struct {
+	clientID int
+	day      time.Time
+	requests int64
+	bytes    int64
+}
+
+// clientDay identifies a row of proxy_client_usage
+type clientDay struct {
+	clientID int
+	day      time.Time
+}
+
+// proxyUsage is the change to one proxy's statistics from a batch of requests
+type proxyUsage struct {
+	proxyID      int
+	requests     int64
+	successes    int64
+	responseTime int64 // sum, in milliseconds
+	failures     int64 // consecutive failures at the end of the batch
+	recovered    bool  // a request succeeded, resetting the failure count
+	lastCheck    time.Time
+	lastError    *string // error of the last request, nil if it succeeded
+}
+
+// Start starts the background writer
+func (t *UsageTracker) Start() {
+	t.startOnce.Do(func() {
+		t.started.Store(true)
+		go t.run()
+	})
+}
+
+// Close stops accepting records and waits until the queued ones are written
+// Records of tunnels closing afterwards are dropped.
+func (t *UsageTracker) Close(ctx context.Context) error {
+	t.closeOnce.Do(func() {
+		t.closed.Store(true)
+		close(t.done)
+	})
+	if !t.started.Load() {
+		return nil
 	}
 
-	// Update proxy statistics
-	if err := t.updateProxyStats(ctx, record); err != nil {
-		return fmt.Errorf("failed to update proxy stats: %w", err)
+	select {
+	case <-t.stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("usage writer did not finish: %w", ctx.Err())
 	}
+}
 
-	return nil
+// Record queues a proxy request to be recorded and added to the proxy's statistics
+// When the database falls behind and the queue is full, the caller is held up
+// for at most usageEnqueueTimeout before the record is dropped.
+func (t *UsageTracker) Record(record RequestRecord) {
+	if t.closed.Load() {
+		metrics.UsageRecordsDropped.WithLabelValues("closed").Inc()
+		return
+	}
+
+	select {
+	case t.queue <- record:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(usageEnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case t.queue <- record:
+	case <-timer.C:
+		metrics.UsageRecordsDropped.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// RecordClient queues a proxied request of a client to be added to its daily usage
+// Like Record, a full queue holds up the caller for at most usageEnqueueTimeout.
+func (t *UsageTracker) RecordClient(clientID int, day time.Time, bytes int64) {
+	if t.closed.Load() {
+		metrics.ClientUsageDropped.WithLabelValues("closed").Inc()
+		return
+	}
+
+	usage := clientUsage{clientID: clientID, day: day, requests: 1, bytes: bytes}
+	select {
+	case t.clientQueue <- usage:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(usageEnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case t.clientQueue <- usage:
+	case <-timer.C:
+		metrics.ClientUsageDropped.WithLabelValues("queue_full").Inc()
+	}
+}
+
+// run writes queued records until Close, flushing full batches right away and
+// partial ones every usageFlushInterval
+// Client usage is summed per client and day and written every usageFlushInterval.
+func (t *UsageTracker) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]RequestRecord, 0, usageBatchSize)
+	add := func(record RequestRecord) {
+		batch = append(batch, record)
+		if len(batch) >= usageBatchSize {
+			t.flush(batch)
+			batch = batch[:0]
+		}
+	}
+
+	clients := make(map[clientDay]*clientUsage)
+
+	for {
+		select {
+		case record := <-t.queue:
+			add(record)
+		case usage := <-t.clientQueue:
+			addClientUsage(clients, usage)
+		case <-ticker.C:
+			if len(batch) > 0 {
+				t.flush(batch)
+				batch = batch[:0]
+			}
+			if len(clients) > 0 {
+				t.flushClients(clients)
+				clear(clients)
+			}
+		case <-t.done:
+			// Write everything queued before Close
+			for {
+				select {
+				case record := <-t.queue:
+					add(record)
+				case usage := <-t.clientQueue:
+					addClientUsage(clients, usage)
+				default:
+					if len(batch) > 0 {
+						t.flush(batch)
+					}
+					if len(clients) > 0 {
+						t.flushClients(clients)
+					}
+					return
+				}
+			}
+		}
+	}
 }
 
-// insertProxyRequest inserts a record into the proxy_requests hypertable
-func (t *UsageTracker) insertProxyRequest(ctx context.Context, record RequestRecord) error {
+// flush writes a batch of records and counts the outcome
+func (t *UsageTracker) flush(records []RequestRecord) {
+	metrics.UsageQueueDepth.Set(float64(len(t.queue)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), usageFlushTimeout)
+	defer cancel()
+
+	start := time.Now()
+	n, err := t.writeBatch(ctx, records)
+	metrics.UsageFlushDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.UsageFlushes.WithLabelValues(metrics.ResultFailure).Inc()
+		metrics.UsageRecordsDropped.WithLabelValues("write_error").Add(float64(n))
+		t.logger.Error("failed to write proxy usage", "error", err, "records", len(records))
+		return
+	}
+
+	metrics.UsageFlushes.WithLabelValues(metrics.ResultSuccess).Inc()
+	metrics.UsageRecordsWritten.Add(float64(n))
+}
+
+// flushClients adds the summed client usage to proxy_client_usage and counts the outcome
+func (t *UsageTracker) flushClients(clients map[clientDay]*clientUsage) {
+	ctx, cancel := context.WithTimeout(context.Background(), usageFlushTimeout)
+	defer cancel()
+
+	usage := sortedClientUsage(clients)
+	if err := t.writeClients(ctx, usage); err != nil {
+		metrics.UsageFlushes.WithLabelValues(metrics.ResultFailure).Inc()
+		metrics.ClientUsageDropped.WithLabelValues("write_error").Add(float64(len(usage)))
+		t.logger.Error("failed to write client usage", "error", err, "clients", len(usage))
+		return
+	}
+	metrics.UsageFlushes.WithLabelValues(metrics.ResultSuccess).Inc()
+}
+
+// writeClients upserts client usage with one statement
+// Usage of clients deleted while it was queued is skipped.
+func (t *UsageTracker) writeClients(ctx context.Context, usage []clientUsage) error {
 	query := `
-		INSERT INTO proxy_requests (
-			proxy_id, proxy_address, method, url, status_code, success, response_time, error, timestamp
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO proxy_client_usage (client_id, day, requests, bytes)
+		SELECT u.client_id, u.day, u.requests, u.bytes
+		FROM unnest($1::INTEGER[], $2::DATE[], $3::BIGINT[], $4::BIGINT[]) AS u(client_id, day, requests, bytes)
+		WHERE u.client_id IN (SELECT id FROM proxy_clients)
+		ON CONFLICT (client_id, day) DO UPDATE
+		SET requests = proxy_client_usage.requests + EXCLUDED.requests,
+		    bytes = proxy_client_usage.bytes + EXCLUDED.bytes
 	`
 
+	n := len(usage)
+	ids := make([]int, n)
+	days := make([]time.Time, n)
+	requests := make([]int64, n)
+	bytes := make([]int64, n)
+	for i, u := range usage {
+		ids[i] = u.clientID
+		days[i] = u.day
+		requests[i] = u.requests
+		bytes[i] = u.bytes
+	}
+
+	if _, err := t.repo.GetDB().Pool.Exec(ctx, query, ids, days, requests, bytes); err != nil {
+		return fmt.Errorf("failed to record proxy client usage: %w", err)
+	}
+	return nil
+}
+
+// addClientUsage adds usage to the sum for its client and day
+func addClientUsage(clients map[clientDay]*clientUsage, usage clientUsage) {
+	key := clientDay{clientID: usage.clientID, day: usage.day}
+	if sum, ok := clients[key]; ok {
+		sum.requests += usage.requests
+		sum.bytes += usage.bytes
+	} else {
+		clients[key] = &usage
+	}
+}
+
+// sortedClientUsage returns the summed client usage ordered by client and day
+func sortedClientUsage(clients map[clientDay]*clientUsage) []clientUsage {
+	usage := make([]clientUsage, 0, len(clients))
+	for _, u := range clients {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].clientID != usage[j].clientID {
+			return usage[i].clientID < usage[j].clientID
+		}
+		return usage[i].day.Before(usage[j].day)
+	})
+	return usage
+}
+
+// writeBatch writes a batch of records
+// Records of proxies deleted while they were queued are dropped; the returned
+// count is that of the remaining records, which were written unless err is set.
+func (t *UsageTracker) writeBatch(ctx context.Context, records []RequestRecord) (int, error) {
+	err := t.write(ctx, records)
+
+	// Foreign key violation: a proxy no longer exists
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != "23503" {
+		return len(records), err
+	}
+
+	kept, err := t.withExistingProxies(ctx, records)
+	if err != nil {
+		return len(records), err
+	}
+	metrics.UsageRecordsDropped.WithLabelValues("deleted_proxy").Add(float64(len(records) - len(kept)))
+	if len(kept) == 0 {
+		return 0, nil
+	}
+
+	return len(kept), t.write(ctx, kept)
+}
+
+// write copies the records into proxy_requests and applies their statistics to
+// proxies with one UPDATE, in one transaction
+func (t *UsageTracker) write(ctx context.Context, records []RequestRecord) error {
+	tx, err := t.repo.GetDB().Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows := make([][]any, len(records))
+	for i, record := range records {
+		rows[i] = requestRow(record)
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"proxy_requests"}, requestColumns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("failed to insert proxy requests: %w", err)
+	}
+
+	if err := updateProxyStats(ctx, tx, aggregateUsage(records)); err != nil {
+		return fmt.Errorf("failed to update proxy stats: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// withExistingProxies returns the records whose proxy still exists
+func (t *UsageTracker) withExistingProxies(ctx context.Context, records []RequestRecord) ([]RequestRecord, error) {
+	ids := make([]int, len(records))
+	for i, record := range records {
+		ids[i] = record.ProxyID
+	}
+
+	rows, err := t.repo.GetDB().Pool.Query(ctx, "SELECT id FROM proxies WHERE id = ANY($1)", ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up proxies: %w", err)
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up proxies: %w", err)
+	}
+
+	exists := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	kept := make([]RequestRecord, 0, len(records))
+	for _, record := range records {
+		if exists[record.ProxyID] {
+			kept = append(kept, record)
+		}
+	}
+	return kept, nil
+}
+
+// requestRow returns the proxy_requests row of a record, in requestColumns order
+func requestRow(record RequestRecord) []any {
 	var errorMsg *string
 	if record.ErrorMessage != "" {
 		errorMsg = &record.ErrorMessage
@@ -66,9 +421,23 @@ func (t *UsageTracker) insertProxyRequest(ctx context.Context, record RequestRec
 		statusCode = &record.StatusCode
 	}
 
-	_, err := t.repo.GetDB().Pool.Exec(
-		ctx,
-		query,
+	var clientID *int
+	if record.ClientID > 0 {
+		clientID = &record.ClientID
+	}
+
+	var duration *int
+	if record.Duration > 0 {
+		ms := int(record.Duration.Milliseconds())
+		duration = &ms
+	}
+
+	var closeReason *string
+	if record.CloseReason != "" {
+		closeReason = &record.CloseReason
+	}
+
+	return []any{
 		record.ProxyID,
 		record.ProxyAddress,
 		record.Method,
@@ -78,64 +447,106 @@ func (t *UsageTracker) insertProxyRequest(ctx context.Context, record RequestRec
 		record.ResponseTime,
 		errorMsg,
 		record.Timestamp,
-	)
+		clientID,
+		record.BytesSent,
+		record.BytesReceived,
+		duration,
+		closeReason,
+	}
+}
 
-	return err
+// aggregateUsage sums the records of a batch per proxy, in proxy ID order
+// Records are applied in queue order, so the last request decides the error
+// and the consecutive failure count.
+func aggregateUsage(records []RequestRecord) []proxyUsage {
+	byProxy := make(map[int]*proxyUsage)
+	for _, record := range records {
+		u := byProxy[record.ProxyID]
+		if u == nil {
+			u = &proxyUsage{proxyID: record.ProxyID}
+			byProxy[record.ProxyID] = u
+		}
+
+		u.requests++
+		u.responseTime += int64(record.ResponseTime)
+		if record.Timestamp.After(u.lastCheck) {
+			u.lastCheck = record.Timestamp
+		}
+
+		if record.Success {
+			u.successes++
+			u.failures = 0
+			u.recovered = true
+			u.lastError = nil
+		} else {
+			u.failures++
+			u.lastError = nil
+			if record.ErrorMessage != "" {
+				msg := record.ErrorMessage
+				u.lastError = &msg
+			}
+		}
+	}
+
+	usage := make([]proxyUsage, 0, len(byProxy))
+	for _, u := range byProxy {
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].proxyID < usage[j].proxyID })
+	return usage
 }
 
-// updateProxyStats updates proxy statistics in the proxies table
-func (t *UsageTracker) updateProxyStats(ctx context.Context, record RequestRecord) error {
-	// Use a single query to update all statistics atomically
-	// Note: We calculate avg_response_time correctly by using current requests value before increment
+// updateProxyStats adds a batch's usage to the proxies' statistics
+func updateProxyStats(ctx context.Context, tx pgx.Tx, usage []proxyUsage) error {
+	// Average response time is weighted by the requests before and in the batch
 	query := `
-		UPDATE proxies
+		UPDATE proxies p
 		SET
-			requests = requests + 1,
-			successful_requests = CASE
-				WHEN $2 THEN successful_requests + 1
-				ELSE successful_requests
-			END,
+			requests = p.requests + u.requests,
+			successful_requests = p.successful_requests + u.successes,
 			failed_requests = CASE
-				WHEN $2 THEN 0  -- Reset consecutive failures on success
-				ELSE failed_requests + 1
+				WHEN u.recovered THEN u.failures  -- Reset consecutive failures on success
+				ELSE p.failed_requests + u.failures
 			END,
 			avg_response_time = (
-				CASE
-					WHEN requests = 0 THEN $3
-					ELSE ((avg_response_time * requests) + $3) / (requests + 1)
-				END
+				(COALESCE(p.avg_response_time, 0)::BIGINT * p.requests + u.response_time) / (p.requests + u.requests)
 			)::INTEGER,
-			last_check = $4,
-			last_error = CASE
-				WHEN $2 THEN NULL  -- Clear error on success
-				ELSE $5
-			END,
+			last_check = u.last_check,
+			last_error = u.last_error,  -- Cleared when the last request succeeded
+			-- Request failures are handled by the in-memory circuit breaker;
+			-- only health checks mark a proxy as failed
 			status = CASE
-				WHEN $2 THEN 'active'  -- Success = active
-				ELSE CASE
-					WHEN (failed_requests + 1) >= 3 THEN 'failed'  -- 3 consecutive failures = failed
-					ELSE status
-				END
+				WHEN u.recovered THEN 'active'  -- Success = active
+				ELSE p.status
 			END,
 			updated_at = NOW()
-		WHERE id = $1
+		FROM unnest(
+			$1::INTEGER[], $2::BIGINT[], $3::BIGINT[], $4::BIGINT[], $5::BIGINT[], $6::BOOLEAN[], $7::TIMESTAMP[], $8::TEXT[]
+		) AS u(id, requests, successes, response_time, failures, recovered, last_check, last_error)
+		WHERE p.id = u.id
 	`
 
-	var errorMsg *string
-	if record.ErrorMessage != "" {
-		errorMsg = &record.ErrorMessage
+	n := len(usage)
+	ids := make([]int, n)
+	requests := make([]int64, n)
+	successes := make([]int64, n)
+	responseTimes := make([]int64, n)
+	failures := make([]int64, n)
+	recovered := make([]bool, n)
+	lastChecks := make([]time.Time, n)
+	lastErrors := make([]*string, n)
+	for i, u := range usage {
+		ids[i] = u.proxyID
+		requests[i] = u.requests
+		successes[i] = u.successes
+		responseTimes[i] = u.responseTime
+		failures[i] = u.failures
+		recovered[i] = u.recovered
+		lastChecks[i] = u.lastCheck
+		lastErrors[i] = u.lastError
 	}
 
-	_, err := t.repo.GetDB().Pool.Exec(
-		ctx,
-		query,
-		record.ProxyID,
-		record.Success,
-		record.ResponseTime,
-		record.Timestamp,
-		errorMsg,
-	)
-
+	_, err := tx.Exec(ctx, query, ids, requests, successes, responseTimes, failures, recovered, lastChecks, lastErrors)
 	return err
 }
 
@@ -151,83 +562,44 @@ func (t *UsageTracker) UpdateProxyStatus(ctx context.Context, proxyID int, statu
 	return err
 }
 
-// RecordHealthCheck records a health check result
+// RecordHealthCheck records a health check result in the proxy's check history
+// and updates its status
 func (t *UsageTracker) RecordHealthCheck(ctx context.Context, proxyID int, success bool, responseTime int, errorMsg string) error {
 	now := time.Now()
 
-	status := "active"
-	if !success {
-		// Check how many consecutive failures
-		var failedRequests int64
-		query := `SELECT failed_requests FROM proxies WHERE id = $1`
-		if err := t.repo.GetDB().Pool.QueryRow(ctx, query, proxyID).Scan(&failedRequests); err != nil {
-			return err
-		}
+	var lastError *string
+	if errorMsg != "" {
+		lastError = &errorMsg
+	}
 
-		// Mark as failed after 3 consecutive failures
-		if failedRequests >= 2 {
-			status = "failed"
-		}
+	historyQuery := `
+		INSERT INTO proxy_health_checks (proxy_id, success, response_time, error)
+		VALUES ($1, $2, $3, $4)
+	`
+	if _, err := t.repo.GetDB().Pool.Exec(ctx, historyQuery, proxyID, success, responseTime, lastError); err != nil {
+		return fmt.Errorf("failed to insert health check: %w", err)
 	}
 
+	// Health check failures count towards the same consecutive failure counter as
+	// requests, so a recovered proxy needs 3 new failures before it is marked failed again
 	query := `
 		UPDATE proxies
 		SET
 			last_check = $1,
 			last_error = $2,
-			status = $3,
+			failed_requests = CASE
+				WHEN $3 THEN 0  -- Reset consecutive failures on success
+				ELSE failed_requests + 1
+			END,
+			status = CASE
+				WHEN $3 THEN 'active'
+				WHEN (failed_requests + 1) >= 3 THEN 'failed'  -- 3 consecutive failures = failed
+				ELSE status
+			END,
 			updated_at = NOW()
 		WHERE id = $4
 	`
 
-	var lastError *string
-	if errorMsg != "" {
-		lastError = &errorMsg
-	}
-
-	_, err := t.repo.GetDB().Pool.Exec(ctx, query, now, lastError, status, proxyID)
+	_, err := t.repo.GetDB().Pool.Exec(ctx, query, now, lastError, success, proxyID)
 	return err
 }
-
-// GetRecentRequests retrieves recent requests for a proxy
-func (t *UsageTracker) GetRecentRequests(ctx context.Context, proxyID int, limit int) ([]RequestRecord, error) {
-	query := `
-		SELECT
-			proxy_id, method, url, status, response_time,
-			COALESCE(error_message, '') as error_message, timestamp
-		FROM proxy_requests
-		WHERE proxy_id = $1
-		ORDER BY timestamp DESC
-		LIMIT $2
-	`
-
-	rows, err := t.repo.GetDB().Pool.Query(ctx, query, proxyID, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	records := make([]RequestRecord, 0, limit)
-	for rows.Next() {
-		var record RequestRecord
-		var status string
-
-		err := rows.Scan(
-			&record.ProxyID,
-			&record.Method,
-			&record.RequestedURL,
-			&status,
-			&record.ResponseTime,
-			&record.ErrorMessage,
-			&record.Timestamp,
-		)
-		if err != nil {
-			return nil, err
-		}
-
-		record.Success = (status == "success")
-		records = append(records, record)
-	}
-
-	return records, nil
-}
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestAggregateUsage tests that a batch of records is summed per proxy with the
+// last request deciding the error and consecutive failures
+func TestAggregateUsage(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	records := []RequestRecord{
+		{ProxyID: 2, Success: false, ResponseTime: 100, ErrorMessage: "timeout", Timestamp: base},
+		{ProxyID: 1, Success: true, ResponseTime: 200, Timestamp: base.Add(time.Second)},
+		{ProxyID: 2, Success: true, ResponseTime: 300, Timestamp: base.Add(2 * time.Second)},
+		{ProxyID: 2, Success: false, ResponseTime: 0, ErrorMessage: "refused", Timestamp: base.Add(3 * time.Second)},
+		{ProxyID: 2, Success: false, ResponseTime: 0, ErrorMessage: "reset", Timestamp: base.Add(4 * time.Second)},
+		{ProxyID: 3, Success: false, ResponseTime: 50, ErrorMessage: "timeout", Timestamp: base.Add(5 * time.Second)},
+		{ProxyID: 1, Success: true, ResponseTime: 400, Timestamp: base.Add(-time.Second)},
+	}
+
+	usage := aggregateUsage(records)
+	if len(usage) != 3 {
+		t.Fatalf("got usage for %d proxies, want 3", len(usage))
+	}
+	for i, id := range []int{1, 2, 3} {
+		if usage[i].proxyID != id {
+			t.Fatalf("usage[%d] is proxy %d, want %d", i, usage[i].proxyID, id)
+		}
+	}
+
+	p1, p2, p3 := usage[0], usage[1], usage[2]
+	if p1.requests != 2 || p1.successes != 2 || p1.responseTime != 600 || p1.failures != 0 || !p1.recovered || p1.lastError != nil {
+		t.Errorf("proxy 1 usage = %+v", p1)
+	}
+	if !p1.lastCheck.Equal(base.Add(time.Second)) {
+		t.Errorf("proxy 1 last check = %v, want the latest request", p1.lastCheck)
+	}
+
+	// Two failures after the success count as consecutive failures
+	if p2.requests != 4 || p2.successes != 1 || p2.responseTime != 400 || p2.failures != 2 || !p2.recovered {
+		t.Errorf("proxy 2 usage = %+v", p2)
+	}
+	if p2.lastError == nil || *p2.lastError != "reset" {
+		t.Errorf("proxy 2 last error = %v, want reset", p2.lastError)
+	}
+
+	// Without a success, failures add to the proxy's current count
+	if p3.requests != 1 || p3.successes != 0 || p3.failures != 1 || p3.recovered {
+		t.Errorf("proxy 3 usage = %+v", p3)
+	}
+}
+
+// TestUsageTrackerDrops tests that records are dropped when the queue is full
+// or the tracker is closed, without blocking the caller for long
+func TestUsageTrackerDrops(t *testing.T) {
+	tracker := NewUsageTracker(nil, nil)
+	tracker.queue = make(chan RequestRecord, 1)
+
+	full := metrics.UsageRecordsDropped.WithLabelValues("queue_full")
+	before := testutil.ToFloat64(full)
+
+	tracker.Record(RequestRecord{ProxyID: 1})
+	start := time.Now()
+	tracker.Record(RequestRecord{ProxyID: 2})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Record on a full queue took %v", elapsed)
+	}
+	if got := testutil.ToFloat64(full) - before; got != 1 {
+		t.Errorf("queue_full drops increased by %v, want 1", got)
+	}
+	if len(tracker.queue) != 1 || (<-tracker.queue).ProxyID != 1 {
+		t.Error("queued record was replaced")
+	}
+
+	// Close without a running writer returns right away
+	if err := tracker.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	closed := metrics.UsageRecordsDropped.WithLabelValues("closed")
+	before = testutil.ToFloat64(closed)
+	tracker.Record(RequestRecord{ProxyID: 3})
+	if got := testutil.ToFloat64(closed) - before; got != 1 {
+		t.Errorf("closed drops increased by %v, want 1", got)
+	}
+	if len(tracker.queue) != 0 {
+		t.Error("record was queued after Close")
+	}
+}
+
+// TestClientUsage tests that client usage is summed per client and day and
+// dropped like request records when it can't be queued
+func TestClientUsage(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	next := day.AddDate(0, 0, 1)
+
+	clients := make(map[clientDay]*clientUsage)
+	for _, u := range []clientUsage{
+		{clientID: 2, day: day, requests: 1, bytes: 100},
+		{clientID: 1, day: next, requests: 1, bytes: 10},
+		{clientID: 2, day: day, requests: 1, bytes: 50},
+		{clientID: 1, day: day, requests: 1, bytes: 0},
+	} {
+		addClientUsage(clients, u)
+	}
+
+	want := []clientUsage{
+		{clientID: 1, day: day, requests: 1, bytes: 0},
+		{clientID: 1, day: next, requests: 1, bytes: 10},
+		{clientID: 2, day: day, requests: 2, bytes: 150},
+	}
+	got := sortedClientUsage(clients)
+	if len(got) != len(want) {
+		t.Fatalf("got usage for %d client days, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("usage[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	tracker := NewUsageTracker(nil, nil)
+	tracker.clientQueue = make(chan clientUsage, 1)
+
+	full := metrics.ClientUsageDropped.WithLabelValues("queue_full")
+	before := testutil.ToFloat64(full)
+	tracker.RecordClient(1, day, 10)
+	tracker.RecordClient(1, day, 20)
+	if got := testutil.ToFloat64(full) - before; got != 1 {
+		t.Errorf("queue_full drops increased by %v, want 1", got)
+	}
+	if len(tracker.clientQueue) != 1 || (<-tracker.clientQueue).bytes != 10 {
+		t.Error("queued client usage was replaced")
+	}
+
+	if err := tracker.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	closed := metrics.ClientUsageDropped.WithLabelValues("closed")
+	before = testutil.ToFloat64(closed)
+	tracker.RecordClient(1, day, 30)
+	if got := testutil.ToFloat64(closed) - before; got != 1 {
+		t.Errorf("closed drops increased by %v, want 1", got)
+	}
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ClientRepository handles proxy client database operations
+type ClientRepository struct {
+	db *database.DB
+}
+
+// NewClientRepository creates a new ClientRepository
+func NewClientRepository(db *database.DB) *ClientRepository {
+	return &ClientRepository{db: db}
+}
+
+// clientSelect selects a client joined with its usage for the day given as $1
+const clientSelect = `
+	SELECT c.id, c.username, c.password_hash, c.description, c.enabled,
+	       c.requests_per_minute, c.daily_request_quota, c.daily_byte_quota,
+	       COALESCE(u.requests, 0), COALESCE(u.bytes, 0),
+	       c.created_at, c.updated_at
+	FROM proxy_clients c
+	LEFT JOIN proxy_client_usage u ON u.client_id = c.id AND u.day = $1
+`
+
+// UsageDay returns the date used to bucket client usage (UTC)
+func UsageDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// List retrieves all clients with today's usage, ordered by username
+func (r *ClientRepository) List(ctx context.Context) ([]models.ProxyClient, error) {
+	return r.list(ctx, clientSelect+` ORDER BY c.username`)
+}
+
+// ListEnabled retrieves enabled clients with today's usage, including password hashes
+func (r *ClientRepository) ListEnabled(ctx context.Context) ([]models.ProxyClient, error) {
+	return r.list(ctx, clientSelect+` WHERE c.enabled ORDER BY c.id`)
+}
+
+// list runs a client query and scans the results
+func (r *ClientRepository) list(ctx context.Context, query string) ([]models.ProxyClient, error) {
+	rows, err := r.db.Pool.Query(ctx, query, UsageDay(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy clients: %w", err)
+	}
+	defer rows.Close()
+
+	clients := []models.ProxyClient{}
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy client: %w", err)
+		}
+		clients = append(clients, *c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list proxy clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+// GetByID retrieves a client with today's usage by ID
+func (r *ClientRepository) GetByID(ctx context.Context, id int) (*models.ProxyClient, error) {
+	row := r.db.Pool.QueryRow(ctx, clientSelect+` WHERE c.id = $2`, UsageDay(time.Now()), id)
+
+	c, err := scanClient(row)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy client: %w", err)
+	}
+
+	return c, nil
+}
+
+// Create creates a new client, hashing the password with bcrypt
+func (r *ClientRepository) Create(ctx context.Context, req models.CreateProxyClientRequest) (*models.ProxyClient, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	query := `
+		INSERT INTO proxy_clients (
+			username, password_hash, description, enabled,
+			requests_per_minute, daily_request_quota, daily_byte_quota
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	var id int
+	err = r.db.Pool.QueryRow(ctx, query,
+		req.Username, string(hash), req.Description, enabled,
+		req.RequestsPerMinute, req.DailyRequestQuota, req.DailyByteQuota,
+	).Scan(&id)
+
+	if err != nil {
+		// Check if it's a unique constraint violation
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("client %s already exists", req.Username)
+		}
+		return nil, fmt.Errorf("failed to create proxy client: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Update updates a client; nil fields are left unchanged
+func (r *ClientRepository) Update(ctx context.Context, id int, req models.UpdateProxyClientRequest) (*models.ProxyClient, error) {
+	var passwordHash *string
+	if req.Password != nil {
+		hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		hashStr := string(hash)
+		passwordHash = &hashStr
+	}
+
+	query := `
+		UPDATE proxy_clients
+		SET password_hash = COALESCE($1, password_hash),
+		    description = COALESCE($2, description),
+		    enabled = COALESCE($3, enabled),
+		    requests_per_minute = COALESCE($4, requests_per_minute),
+		    daily_request_quota = COALESCE($5, daily_request_quota),
+		    daily_byte_quota = COALESCE($6, daily_byte_quota),
+		    updated_at = NOW()
+		WHERE id = $7
+	`
+
+	result, err := r.db.Pool.Exec(ctx, query,
+		passwordHash, req.Description, req.Enabled,
+		req.RequestsPerMinute, req.DailyRequestQuota, req.DailyByteQuota, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update proxy client: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, nil
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a client by ID
+func (r *ClientRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM proxy_clients WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete proxy client: %w", err)
+	}
+	return nil
+}
+
+// scanClient scans a row produced by clientSelect
+func scanClient(row pgx.Row) (*models.ProxyClient, error) {
+	var c models.ProxyClient
+	err := row.Scan(
+		&c.ID, &c.Username, &c.PasswordHash, &c.Description, &c.Enabled,
+		&c.RequestsPerMinute, &c.DailyRequestQuota, &c.DailyByteQuota,
+		&c.RequestsToday, &c.BytesToday,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
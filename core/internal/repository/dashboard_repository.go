@@ -44,7 +44,8 @@ func (r *DashboardRepository) GetStats(ctx context.Context) (*models.DashboardSt
 			SELECT
 				COUNT(*) as requests_today,
 				COALESCE(AVG(CASE WHEN success THEN 1.0 ELSE 0.0 END) * 100, 0) as success_rate_today,
-				COALESCE(AVG(response_time), 0)::int as response_time_today
+				COALESCE(AVG(response_time), 0)::int as response_time_today,
+				COALESCE(SUM(COALESCE(bytes_sent, 0) + COALESCE(bytes_received, 0)), 0)::bigint as bytes_today
 			FROM proxy_requests
 			WHERE timestamp >= NOW() - INTERVAL '1 day'
 		)
@@ -59,7 +60,8 @@ func (r *DashboardRepository) GetStats(ctx context.Context) (*models.DashboardSt
 				ELSE 0
 			END as request_growth,
 			(t.success_rate_today - y.success_rate_yesterday) as success_rate_growth,
-			(t.response_time_today - y.response_time_yesterday) as response_time_delta
+			(t.response_time_today - y.response_time_yesterday) as response_time_delta,
+			t.bytes_today
 		FROM current_stats c, yesterday_stats y, today_stats t
 	`
 
@@ -73,6 +75,7 @@ func (r *DashboardRepository) GetStats(ctx context.Context) (*models.DashboardSt
 		&stats.RequestGrowth,
 		&stats.SuccessRateGrowth,
 		&stats.ResponseTimeDelta,
+		&stats.BytesToday,
 	)
 
 	if err != nil {
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// HealthCheckRepository handles health check run and history database operations
+type HealthCheckRepository struct {
+	db *database.DB
+}
+
+// NewHealthCheckRepository creates a new HealthCheckRepository
+func NewHealthCheckRepository(db *database.DB) *HealthCheckRepository {
+	return &HealthCheckRepository{db: db}
+}
+
+// CreateRun stores the summary of a health check run
+func (r *HealthCheckRepository) CreateRun(ctx context.Context, run *models.HealthCheckRun) error {
+	query := `
+		INSERT INTO health_check_runs (started_at, finished_at, checked, healthy, failed, resurrected, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err := r.db.Pool.QueryRow(ctx, query,
+		run.StartedAt, run.FinishedAt, run.Checked, run.Healthy, run.Failed, run.Resurrected, run.Error,
+	).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create health check run: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns retrieves the most recent health check runs
+func (r *HealthCheckRepository) ListRuns(ctx context.Context, limit int) ([]models.HealthCheckRun, error) {
+	query := `
+		SELECT id, started_at, finished_at, checked, healthy, failed, resurrected, error
+		FROM health_check_runs
+		ORDER BY started_at DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list health check runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.HealthCheckRun{}
+	for rows.Next() {
+		var run models.HealthCheckRun
+		err := rows.Scan(
+			&run.ID, &run.StartedAt, &run.FinishedAt,
+			&run.Checked, &run.Healthy, &run.Failed, &run.Resurrected, &run.Error,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan health check run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list health check runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetProxyHistory retrieves a proxy's health check summary over the window and
+// its most recent results
+func (r *HealthCheckRepository) GetProxyHistory(ctx context.Context, proxyID int, window time.Duration, limit int) (*models.ProxyHealthHistory, error) {
+	history := &models.ProxyHealthHistory{
+		ProxyID: proxyID,
+		Results: []models.ProxyHealthCheck{},
+	}
+
+	summaryQuery := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE success),
+			percentile_cont(0.50) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success),
+			percentile_cont(0.90) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success),
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success),
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success)
+		FROM proxy_health_checks
+		WHERE proxy_id = $1
+		  AND timestamp >= NOW() - make_interval(secs => $2)
+	`
+
+	var p50, p90, p95, p99 *float64
+	err := r.db.Pool.QueryRow(ctx, summaryQuery, proxyID, window.Seconds()).Scan(
+		&history.Checks, &history.Passed, &p50, &p90, &p95, &p99,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy health summary: %w", err)
+	}
+
+	if history.Checks > 0 {
+		uptime := float64(history.Passed) / float64(history.Checks) * 100
+		history.UptimePercent = &uptime
+	}
+	if p50 != nil {
+		history.Latency = &models.LatencyPercentiles{P50: *p50, P90: *p90, P95: *p95, P99: *p99}
+	}
+
+	resultsQuery := `
+		SELECT timestamp, success, response_time, error
+		FROM proxy_health_checks
+		WHERE proxy_id = $1
+		  AND timestamp >= NOW() - make_interval(secs => $2)
+		ORDER BY timestamp DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Pool.Query(ctx, resultsQuery, proxyID, window.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy health checks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var check models.ProxyHealthCheck
+		if err := rows.Scan(&check.Timestamp, &check.Success, &check.ResponseTime, &check.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy health check: %w", err)
+		}
+		history.Results = append(history.Results, check)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list proxy health checks: %w", err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PoolRepository handles proxy pool database operations
+type PoolRepository struct {
+	db *database.DB
+}
+
+// NewPoolRepository creates a new PoolRepository
+func NewPoolRepository(db *database.DB) *PoolRepository {
+	return &PoolRepository{db: db}
+}
+
+// poolSelect selects pools with the number of proxies matching their tags
+const poolSelect = `
+	SELECT pp.id, pp.name, pp.description, pp.tags, pp.method,
+	       (SELECT COUNT(*) FROM proxies p WHERE p.tags @> pp.tags),
+	       pp.created_at, pp.updated_at
+	FROM proxy_pools pp
+`
+
+// List retrieves all pools ordered by name
+func (r *PoolRepository) List(ctx context.Context) ([]models.ProxyPool, error) {
+	rows, err := r.db.Pool.Query(ctx, poolSelect+` ORDER BY pp.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy pools: %w", err)
+	}
+	defer rows.Close()
+
+	pools := []models.ProxyPool{}
+	for rows.Next() {
+		var p models.ProxyPool
+		err := rows.Scan(
+			&p.ID, &p.Name, &p.Description, &p.Tags, &p.Method,
+			&p.ProxyCount, &p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy pool: %w", err)
+		}
+		pools = append(pools, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list proxy pools: %w", err)
+	}
+
+	return pools, nil
+}
+
+// GetByID retrieves a pool by ID
+func (r *PoolRepository) GetByID(ctx context.Context, id int) (*models.ProxyPool, error) {
+	var p models.ProxyPool
+	err := r.db.Pool.QueryRow(ctx, poolSelect+` WHERE pp.id = $1`, id).Scan(
+		&p.ID, &p.Name, &p.Description, &p.Tags, &p.Method,
+		&p.ProxyCount, &p.CreatedAt, &p.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy pool: %w", err)
+	}
+
+	return &p, nil
+}
+
+// Create creates a new pool
+func (r *PoolRepository) Create(ctx context.Context, req models.CreateProxyPoolRequest) (*models.ProxyPool, error) {
+	query := `
+		INSERT INTO proxy_pools (name, description, tags, method)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var id int
+	err := r.db.Pool.QueryRow(ctx, query, req.Name, req.Description, req.Tags, req.Method).Scan(&id)
+	if err != nil {
+		// Check if it's a unique constraint violation
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("pool %s already exists", req.Name)
+		}
+		return nil, fmt.Errorf("failed to create proxy pool: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Update updates a pool; nil fields are left unchanged
+func (r *PoolRepository) Update(ctx context.Context, id int, req models.UpdateProxyPoolRequest) (*models.ProxyPool, error) {
+	query := `
+		UPDATE proxy_pools
+		SET description = COALESCE($1, description),
+		    tags = COALESCE($2, tags),
+		    method = COALESCE($3, method),
+		    updated_at = NOW()
+		WHERE id = $4
+	`
+
+	result, err := r.db.Pool.Exec(ctx, query, req.Description, req.Tags, req.Method, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update proxy pool: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return nil, nil
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// Delete deletes a pool by ID
+func (r *PoolRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM proxy_pools WHERE id = $1`
+	_, err := r.db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete proxy pool: %w", err)
+	}
+	return nil
+}
@@ -28,7 +28,8 @@ func (r *ProxyRepository) GetDB() *database.DB {
 }
 
 // List retrieves proxies with pagination and filters
-func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, status, protocol, sortField, sortOrder string) ([]models.ProxyWithStats, int, error) {
+// Only proxies carrying all of the given tags and matching the geo filter are returned
+func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, status, protocol string, tags models.Tags, geo models.GeoFilter, sortField, sortOrder string) ([]models.ProxyWithStats, int, error) {
 	// Build WHERE clause
 	whereClauses := []string{}
 	args := []interface{}{}
@@ -53,6 +54,17 @@ func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, sta
 		argPos++
 	}
 
+	if len(tags) > 0 {
+		whereClauses = append(whereClauses, fmt.Sprintf("tags @> $%d", argPos))
+		args = append(args, tags)
+		argPos++
+	}
+
+	geoClauses, geoArgs := GeoConditions(geo, argPos)
+	whereClauses = append(whereClauses, geoClauses...)
+	args = append(args, geoArgs...)
+	argPos += len(geoArgs)
+
 	whereClause := ""
 	if len(whereClauses) > 0 {
 		whereClause = "WHERE " + strings.Join(whereClauses, " AND ")
@@ -64,6 +76,7 @@ func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, sta
 		"status":            true,
 		"requests":          true,
 		"avg_response_time": true,
+		"country":           true,
 		"created_at":        true,
 	}
 
@@ -88,7 +101,8 @@ func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, sta
 		SELECT
 			id, address, protocol, username, status,
 			requests, successful_requests, failed_requests,
-			avg_response_time, last_check, created_at, updated_at
+			avg_response_time, last_check, tags,
+			exit_ip, country, city, asn, as_org, created_at, updated_at
 		FROM proxies
 		%s
 		ORDER BY %s %s
@@ -109,7 +123,8 @@ func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, sta
 		err := rows.Scan(
 			&p.ID, &p.Address, &p.Protocol, &p.Username, &p.Status,
 			&p.Requests, &p.SuccessfulRequests, &p.FailedRequests,
-			&p.AvgResponseTime, &p.LastCheck, &p.CreatedAt, &p.UpdatedAt,
+			&p.AvgResponseTime, &p.LastCheck, &p.Tags,
+			&p.ExitIP, &p.Country, &p.City, &p.ASN, &p.ASOrg, &p.CreatedAt, &p.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan proxy: %w", err)
@@ -131,6 +146,12 @@ func (r *ProxyRepository) List(ctx context.Context, page, limit int, search, sta
 			SuccessRate:     successRate,
 			AvgResponseTime: p.AvgResponseTime,
 			LastCheck:       p.LastCheck,
+			Tags:            p.Tags,
+			ExitIP:          p.ExitIP,
+			Country:         p.Country,
+			City:            p.City,
+			ASN:             p.ASN,
+			ASOrg:           p.ASOrg,
 			CreatedAt:       p.CreatedAt,
 			UpdatedAt:       p.UpdatedAt,
 		})
@@ -145,7 +166,9 @@ func (r *ProxyRepository) GetByID(ctx context.Context, id int) (*models.Proxy, e
 		SELECT
 			id, address, protocol, username, password, status,
 			requests, successful_requests, failed_requests,
-			avg_response_time, last_check, last_error, created_at, updated_at
+			avg_response_time, last_check, last_error, tags,
+			exit_ip, country, city, asn, as_org, tls_server_name, ca_bundle,
+			created_at, updated_at
 		FROM proxies
 		WHERE id = $1
 	`
@@ -154,7 +177,9 @@ func (r *ProxyRepository) GetByID(ctx context.Context, id int) (*models.Proxy, e
 	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
 		&p.ID, &p.Address, &p.Protocol, &p.Username, &p.Password, &p.Status,
 		&p.Requests, &p.SuccessfulRequests, &p.FailedRequests,
-		&p.AvgResponseTime, &p.LastCheck, &p.LastError, &p.CreatedAt, &p.UpdatedAt,
+		&p.AvgResponseTime, &p.LastCheck, &p.LastError, &p.Tags,
+		&p.ExitIP, &p.Country, &p.City, &p.ASN, &p.ASOrg, &p.TLSServerName, &p.CABundle,
+		&p.CreatedAt, &p.UpdatedAt,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -171,14 +196,19 @@ func (r *ProxyRepository) GetByID(ctx context.Context, id int) (*models.Proxy, e
 // Create creates a new proxy
 func (r *ProxyRepository) Create(ctx context.Context, req models.CreateProxyRequest) (*models.Proxy, error) {
 	query := `
-		INSERT INTO proxies (address, protocol, username, password)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, address, protocol, username, status, created_at, updated_at
+		INSERT INTO proxies (address, protocol, username, password, tags, tls_server_name, ca_bundle)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''))
+		RETURNING id, address, protocol, username, status, tags, tls_server_name, ca_bundle, created_at, updated_at
 	`
 
+	tags := req.Tags
+	if tags == nil {
+		tags = models.Tags{}
+	}
+
 	var p models.Proxy
-	err := r.db.Pool.QueryRow(ctx, query, req.Address, req.Protocol, req.Username, req.Password).Scan(
-		&p.ID, &p.Address, &p.Protocol, &p.Username, &p.Status, &p.CreatedAt, &p.UpdatedAt,
+	err := r.db.Pool.QueryRow(ctx, query, req.Address, req.Protocol, req.Username, req.Password, tags, req.TLSServerName, req.CABundle).Scan(
+		&p.ID, &p.Address, &p.Protocol, &p.Username, &p.Status, &p.Tags, &p.TLSServerName, &p.CABundle, &p.CreatedAt, &p.UpdatedAt,
 	)
 
 	if err != nil {
@@ -201,14 +231,17 @@ func (r *ProxyRepository) Update(ctx context.Context, id int, req models.UpdateP
 		    protocol = COALESCE(NULLIF($2, ''), protocol),
 		    username = $3,
 		    password = $4,
+		    tags = COALESCE($5, tags),
+		    tls_server_name = CASE WHEN $6::text IS NULL THEN tls_server_name ELSE NULLIF($6, '') END,
+		    ca_bundle = CASE WHEN $7::text IS NULL THEN ca_bundle ELSE NULLIF($7, '') END,
 		    updated_at = NOW()
-		WHERE id = $5
-		RETURNING id, address, protocol, status, updated_at
+		WHERE id = $8
+		RETURNING id, address, protocol, status, tags, tls_server_name, ca_bundle, updated_at
 	`
 
 	var p models.Proxy
-	err := r.db.Pool.QueryRow(ctx, query, req.Address, req.Protocol, req.Username, req.Password, id).Scan(
-		&p.ID, &p.Address, &p.Protocol, &p.Status, &p.UpdatedAt,
+	err := r.db.Pool.QueryRow(ctx, query, req.Address, req.Protocol, req.Username, req.Password, req.Tags, req.TLSServerName, req.CABundle, id).Scan(
+		&p.ID, &p.Address, &p.Protocol, &p.Status, &p.Tags, &p.TLSServerName, &p.CABundle, &p.UpdatedAt,
 	)
 
 	if err == pgx.ErrNoRows {
@@ -222,6 +255,27 @@ func (r *ProxyRepository) Update(ctx context.Context, id int, req models.UpdateP
 	return &p, nil
 }
 
+// UpdateGeo stores the exit IP discovered by a health check and its GeoIP location
+// Empty location fields (e.g. without a GeoIP database) are stored as NULL
+func (r *ProxyRepository) UpdateGeo(ctx context.Context, id int, exitIP string, geo models.GeoInfo) error {
+	query := `
+		UPDATE proxies
+		SET exit_ip = $1,
+		    country = NULLIF($2, ''),
+		    city = NULLIF($3, ''),
+		    asn = NULLIF($4, 0),
+		    as_org = NULLIF($5, ''),
+		    updated_at = NOW()
+		WHERE id = $6
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query, exitIP, geo.Country, geo.City, geo.ASN, geo.ASOrg, id)
+	if err != nil {
+		return fmt.Errorf("failed to update proxy geo: %w", err)
+	}
+	return nil
+}
+
 // Delete deletes a proxy by ID
 func (r *ProxyRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM proxies WHERE id = $1`
@@ -242,6 +296,28 @@ func (r *ProxyRepository) BulkDelete(ctx context.Context, ids []int) (int, error
 	return int(result.RowsAffected()), nil
 }
 
+// BulkUpdateTags sets and removes tags on multiple proxies
+func (r *ProxyRepository) BulkUpdateTags(ctx context.Context, ids []int, set models.Tags, remove []string) (int, error) {
+	if set == nil {
+		set = models.Tags{}
+	}
+	if remove == nil {
+		remove = []string{}
+	}
+
+	query := `
+		UPDATE proxies
+		SET tags = (tags || $2::jsonb) - $3::text[],
+		    updated_at = NOW()
+		WHERE id = ANY($1)
+	`
+	result, err := r.db.Pool.Exec(ctx, query, ids, set, remove)
+	if err != nil {
+		return 0, fmt.Errorf("failed to bulk update proxy tags: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
 // GetStats retrieves overall proxy statistics
 func (r *ProxyRepository) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	query := `
@@ -283,6 +359,31 @@ func (r *ProxyRepository) GetStats(ctx context.Context) (map[string]interface{},
 	}, nil
 }
 
+// CountByStatus retrieves the number of proxies per status
+func (r *ProxyRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.Pool.Query(ctx, "SELECT status, COUNT(*) FROM proxies GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count proxies by status: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy status count: %w", err)
+		}
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count proxies by status: %w", err)
+	}
+
+	return counts, nil
+}
+
 // GetAllActive retrieves all active proxies
 func (r *ProxyRepository) GetAllActive(ctx context.Context) ([]models.ProxyStatusSimple, error) {
 	query := `
@@ -332,3 +433,30 @@ func (r *ProxyRepository) GetAllActive(ctx context.Context) ([]models.ProxyStatu
 
 	return proxies, nil
 }
+
+// GeoConditions builds WHERE conditions on the proxies geo columns
+// Placeholders are numbered from argPos; the returned args fill them in order
+func GeoConditions(geo models.GeoFilter, argPos int) ([]string, []interface{}) {
+	clauses := []string{}
+	args := []interface{}{}
+
+	if geo.Country != "" {
+		clauses = append(clauses, fmt.Sprintf("country = UPPER($%d)", argPos))
+		args = append(args, geo.Country)
+		argPos++
+	}
+
+	if geo.ASN != 0 {
+		clauses = append(clauses, fmt.Sprintf("asn = $%d", argPos))
+		args = append(args, geo.ASN)
+		argPos++
+	}
+
+	if len(geo.ExcludeASN) > 0 {
+		// Proxies with an unknown ASN can't be proven to be outside the excluded networks
+		clauses = append(clauses, fmt.Sprintf("asn IS NOT NULL AND NOT (asn = ANY($%d))", argPos))
+		args = append(args, geo.ExcludeASN)
+	}
+
+	return clauses, args
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/models"
+)
+
+// topErrorsLimit is how many error messages request stats report
+const topErrorsLimit = 10
+
+// RequestRepository handles proxy request history database operations
+type RequestRepository struct {
+	db *database.DB
+}
+
+// NewRequestRepository creates a new RequestRepository
+func NewRequestRepository(db *database.DB) *RequestRepository {
+	return &RequestRepository{db: db}
+}
+
+// ListByProxy retrieves a proxy's requests newest first, starting after the
+// filter's cursor
+func (r *RequestRepository) ListByProxy(ctx context.Context, proxyID int, filter models.ProxyRequestFilter, limit int) ([]models.ProxyRequest, error) {
+	conditions := []string{"proxy_id = $1"}
+	args := []interface{}{proxyID}
+
+	if filter.Success != nil {
+		args = append(args, *filter.Success)
+		conditions = append(conditions, fmt.Sprintf("success = $%d", len(args)))
+	}
+	if filter.StatusMin > 0 {
+		args = append(args, filter.StatusMin)
+		conditions = append(conditions, fmt.Sprintf("status_code >= $%d", len(args)))
+	}
+	if filter.StatusMax > 0 {
+		args = append(args, filter.StatusMax)
+		conditions = append(conditions, fmt.Sprintf("status_code <= $%d", len(args)))
+	}
+	if filter.StartTime != nil {
+		args = append(args, *filter.StartTime)
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+	if filter.EndTime != nil {
+		args = append(args, *filter.EndTime)
+		conditions = append(conditions, fmt.Sprintf("timestamp <= $%d", len(args)))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.Timestamp, filter.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(timestamp, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT
+			id, timestamp, proxy_id, client_id, method, url, status_code,
+			COALESCE(response_time, 0), success, error,
+			COALESCE(bytes_sent, 0), COALESCE(bytes_received, 0), duration, close_reason
+		FROM proxy_requests
+		WHERE %s
+		ORDER BY timestamp DESC, id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proxy requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []models.ProxyRequest{}
+	for rows.Next() {
+		var req models.ProxyRequest
+		err := rows.Scan(
+			&req.ID, &req.Timestamp, &req.ProxyID, &req.ClientID, &req.Method, &req.URL, &req.StatusCode,
+			&req.ResponseTime, &req.Success, &req.Error,
+			&req.BytesSent, &req.BytesReceived, &req.Duration, &req.CloseReason,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list proxy requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// GetStats retrieves a proxy's request statistics over the window, with
+// request counts grouped into buckets of the given size
+func (r *RequestRepository) GetStats(ctx context.Context, proxyID int, window, bucket time.Duration) (*models.ProxyRequestStats, error) {
+	stats := &models.ProxyRequestStats{
+		ProxyID:   proxyID,
+		Buckets:   []models.ProxyRequestBucket{},
+		TopErrors: []models.ProxyErrorCount{},
+	}
+
+	summaryQuery := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE success),
+			percentile_cont(0.50) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success),
+			percentile_cont(0.90) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success),
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success),
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY response_time) FILTER (WHERE success)
+		FROM proxy_requests
+		WHERE proxy_id = $1
+		  AND timestamp >= NOW() - make_interval(secs => $2)
+	`
+
+	var p50, p90, p95, p99 *float64
+	err := r.db.Pool.QueryRow(ctx, summaryQuery, proxyID, window.Seconds()).Scan(
+		&stats.Requests, &stats.Successful, &p50, &p90, &p95, &p99,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy request summary: %w", err)
+	}
+
+	stats.Failed = stats.Requests - stats.Successful
+	if stats.Requests > 0 {
+		rate := float64(stats.Successful) / float64(stats.Requests) * 100
+		stats.SuccessRate = &rate
+	}
+	if p50 != nil {
+		stats.Latency = &models.LatencyPercentiles{P50: *p50, P90: *p90, P95: *p95, P99: *p99}
+	}
+
+	bucketQuery := `
+		SELECT
+			time_bucket(make_interval(secs => $3), timestamp) AS bucket,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE success),
+			COALESCE(AVG(response_time) FILTER (WHERE success), 0)::int
+		FROM proxy_requests
+		WHERE proxy_id = $1
+		  AND timestamp >= NOW() - make_interval(secs => $2)
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	rows, err := r.db.Pool.Query(ctx, bucketQuery, proxyID, window.Seconds(), bucket.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy request buckets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b models.ProxyRequestBucket
+		if err := rows.Scan(&b.Time, &b.Requests, &b.Successful, &b.AvgResponseTime); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy request bucket: %w", err)
+		}
+		b.Failed = b.Requests - b.Successful
+		if b.Requests > 0 {
+			b.SuccessRate = float64(b.Successful) / float64(b.Requests) * 100
+		}
+		stats.Buckets = append(stats.Buckets, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get proxy request buckets: %w", err)
+	}
+
+	errorsQuery := `
+		SELECT error, COUNT(*) AS count
+		FROM proxy_requests
+		WHERE proxy_id = $1
+		  AND timestamp >= NOW() - make_interval(secs => $2)
+		  AND error IS NOT NULL
+		  AND error <> ''
+		GROUP BY error
+		ORDER BY count DESC, error
+		LIMIT $3
+	`
+
+	errRows, err := r.db.Pool.Query(ctx, errorsQuery, proxyID, window.Seconds(), topErrorsLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy request errors: %w", err)
+	}
+	defer errRows.Close()
+
+	for errRows.Next() {
+		var e models.ProxyErrorCount
+		if err := errRows.Scan(&e.Error, &e.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy request error: %w", err)
+		}
+		stats.TopErrors = append(stats.TopErrors, e)
+	}
+
+	if err := errRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get proxy request errors: %w", err)
+	}
+
+	return stats, nil
+}
@@ -111,7 +111,47 @@ func (r *SettingsRepository) UpdateAll(ctx context.Context, settings *models.Set
 	return nil
 }
 
+// GetRoutes retrieves the reverse-proxy route table
+func (r *SettingsRepository) GetRoutes(ctx context.Context) ([]models.ProxyRoute, error) {
+	value, err := r.Get(ctx, "routes")
+	if err != nil {
+		return nil, err
+	}
+
+	var table models.RouteSettings
+	if value != nil {
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal routes: %w", err)
+		}
+		if err := json.Unmarshal(valueJSON, &table); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal routes: %w", err)
+		}
+	}
+
+	if table.Routes == nil {
+		table.Routes = []models.ProxyRoute{}
+	}
+	return table.Routes, nil
+}
+
+// SetRoutes replaces the reverse-proxy route table
+func (r *SettingsRepository) SetRoutes(ctx context.Context, routes []models.ProxyRoute) error {
+	valueJSON, err := json.Marshal(models.RouteSettings{Routes: routes})
+	if err != nil {
+		return fmt.Errorf("failed to marshal routes: %w", err)
+	}
+
+	var value map[string]any
+	if err := json.Unmarshal(valueJSON, &value); err != nil {
+		return fmt.Errorf("failed to unmarshal routes: %w", err)
+	}
+
+	return r.Set(ctx, "routes", value)
+}
+
 // Reset resets all settings to defaults
+// The route table is kept, it is not part of the settings form.
 func (r *SettingsRepository) Reset(ctx context.Context) error {
 	defaults := map[string]map[string]any{
 		"authentication": {
@@ -124,15 +164,37 @@ func (r *SettingsRepository) Reset(ctx context.Context) error {
 			"time_based": map[string]any{
 				"interval": 120,
 			},
+			"rate_limited": map[string]any{
+				"max_requests_per_minute": 30,
+				"window_seconds":          60,
+				"max_wait_seconds":        5,
+			},
 			"remove_unhealthy":     true,
 			"fallback":             true,
 			"fallback_max_retries": 10,
 			"follow_redirect":      false,
 			"timeout":              90,
 			"retries":              3,
+			"retry_body_limit":     1 << 20, // 1 MiB
+			"retry_non_idempotent": false,
 			"allowed_protocols":    []string{"http", "https", "socks5"}, // All protocols allowed by default
 			"max_response_time":    0,                                   // 0 means no limit
 			"min_success_rate":     0.0,                                 // 0 means no minimum
+			"sticky_session_ttl":   600,                                 // 10 minutes idle
+			"circuit_breaker": map[string]any{
+				"failure_threshold":  5,
+				"open_seconds":       30,
+				"max_open_seconds":   600,
+				"half_open_requests": 1,
+			},
+			"blocked": map[string]any{
+				"status_codes":     []int{429, 503},
+				"body_patterns":    []string{},
+				"max_retries":      2,
+				"backoff_ms":       500,
+				"max_backoff_ms":   5000,
+				"cooldown_seconds": 60,
+			},
 		},
 		"rate_limit": {
 			"enabled":      false,
@@ -145,7 +207,9 @@ func (r *SettingsRepository) Reset(ctx context.Context) error {
 			"url":                         "https://api.ipify.org",
 			"status":                      200,
 			"headers":                     []string{"User-Agent: Rota-HealthCheck/1.0"},
+			"interval_minutes":            5,
 			"retest_failed_after_minutes": 0,
+			"exit_ip_url":                 "https://api.ipify.org",
 		},
 		"log_retention": {
 			"enabled":                true,
@@ -153,6 +217,9 @@ func (r *SettingsRepository) Reset(ctx context.Context) error {
 			"compression_after_days": 7,
 			"cleanup_interval_hours": 24,
 		},
+		"webshare": {
+			"sync_interval_seconds": 0, // 0 uses WEBSHARE_SYNC_INTERVAL_SECONDS
+		},
 	}
 
 	for key, value := range defaults {
@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alpkeskin/rota/core/internal/database"
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrLastAdmin is returned when an operation would leave the system without an admin
+var ErrLastAdmin = errors.New("at least one admin user must remain")
+
+// UserRepository handles dashboard user database operations
+type UserRepository struct {
+	db *database.DB
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(db *database.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// List retrieves all users ordered by username
+func (r *UserRepository) List(ctx context.Context) ([]models.User, error) {
+	query := `
+		SELECT id, username, password_hash, role, created_at, updated_at
+		FROM users
+		ORDER BY username
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+
+	return users, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	query := `
+		SELECT id, username, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	var u models.User
+	err := r.db.Pool.QueryRow(ctx, query, id).Scan(
+		&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &u, nil
+}
+
+// GetByUsername retrieves a user by username
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	query := `
+		SELECT id, username, password_hash, role, created_at, updated_at
+		FROM users
+		WHERE username = $1
+	`
+
+	var u models.User
+	err := r.db.Pool.QueryRow(ctx, query, username).Scan(
+		&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &u, nil
+}
+
+// Create creates a new user, hashing the password with bcrypt
+func (r *UserRepository) Create(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	query := `
+		INSERT INTO users (username, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING id, username, password_hash, role, created_at, updated_at
+	`
+
+	var u models.User
+	err = r.db.Pool.QueryRow(ctx, query, req.Username, string(hash), req.Role).Scan(
+		&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+	)
+
+	if err != nil {
+		// Check if it's a unique constraint violation
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, fmt.Errorf("user %s already exists", req.Username)
+		}
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &u, nil
+}
+
+// Update updates a user's password and/or role
+// Demoting the last admin returns ErrLastAdmin
+func (r *UserRepository) Update(ctx context.Context, id int, req models.UpdateUserRequest) (*models.User, error) {
+	var passwordHash *string
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		hashStr := string(hash)
+		passwordHash = &hashStr
+	}
+
+	var u models.User
+	err := pgx.BeginFunc(ctx, r.db.Pool, func(tx pgx.Tx) error {
+		if req.Role != "" && req.Role != models.UserRoleAdmin {
+			if err := r.ensureOtherAdmin(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+
+		query := `
+			UPDATE users
+			SET password_hash = COALESCE($1, password_hash),
+			    role = COALESCE(NULLIF($2, ''), role),
+			    updated_at = NOW()
+			WHERE id = $3
+			RETURNING id, username, password_hash, role, created_at, updated_at
+		`
+
+		return tx.QueryRow(ctx, query, passwordHash, string(req.Role), id).Scan(
+			&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+		)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return &u, nil
+}
+
+// Delete deletes a user by ID and returns the deleted user
+// Deleting the last admin returns ErrLastAdmin
+func (r *UserRepository) Delete(ctx context.Context, id int) (*models.User, error) {
+	var u models.User
+	err := pgx.BeginFunc(ctx, r.db.Pool, func(tx pgx.Tx) error {
+		if err := r.ensureOtherAdmin(ctx, tx, id); err != nil {
+			return err
+		}
+
+		query := `
+			DELETE FROM users
+			WHERE id = $1
+			RETURNING id, username, password_hash, role, created_at, updated_at
+		`
+
+		return tx.QueryRow(ctx, query, id).Scan(
+			&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+		)
+	})
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		if errors.Is(err, ErrLastAdmin) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return &u, nil
+}
+
+// ensureOtherAdmin returns ErrLastAdmin if the given user is the only admin left
+// Admin rows are locked so concurrent demotions cannot both succeed
+func (r *UserRepository) ensureOtherAdmin(ctx context.Context, tx pgx.Tx, id int) error {
+	rows, err := tx.Query(ctx, `SELECT id FROM users WHERE role = 'admin' FOR UPDATE`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	isAdmin := false
+	admins := 0
+	for rows.Next() {
+		var adminID int
+		if err := rows.Scan(&adminID); err != nil {
+			return err
+		}
+		admins++
+		if adminID == id {
+			isAdmin = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if isAdmin && admins <= 1 {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
+
+// Authenticate verifies a username/password pair and returns the matching user
+// Returns nil if the credentials are invalid
+func (r *UserRepository) Authenticate(ctx context.Context, username, password string) (*models.User, error) {
+	user, err := r.GetByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		// Compare against a dummy hash so unknown usernames take as long as wrong passwords
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, nil
+	}
+
+	return user, nil
+}
+
+// dummyPasswordHash is a bcrypt hash used to equalize timing for unknown users
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("rota-dummy-password"), bcrypt.DefaultCost)
+
+// EnsureAdmin creates the bootstrap admin account when the users table is empty
+// This keeps ROTA_ADMIN_USER/ROTA_ADMIN_PASSWORD working for fresh installs
+func (r *UserRepository) EnsureAdmin(ctx context.Context, username, password string) (bool, error) {
+	var count int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	if count > 0 {
+		return false, nil
+	}
+
+	_, err := r.Create(ctx, models.CreateUserRequest{
+		Username: username,
+		Password: password,
+		Role:     models.UserRoleAdmin,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,12 +13,13 @@ import (
 )
 
 // LogCleanupService handles automatic log cleanup and retention
+// Settings changes are applied through UpdateSettings without a restart.
 type LogCleanupService struct {
 	db           *database.DB
 	settingsRepo *repository.SettingsRepository
 	logger       *logger.Logger
+	reloadChan   chan models.LogRetentionSettings
 	stopChan     chan struct{}
-	ticker       *time.Ticker
 }
 
 // NewLogCleanupService creates a new log cleanup service
@@ -30,6 +32,7 @@ func NewLogCleanupService(
 		db:           db,
 		settingsRepo: settingsRepo,
 		logger:       log,
+		reloadChan:   make(chan models.LogRetentionSettings, 1),
 		stopChan:     make(chan struct{}),
 	}
 }
@@ -44,24 +47,17 @@ func (s *LogCleanupService) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to get settings: %w", err)
 	}
 
-	if !settings.LogRetention.Enabled {
-		s.logger.Info("log cleanup is disabled")
-		return nil
+	if settings.LogRetention.Enabled {
+		// Run cleanup immediately on start
+		go func() {
+			if err := s.runCleanup(ctx, settings.LogRetention); err != nil {
+				s.logger.Error("failed to run initial cleanup", "error", err)
+			}
+		}()
 	}
 
-	// Set initial interval
-	interval := time.Duration(settings.LogRetention.CleanupIntervalHours) * time.Hour
-	s.ticker = time.NewTicker(interval)
-
-	// Run cleanup immediately on start
-	go func() {
-		if err := s.runCleanup(ctx); err != nil {
-			s.logger.Error("failed to run initial cleanup", "error", err)
-		}
-	}()
-
 	// Start background worker
-	go s.worker(ctx)
+	go s.worker(ctx, settings.LogRetention)
 
 	return nil
 }
@@ -70,69 +66,72 @@ func (s *LogCleanupService) Start(ctx context.Context) error {
 func (s *LogCleanupService) Stop() {
 	s.logger.Info("stopping log cleanup service")
 	close(s.stopChan)
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
 }
 
-// worker runs the cleanup job periodically
-func (s *LogCleanupService) worker(ctx context.Context) {
+// worker runs the cleanup job periodically, restarting its ticker on settings changes
+func (s *LogCleanupService) worker(ctx context.Context, config models.LogRetentionSettings) {
 	for {
-		select {
-		case <-s.ticker.C:
-			if err := s.runCleanup(ctx); err != nil {
-				s.logger.Error("cleanup job failed", "error", err)
+		var ticker *time.Ticker
+		var tick <-chan time.Time
+		if config.Enabled && config.CleanupIntervalHours > 0 {
+			ticker = time.NewTicker(time.Duration(config.CleanupIntervalHours) * time.Hour)
+			tick = ticker.C
+		} else {
+			s.logger.Info("log cleanup is disabled")
+		}
+
+		reloaded := false
+		for !reloaded {
+			select {
+			case <-tick:
+				if err := s.runCleanup(ctx, config); err != nil {
+					s.logger.Error("cleanup job failed", "error", err)
+				}
+			case config = <-s.reloadChan:
+				reloaded = true
+			case <-s.stopChan:
+				if ticker != nil {
+					ticker.Stop()
+				}
+				s.logger.Info("log cleanup worker stopped")
+				return
+			case <-ctx.Done():
+				if ticker != nil {
+					ticker.Stop()
+				}
+				s.logger.Info("log cleanup worker context cancelled")
+				return
 			}
-		case <-s.stopChan:
-			s.logger.Info("log cleanup worker stopped")
-			return
-		case <-ctx.Done():
-			s.logger.Info("log cleanup worker context cancelled")
-			return
+		}
+
+		if ticker != nil {
+			ticker.Stop()
 		}
 	}
 }
 
 // runCleanup performs the actual cleanup
-func (s *LogCleanupService) runCleanup(ctx context.Context) error {
+// Both policies are attempted even if the first one fails.
+func (s *LogCleanupService) runCleanup(ctx context.Context, config models.LogRetentionSettings) error {
 	s.logger.Info("running log cleanup")
 
-	// Get current settings
-	settings, err := s.settingsRepo.GetAll(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get settings: %w", err)
+	retentionErr := s.updateRetentionPolicy(ctx, config)
+	if retentionErr != nil {
+		s.logger.Error("failed to update retention policy", "error", retentionErr)
 	}
 
-	if !settings.LogRetention.Enabled {
-		s.logger.Info("log cleanup is disabled, skipping")
-		return nil
+	compressionErr := s.updateCompressionPolicy(ctx, config)
+	if compressionErr != nil {
+		s.logger.Error("failed to update compression policy", "error", compressionErr)
 	}
 
-	// Update retention policy
-	if err := s.updateRetentionPolicy(ctx, settings.LogRetention); err != nil {
-		s.logger.Error("failed to update retention policy", "error", err)
-		// Don't return error, continue with other tasks
-	}
-
-	// Update compression policy
-	if err := s.updateCompressionPolicy(ctx, settings.LogRetention); err != nil {
-		s.logger.Error("failed to update compression policy", "error", err)
-		// Don't return error, continue with other tasks
-	}
-
-	// Update ticker if interval changed
-	newInterval := time.Duration(settings.LogRetention.CleanupIntervalHours) * time.Hour
-	if s.ticker != nil {
-		currentInterval := time.Duration(settings.LogRetention.CleanupIntervalHours) * time.Hour
-		if currentInterval != newInterval {
-			s.ticker.Reset(newInterval)
-			s.logger.Info("updated cleanup interval", "hours", settings.LogRetention.CleanupIntervalHours)
-		}
+	if err := errors.Join(retentionErr, compressionErr); err != nil {
+		return err
 	}
 
 	s.logger.Info("log cleanup completed",
-		"retention_days", settings.LogRetention.RetentionDays,
-		"compression_after_days", settings.LogRetention.CompressionAfterDays,
+		"retention_days", config.RetentionDays,
+		"compression_after_days", config.CompressionAfterDays,
 	)
 
 	return nil
@@ -175,34 +174,18 @@ func (s *LogCleanupService) updateCompressionPolicy(ctx context.Context, config
 	return nil
 }
 
-// UpdateSettings updates the cleanup service with new settings
-func (s *LogCleanupService) UpdateSettings(ctx context.Context) error {
-	settings, err := s.settingsRepo.GetAll(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get settings: %w", err)
+// UpdateSettings applies new retention settings
+// The policies are updated immediately and the cleanup interval restarts.
+func (s *LogCleanupService) UpdateSettings(ctx context.Context, config models.LogRetentionSettings) error {
+	// Replace a pending reload that the worker hasn't picked up yet
+	select {
+	case <-s.reloadChan:
+	default:
 	}
+	s.reloadChan <- config
 
-	if !settings.LogRetention.Enabled {
-		s.logger.Info("log cleanup disabled")
-		if s.ticker != nil {
-			s.ticker.Stop()
-		}
+	if !config.Enabled {
 		return nil
 	}
-
-	// Restart ticker with new interval
-	if s.ticker != nil {
-		s.ticker.Stop()
-	}
-	interval := time.Duration(settings.LogRetention.CleanupIntervalHours) * time.Hour
-	s.ticker = time.NewTicker(interval)
-
-	// Run cleanup immediately
-	go func() {
-		if err := s.runCleanup(ctx); err != nil {
-			s.logger.Error("failed to run cleanup after settings update", "error", err)
-		}
-	}()
-
-	return nil
+	return s.runCleanup(ctx, config)
 }
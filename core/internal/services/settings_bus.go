@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/alpkeskin/rota/core/internal/models"
+	"github.com/alpkeskin/rota/core/pkg/logger"
+)
+
+// SettingsApplier applies updated settings to a running subsystem
+// The settings are shared between subscribers and must not be modified.
+type SettingsApplier func(ctx context.Context, settings *models.Settings) error
+
+// settingsSubscriber is a named subsystem notified of settings changes
+type settingsSubscriber struct {
+	name  string
+	apply SettingsApplier
+}
+
+// SettingsBus notifies running subsystems (proxy server, health checks, log
+// cleanup, Webshare scheduler) when settings change, so they apply without a restart
+type SettingsBus struct {
+	mu          sync.RWMutex
+	subscribers []settingsSubscriber
+	logger      *logger.Logger
+}
+
+// NewSettingsBus creates a settings bus without subscribers
+func NewSettingsBus(log *logger.Logger) *SettingsBus {
+	return &SettingsBus{logger: log}
+}
+
+// Subscribe registers a subsystem to be notified of settings changes
+func (b *SettingsBus) Subscribe(name string, apply SettingsApplier) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers = append(b.subscribers, settingsSubscriber{name: name, apply: apply})
+}
+
+// Publish applies the settings to every subscriber in subscription order
+// A failing subscriber doesn't stop the others; the status of each is returned.
+func (b *SettingsBus) Publish(ctx context.Context, settings *models.Settings) []models.SettingsReloadStatus {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+
+	statuses := make([]models.SettingsReloadStatus, 0, len(subscribers))
+	for _, sub := range subscribers {
+		status := models.SettingsReloadStatus{Subsystem: sub.name, Applied: true}
+		if err := sub.apply(ctx, settings); err != nil {
+			b.logger.Error("failed to apply settings", "subsystem", sub.name, "error", err)
+			status.Applied = false
+			status.Error = err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
@@ -5,27 +5,33 @@ import (
 	"sync"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/pkg/logger"
 )
 
 // WebshareScheduler handles automatic synchronization scheduling
+// The interval comes from WEBSHARE_SYNC_INTERVAL_SECONDS unless the webshare
+// settings override it, and changes apply through UpdateSettings.
 type WebshareScheduler struct {
-	syncService *WebshareSyncService
-	interval    time.Duration
-	ticker      *time.Ticker
-	stopChan    chan struct{}
-	mu          sync.Mutex
-	running     bool
-	logger      *logger.Logger
+	syncService     *WebshareSyncService
+	defaultInterval time.Duration
+	interval        time.Duration
+	ticker          *time.Ticker
+	stopChan        chan struct{}
+	ctx             context.Context // from Start, reused when the interval changes
+	mu              sync.Mutex
+	running         bool
+	logger          *logger.Logger
 }
 
 // NewWebshareScheduler creates a new WebshareScheduler
 func NewWebshareScheduler(syncService *WebshareSyncService, intervalSeconds int, log *logger.Logger) *WebshareScheduler {
+	interval := time.Duration(intervalSeconds) * time.Second
 	return &WebshareScheduler{
-		syncService: syncService,
-		interval:    time.Duration(intervalSeconds) * time.Second,
-		stopChan:    make(chan struct{}),
-		logger:      log,
+		syncService:     syncService,
+		defaultInterval: interval,
+		interval:        interval,
+		logger:          log,
 	}
 }
 
@@ -34,6 +40,12 @@ func (s *WebshareScheduler) Start(ctx context.Context) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.ctx = ctx
+	s.startLocked()
+}
+
+// startLocked starts the sync loop with the current interval
+func (s *WebshareScheduler) startLocked() {
 	if s.running {
 		return
 	}
@@ -46,9 +58,10 @@ func (s *WebshareScheduler) Start(ctx context.Context) {
 
 	s.running = true
 	s.ticker = time.NewTicker(s.interval)
+	s.stopChan = make(chan struct{})
 	s.logger.Info("webshare auto-sync scheduler started", "interval", s.interval)
 
-	go s.run(ctx)
+	go s.run(s.ctx, s.interval, s.ticker, s.stopChan)
 }
 
 // Stop stops the scheduler
@@ -56,6 +69,11 @@ func (s *WebshareScheduler) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.stopLocked()
+}
+
+// stopLocked stops the sync loop if it is running
+func (s *WebshareScheduler) stopLocked() {
 	if !s.running {
 		return
 	}
@@ -68,13 +86,37 @@ func (s *WebshareScheduler) Stop() {
 	s.logger.Info("webshare auto-sync scheduler stopped")
 }
 
+// UpdateSettings applies the auto-sync interval from settings, restarting the
+// schedule if it changed
+func (s *WebshareScheduler) UpdateSettings(settings models.WebshareSettings) {
+	interval := s.defaultInterval
+	if settings.SyncIntervalSeconds > 0 {
+		interval = time.Duration(settings.SyncIntervalSeconds) * time.Second
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if interval == s.interval {
+		return
+	}
+	s.interval = interval
+
+	// Not started yet: Start picks up the new interval
+	if s.ctx == nil {
+		return
+	}
+	s.stopLocked()
+	s.startLocked()
+}
+
 // run is the main scheduler loop
-func (s *WebshareScheduler) run(ctx context.Context) {
+func (s *WebshareScheduler) run(ctx context.Context, interval time.Duration, ticker *time.Ticker, stopChan chan struct{}) {
 	// Perform initial sync after interval
 	select {
-	case <-time.After(s.interval):
+	case <-time.After(interval):
 		s.triggerSync(ctx)
-	case <-s.stopChan:
+	case <-stopChan:
 		return
 	case <-ctx.Done():
 		return
@@ -83,9 +125,9 @@ func (s *WebshareScheduler) run(ctx context.Context) {
 	// Then sync on ticker interval
 	for {
 		select {
-		case <-s.ticker.C:
+		case <-ticker.C:
 			s.triggerSync(ctx)
-		case <-s.stopChan:
+		case <-stopChan:
 			return
 		case <-ctx.Done():
 			return
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/alpkeskin/rota/core/internal/metrics"
 	"github.com/alpkeskin/rota/core/internal/models"
 	"github.com/alpkeskin/rota/core/internal/proxy"
 	"github.com/alpkeskin/rota/core/internal/repository"
@@ -49,11 +50,12 @@ func NewWebshareSyncService(
 }
 
 // Sync performs the complete sync workflow
-func (s *WebshareSyncService) Sync(ctx context.Context) error {
+func (s *WebshareSyncService) Sync(ctx context.Context) (err error) {
 	// Check if already syncing
 	s.mu.Lock()
 	if s.isSyncing {
 		s.mu.Unlock()
+		metrics.WebshareSyncs.WithLabelValues("skipped").Inc()
 		return fmt.Errorf("sync already in progress")
 	}
 	s.isSyncing = true
@@ -63,6 +65,12 @@ func (s *WebshareSyncService) Sync(ctx context.Context) error {
 		s.mu.Lock()
 		s.isSyncing = false
 		s.mu.Unlock()
+
+		if err != nil {
+			metrics.WebshareSyncs.WithLabelValues("failure").Inc()
+		} else {
+			metrics.WebshareSyncs.WithLabelValues("success").Inc()
+		}
 	}()
 
 	// Step 0: Create sync status record
@@ -97,7 +105,7 @@ func (s *WebshareSyncService) Sync(ctx context.Context) error {
 	}
 
 	// Get all ROTA proxies
-	rotaProxies, _, err := s.proxyRepo.List(ctx, 1, 10000, "", "", "", "created_at", "asc")
+	rotaProxies, _, err := s.proxyRepo.List(ctx, 1, 10000, "", "", "", nil, models.GeoFilter{}, "created_at", "asc")
 	if err != nil {
 		s.updateSyncStatus(ctx, syncStatus.ID, "FAILED", nil, nil, nil, nil, nil)
 		s.addError(ctx, syncStatus.ID, "fetch_rota_failed", "", fmt.Sprintf("Failed to fetch ROTA proxies: %v", err))
@@ -143,6 +151,7 @@ func (s *WebshareSyncService) Sync(ctx context.Context) error {
 				Protocol: protocol,
 				Username: &wsProxy.Username,
 				Password: &wsProxy.Password,
+				Tags:     models.Tags{"provider": "webshare"},
 			}
 
 			proxy, err := s.proxyRepo.Create(ctx, req)
@@ -218,7 +227,7 @@ func (s *WebshareSyncService) Sync(ctx context.Context) error {
 
 	// Step 6: Post-Sync: Check ROTA Unhealthy IPs
 	s.addLog(ctx, syncStatus.ID, "info", "Checking ROTA unhealthy IPs")
-	rotaFailedProxies, _, err := s.proxyRepo.List(ctx, 1, 10000, "failed", "", "", "created_at", "asc")
+	rotaFailedProxies, _, err := s.proxyRepo.List(ctx, 1, 10000, "failed", "", "", nil, models.GeoFilter{}, "created_at", "asc")
 	if err == nil && len(rotaFailedProxies) > 0 {
 		rotaFailedIPs := []string{}
 		for _, p := range rotaFailedProxies {
@@ -252,6 +261,9 @@ func (s *WebshareSyncService) Sync(ctx context.Context) error {
 	ipReplacedJSON := s.arrayToJSON(ipReplaced)
 
 	s.updateSyncStatus(ctx, syncStatus.ID, "SUCCESS", nil, nil, &ipRemovedJSON, &ipAddedJSON, &ipReplacedJSON)
+	metrics.WebshareSyncProxies.WithLabelValues("added").Add(float64(len(ipAdded)))
+	metrics.WebshareSyncProxies.WithLabelValues("removed").Add(float64(len(ipRemoved)))
+	metrics.WebshareSyncProxies.WithLabelValues("replaced").Add(float64(len(ipReplaced)))
 	s.addLog(ctx, syncStatus.ID, "info", "Sync completed successfully")
 
 	return nil
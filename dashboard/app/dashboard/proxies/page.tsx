@@ -41,6 +41,7 @@ import {
   DropdownMenuTrigger,
 } from "@/components/ui/dropdown-menu"
 import { Input } from "@/components/ui/input"
+import { Textarea } from "@/components/ui/textarea"
 import {
   Table,
   TableBody,
@@ -103,6 +104,8 @@ export default function ProxiesPage() {
     protocol: "http" as "http" | "https" | "socks5",
     username: "",
     password: "",
+    tls_server_name: "",
+    ca_bundle: "",
   })
 
   // Import modal states
@@ -169,7 +172,7 @@ export default function ProxiesPage() {
     try {
       await api.addProxy(newProxy)
       setIsAddDialogOpen(false)
-      setNewProxy({ address: "", protocol: "http", username: "", password: "" })
+      setNewProxy({ address: "", protocol: "http", username: "", password: "", tls_server_name: "", ca_bundle: "" })
       toast.success("Proxy added successfully")
       fetchProxies()
     } catch (error) {
@@ -1090,6 +1093,33 @@ export default function ProxiesPage() {
                 onChange={(e) => setNewProxy({ ...newProxy, password: e.target.value })}
               />
             </div>
+            {newProxy.protocol === "https" && (
+              <>
+                <div className="grid gap-2">
+                  <Label htmlFor="tls_server_name">TLS Server Name (optional)</Label>
+                  <Input
+                    id="tls_server_name"
+                    placeholder="Defaults to the address host"
+                    value={newProxy.tls_server_name}
+                    onChange={(e) => setNewProxy({ ...newProxy, tls_server_name: e.target.value })}
+                  />
+                </div>
+                <div className="grid gap-2">
+                  <Label htmlFor="ca_bundle">CA Bundle (optional)</Label>
+                  <Textarea
+                    id="ca_bundle"
+                    placeholder="-----BEGIN CERTIFICATE-----"
+                    className="font-mono text-xs"
+                    rows={4}
+                    value={newProxy.ca_bundle}
+                    onChange={(e) => setNewProxy({ ...newProxy, ca_bundle: e.target.value })}
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    PEM certificates trusted for the proxy's TLS certificate. System roots are used when empty.
+                  </p>
+                </div>
+              </>
+            )}
           </div>
           <DialogFooter>
             <Button variant="outline" onClick={() => setIsAddDialogOpen(false)}>
@@ -150,6 +150,7 @@ export default function SettingsPage() {
                         updatedRotation.rate_limited = {
                           max_requests_per_minute: 30,
                           window_seconds: 60,
+                          max_wait_seconds: 5,
                         };
                       }
                       setSettings({
@@ -243,6 +244,33 @@ export default function SettingsPage() {
                         Time window in seconds for rate limiting (default: 60 = 1 minute)
                       </p>
                     </div>
+                    <div className="space-y-2">
+                      <Label htmlFor="rate-limited-max-wait">Max Wait (seconds)</Label>
+                      <Input
+                        id="rate-limited-max-wait"
+                        type="number"
+                        min="0"
+                        max="60"
+                        value={settings.rotation.rate_limited?.max_wait_seconds ?? 0}
+                        onChange={(e) =>
+                          setSettings({
+                            ...settings,
+                            rotation: {
+                              ...settings.rotation,
+                              rate_limited: {
+                                ...settings.rotation.rate_limited,
+                                max_requests_per_minute: settings.rotation.rate_limited?.max_requests_per_minute || 30,
+                                window_seconds: settings.rotation.rate_limited?.window_seconds || 60,
+                                max_wait_seconds: parseInt(e.target.value) || 0,
+                              },
+                            },
+                          })
+                        }
+                      />
+                      <p className="text-xs text-muted-foreground">
+                        How long a request waits for a proxy under its limit when all are busy (0 = fail immediately)
+                      </p>
+                    </div>
                   </>
                 )}
 
@@ -329,6 +357,103 @@ export default function SettingsPage() {
                     0 means no minimum. Only use proxies with success rate above this.
                   </p>
                 </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="circuit-failure-threshold">Circuit Breaker Failure Threshold</Label>
+                  <Input
+                    id="circuit-failure-threshold"
+                    type="number"
+                    min="0"
+                    value={settings.rotation.circuit_breaker?.failure_threshold ?? 0}
+                    onChange={(e) =>
+                      setSettings({
+                        ...settings,
+                        rotation: {
+                          ...settings.rotation,
+                          circuit_breaker: {
+                            ...settings.rotation.circuit_breaker,
+                            failure_threshold: parseInt(e.target.value) || 0,
+                          },
+                        },
+                      })
+                    }
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    0 disables the breaker. Consecutive failures before a proxy is taken out of rotation.
+                  </p>
+                </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="circuit-open-seconds">Circuit Open Duration (seconds)</Label>
+                  <Input
+                    id="circuit-open-seconds"
+                    type="number"
+                    min="0"
+                    value={settings.rotation.circuit_breaker?.open_seconds ?? 0}
+                    onChange={(e) =>
+                      setSettings({
+                        ...settings,
+                        rotation: {
+                          ...settings.rotation,
+                          circuit_breaker: {
+                            ...settings.rotation.circuit_breaker,
+                            open_seconds: parseInt(e.target.value) || 0,
+                          },
+                        },
+                      })
+                    }
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    Doubles each time a proxy fails again after reopening.
+                  </p>
+                </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="circuit-max-open-seconds">Circuit Max Open Duration (seconds)</Label>
+                  <Input
+                    id="circuit-max-open-seconds"
+                    type="number"
+                    min="0"
+                    value={settings.rotation.circuit_breaker?.max_open_seconds ?? 0}
+                    onChange={(e) =>
+                      setSettings({
+                        ...settings,
+                        rotation: {
+                          ...settings.rotation,
+                          circuit_breaker: {
+                            ...settings.rotation.circuit_breaker,
+                            max_open_seconds: parseInt(e.target.value) || 0,
+                          },
+                        },
+                      })
+                    }
+                  />
+                </div>
+
+                <div className="space-y-2">
+                  <Label htmlFor="circuit-half-open-requests">Circuit Half-Open Requests</Label>
+                  <Input
+                    id="circuit-half-open-requests"
+                    type="number"
+                    min="0"
+                    value={settings.rotation.circuit_breaker?.half_open_requests ?? 0}
+                    onChange={(e) =>
+                      setSettings({
+                        ...settings,
+                        rotation: {
+                          ...settings.rotation,
+                          circuit_breaker: {
+                            ...settings.rotation.circuit_breaker,
+                            half_open_requests: parseInt(e.target.value) || 0,
+                          },
+                        },
+                      })
+                    }
+                  />
+                  <p className="text-xs text-muted-foreground">
+                    Live requests let through to test a recovering proxy; all must succeed.
+                  </p>
+                </div>
               </div>
 
               {/* Right Column */}
@@ -618,6 +743,29 @@ export default function SettingsPage() {
               </p>
             </div>
 
+            <div className="space-y-2">
+              <Label htmlFor="healthcheck-interval-minutes">Check Interval (minutes)</Label>
+              <Input
+                id="healthcheck-interval-minutes"
+                type="number"
+                min="0"
+                max="1440"
+                value={settings.healthcheck.interval_minutes ?? 0}
+                onChange={(e) =>
+                  setSettings({
+                    ...settings,
+                    healthcheck: {
+                      ...settings.healthcheck,
+                      interval_minutes: parseInt(e.target.value) || 0,
+                    },
+                  })
+                }
+              />
+              <p className="text-xs text-muted-foreground">
+                How often proxies are checked automatically; 0 disables scheduled checks
+              </p>
+            </div>
+
             <div className="space-y-2">
               <Label htmlFor="healthcheck-retest-minutes">Retest Failed After (minutes)</Label>
               <Input
@@ -658,6 +806,24 @@ export default function SettingsPage() {
               </p>
             </div>
 
+            <div className="space-y-2">
+              <Label htmlFor="healthcheck-exit-ip-url">Exit IP URL</Label>
+              <Input
+                id="healthcheck-exit-ip-url"
+                type="url"
+                value={settings.healthcheck.exit_ip_url ?? ""}
+                onChange={(e) =>
+                  setSettings({
+                    ...settings,
+                    healthcheck: { ...settings.healthcheck, exit_ip_url: e.target.value },
+                  })
+                }
+              />
+              <p className="text-xs text-muted-foreground">
+                Endpoint that echoes the caller IP, used to locate proxies. Leave empty to disable
+              </p>
+            </div>
+
             <div className="space-y-2">
               <Label htmlFor="healthcheck-status">Expected Status Code</Label>
               <Input
@@ -6,6 +6,7 @@ import {
   LogsResponse,
   SystemMetrics,
   Settings,
+  SettingsReloadStatus,
   AuthResponse,
   AddProxyRequest,
   UpdateProxyRequest,
@@ -288,6 +289,7 @@ class ApiClient {
   async updateSettings(settings: Partial<Settings>): Promise<{
     message: string
     config: Settings
+    reload: SettingsReloadStatus[]
   }> {
     return this.request("/api/v1/settings", {
       method: "PUT",
@@ -298,6 +300,7 @@ class ApiClient {
   async resetSettings(): Promise<{
     message: string
     config: Settings
+    reload: SettingsReloadStatus[]
   }> {
     return this.request("/api/v1/settings/reset", {
       method: "POST",
@@ -10,10 +10,41 @@ export interface Proxy {
   avg_response_time: number
   last_check: string
   username?: string
+  tags: Record<string, string>
+  exit_ip?: string
+  country?: string
+  city?: string
+  asn?: number
+  as_org?: string
   created_at: string
   updated_at: string
 }
 
+export interface ProxyPool {
+  id: number
+  name: string
+  description?: string
+  tags: Record<string, string>
+  method: string
+  proxy_count: number
+  created_at: string
+  updated_at: string
+}
+
+export interface ProxyRoute {
+  name: string
+  path_prefix: string
+  target: string
+  auth_required: boolean
+  rate_limit: {
+    enabled: boolean
+    interval: number
+    max_requests: number
+  }
+  pool?: string
+  tags?: Record<string, string>
+}
+
 export interface ProxiesResponse {
   proxies: Proxy[]
   pagination: {
@@ -33,6 +64,7 @@ export interface DashboardStats {
   request_growth: number
   success_rate_growth: number
   response_time_delta: number
+  bytes_today: number
 }
 
 export interface ChartDataPoint {
@@ -105,6 +137,7 @@ export interface Settings {
     rate_limited?: {
       max_requests_per_minute: number
       window_seconds: number
+      max_wait_seconds?: number
     }
     remove_unhealthy: boolean
     fallback: boolean
@@ -112,9 +145,26 @@ export interface Settings {
     follow_redirect: boolean
     timeout: number
     retries: number
+    retry_body_limit?: number
+    retry_non_idempotent?: boolean
     allowed_protocols: string[]
     max_response_time: number
     min_success_rate: number
+    sticky_session_ttl: number
+    circuit_breaker: {
+      failure_threshold: number
+      open_seconds: number
+      max_open_seconds: number
+      half_open_requests: number
+    }
+    blocked?: {
+      status_codes: number[]
+      body_patterns: string[]
+      max_retries: number
+      backoff_ms: number
+      max_backoff_ms: number
+      cooldown_seconds: number
+    }
   }
   rate_limit: {
     enabled: boolean
@@ -127,7 +177,9 @@ export interface Settings {
     url: string
     status: number
     headers: string[]
+    interval_minutes: number
     retest_failed_after_minutes: number
+    exit_ip_url: string
   }
   log_retention: {
     enabled: boolean
@@ -135,12 +187,22 @@ export interface Settings {
     compression_after_days: number
     cleanup_interval_hours: number
   }
+  webshare?: {
+    sync_interval_seconds: number
+  }
+}
+
+export interface SettingsReloadStatus {
+  subsystem: string
+  applied: boolean
+  error?: string
 }
 
 export interface AuthResponse {
   token: string
   user: {
     username: string
+    role: "admin" | "operator" | "viewer"
   }
 }
 
@@ -155,6 +217,8 @@ export interface AddProxyRequest {
   protocol: "http" | "https" | "socks4" | "socks4a" | "socks5"
   username?: string
   password?: string
+  tls_server_name?: string
+  ca_bundle?: string
 }
 
 export interface UpdateProxyRequest {
@@ -162,6 +226,8 @@ export interface UpdateProxyRequest {
   protocol?: "http" | "https" | "socks4" | "socks4a" | "socks5"
   username?: string
   password?: string
+  tls_server_name?: string
+  ca_bundle?: string
 }
 
 export interface BulkProxyRequest {
@@ -187,10 +253,114 @@ export interface ProxyTestResult {
   status: "active" | "failed"
   response_time?: number
   error?: string
+  exit_ip?: string
+  country?: string
   tested_at: string
   duration?: number // Alias for response_time for better clarity
 }
 
+export interface HealthCheckRun {
+  id: number
+  started_at: string
+  finished_at: string
+  checked: number
+  healthy: number
+  failed: number
+  resurrected: number
+  error?: string
+}
+
+export interface ProxyHealthCheck {
+  timestamp: string
+  success: boolean
+  response_time: number
+  error?: string
+}
+
+export interface ProxyHealthHistory {
+  proxy_id: number
+  window: "1h" | "6h" | "24h" | "7d" | "30d" | "90d"
+  checks: number
+  passed: number
+  uptime_percent: number | null
+  latency: {
+    p50: number
+    p90: number
+    p95: number
+    p99: number
+  } | null
+  results: ProxyHealthCheck[]
+}
+
+export interface ProxyRequest {
+  id: number
+  timestamp: string
+  proxy_id: number
+  client_id?: number
+  method: string
+  url?: string
+  status_code?: number
+  response_time: number
+  success: boolean
+  error?: string
+  bytes_sent: number
+  bytes_received: number
+  duration?: number
+  close_reason?: string
+}
+
+export interface ProxyRequestListResponse {
+  requests: ProxyRequest[]
+  next_cursor?: string
+}
+
+export interface ProxyRequestStats {
+  proxy_id: number
+  window: "1h" | "6h" | "24h" | "7d" | "30d" | "90d"
+  bucket: string
+  requests: number
+  successful: number
+  failed: number
+  success_rate: number | null
+  latency: {
+    p50: number
+    p90: number
+    p95: number
+    p99: number
+  } | null
+  buckets: {
+    time: string
+    requests: number
+    successful: number
+    failed: number
+    success_rate: number
+    avg_response_time: number
+  }[]
+  top_errors: {
+    error: string
+    count: number
+  }[]
+}
+
+export interface CircuitState {
+  proxy_id: number
+  state: "closed" | "open" | "half_open"
+  failures: number
+  trips: number
+  opened_at?: string
+  open_until?: string
+}
+
+export interface TransportStats {
+  proxy_id: number
+  proxy_address: string
+  open_connections: number
+  dials: number
+  requests: number
+  created_at: string
+  last_used: string
+}
+
 // Webshare Types
 export interface WebshareSyncInfo {
   synced_at: string